@@ -87,6 +87,8 @@ func SetEnv(prefix string, key string, val interface{}) error {
 		os.Setenv(fmt.Sprintf("%s_%s", prefix, key), strconv.Itoa(v))
 	case int64:
 		os.Setenv(fmt.Sprintf("%s_%s", prefix, key), strconv.FormatInt(v, 10))
+	case float64:
+		os.Setenv(fmt.Sprintf("%s_%s", prefix, key), strconv.FormatFloat(v, 'f', -1, 64))
 	case bool:
 		os.Setenv(fmt.Sprintf("%s_%s", prefix, key), strconv.FormatBool(v))
 	case []interface{}:
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
-	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 
 	"github.com/emso-c/konzek-go-assignment/config"
 	"github.com/emso-c/konzek-go-assignment/src/api"
 	"github.com/emso-c/konzek-go-assignment/src/database"
+	"github.com/emso-c/konzek-go-assignment/src/modules/jobs"
 	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
 	"github.com/joho/godotenv"
 )
 
@@ -32,9 +38,34 @@ func main() {
 	// Only the main function should close the logger
 	defer logger.Close()
 
+	// Reopen the log files on SIGHUP, after an external tool such as logrotate moved them
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if rErr := logger.Reopen(); rErr != nil {
+				log.Print("Error reopening log files: ", rErr)
+			}
+		}
+	}()
+
 	// Initialize database
 	db := database.GetDatabase()
-	defer db.Close()
+	defer database.Close()
+
+	// Run the migrate subcommand instead of the server if requested
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if mErr := migrate(os.Args[2:]); mErr != nil {
+			logger.Fatal(mErr.Error())
+		}
+		return
+	}
+
+	// Apply pending schema migrations
+	mErr := database.Migrate(db)
+	if mErr != nil {
+		logger.Fatal("Error migrating database", "error", mErr)
+	}
 
 	api.Init()
 	router := api.GetRouter()
@@ -42,12 +73,79 @@ func main() {
 	// Start the API server
 	host := os.Getenv("SERVER_HOST")
 	port := os.Getenv("SERVER_PORT")
+	server, sErr := api.NewServer(":"+port, router)
+	if sErr != nil {
+		logger.Fatal("Error configuring server", "error", sErr)
+	}
+
+	// Run the background jobs until shutdown, the server waits for the runner to stop before draining the worker pool
+	server.Go(jobs.GetRunner().Run)
+	// Autoscale the worker pool until shutdown
+	policy := worker_manager.GetAutoscalePolicy()
+	server.Go(func(ctx context.Context) {
+		worker_manager.GetWorkerManager().Autoscale(ctx, policy)
+	})
+
+	// Serve until SIGINT or SIGTERM, then drain in-flight requests and jobs
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	log.Print(
 		"Starting server on ",
 		"http://"+host+":"+port,
 	)
-	hErr := http.ListenAndServe(":"+port, router)
+	hErr := server.ListenAndServe(ctx)
 	if hErr != nil {
-		log.Fatal(hErr)
+		logger.Error("Error serving", "error", hErr)
+	}
+	// The deferred calls close the database and then the logger
+}
+
+// migrate runs the migrate subcommand:
+//
+//	app migrate [up]      applies every pending migration
+//	app migrate down [n]  rolls back the n most recent migrations, 1 by default
+//	app migrate status    lists the migrations and whether they are applied
+func migrate(args []string) error {
+	migrations, err := database.Migrations()
+	if err != nil {
+		return err
+	}
+	migrator := database.NewMigrator(database.GetDatabase(), migrations)
+
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	switch command {
+	case "up":
+		count, err := migrator.Up()
+		fmt.Printf("Applied %d migration(s)\n", count)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of migrations to roll back: %s", args[1])
+			}
+		}
+		count, err := migrator.Down(steps)
+		fmt.Printf("Rolled back %d migration(s)\n", count)
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%s\t%s\n", status.Migration, state)
+		}
+		return nil
 	}
+	return fmt.Errorf("unknown migrate command %q, expected up, down or status", command)
 }
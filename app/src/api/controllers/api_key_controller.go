@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/policy"
+	"github.com/emso-c/konzek-go-assignment/src/modules/validation"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
+)
+
+// APIKeyController represents the controller for handling the API keys of the authenticated user.
+type APIKeyController struct {
+	repo repositories.APIKeyRepository
+}
+
+// NewAPIKeyController creates a new instance of the APIKeyController using the given repository.
+func NewAPIKeyController(repo repositories.APIKeyRepository) *APIKeyController {
+	return &APIKeyController{repo: repo}
+}
+
+// validateAPIKeyRequest validates a new API key, see models.CreateAPIKeyRequest.
+// Scopes must be API key scopes granted by the role of the identity.
+func validateAPIKeyRequest(req models.CreateAPIKeyRequest, identity *auth.Identity) validation.Errors {
+	errs := validation.Struct(req)
+	if errs != nil {
+		return errs
+	}
+	owner := &auth.Identity{UserID: identity.UserID, Username: identity.Username, Role: identity.Role}
+	for _, scope := range req.Scopes {
+		if !policy.IsAPIKeyScope(scope) {
+			return validation.Errors{{Field: "scopes", Rule: "scope", Message: "invalid scope: " + scope}}
+		}
+		if !policy.Allowed(owner, policy.Permission(scope)) {
+			return validation.Errors{{Field: "scopes", Rule: "scope", Message: "scope not allowed for your role: " + scope}}
+		}
+	}
+	return nil
+}
+
+// CreateAPIKey creates a new API key for the authenticated user.
+// The key is only returned in this response, only its hash is stored.
+// Example:
+// HTTP POST http://localhost:8080/api/keys
+// Content-Type: application/json
+//
+//	{
+//		"name": "ci",
+//		"scopes": ["tasks:read"],
+//		"expiresAt": "2030-01-01T00:00:00Z"
+//	}
+func (kc *APIKeyController) CreateAPIKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("CreateAPIKey")
+		identity, ok := requireIdentity(w, r)
+		if !ok {
+			return
+		}
+
+		var req models.CreateAPIKeyRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			responses.Error(w, http.StatusBadRequest, "Error decoding request body")
+			logger.Error("Error decoding request body", "error", err)
+			return
+		}
+
+		if errs := validateAPIKeyRequest(req, identity); errs != nil {
+			responses.ValidationError(w, errs)
+			logger.Error("Invalid request body", "error", errs)
+			return
+		}
+
+		key, prefix, hash, err := auth.NewAPIKey()
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error generating API key")
+			logger.Error("Error generating API key", "error", err)
+			return
+		}
+
+		apiKey, err := kc.repo.Create(r.Context(), models.APIKey{
+			UserId:    identity.UserID,
+			Name:      req.Name,
+			Prefix:    prefix,
+			KeyHash:   hash,
+			Scopes:    req.Scopes,
+			ExpiresAt: req.ExpiresAt,
+		})
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error inserting API key into database")
+			logger.Error("Error inserting API key into database", "error", err)
+			return
+		}
+
+		logger.Info("API key created successfully")
+
+		responses.JSON(w, http.StatusCreated, models.CreateAPIKeyResponse{APIKey: apiKey, Key: key})
+	}
+}
+
+// GetAPIKeys lists the API keys of the authenticated user, including revoked and expired ones.
+// Example:
+// HTTP GET http://localhost:8080/api/keys
+func (kc *APIKeyController) GetAPIKeys() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("GetAPIKeys")
+		identity, ok := requireIdentity(w, r)
+		if !ok {
+			return
+		}
+
+		keys, err := kc.repo.ListByUser(r.Context(), identity.UserID)
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error getting API keys from database")
+			logger.Error("Error getting API keys from database", "error", err)
+			return
+		}
+
+		responses.JSON(w, http.StatusOK, keys)
+	}
+}
+
+// RevokeAPIKey revokes an API key of the authenticated user. Revoked keys are rejected immediately.
+// Example:
+// HTTP DELETE http://localhost:8080/api/keys/1
+func (kc *APIKeyController) RevokeAPIKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("RevokeAPIKey")
+		identity, ok := requireIdentity(w, r)
+		if !ok {
+			return
+		}
+		id, ok := parseID(w, r)
+		if !ok {
+			return
+		}
+
+		key, err := kc.repo.Revoke(r.Context(), id, identity.UserID)
+		if errors.Is(err, repositories.ErrAPIKeyNotFound) {
+			responses.Error(w, http.StatusNotFound, "API key not found")
+			logger.Error("API key not found")
+			return
+		}
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error revoking API key")
+			logger.Error("Error revoking API key", "error", err)
+			return
+		}
+
+		logger.Info("API key revoked successfully")
+
+		responses.JSON(w, http.StatusOK, key)
+	}
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyController(t *testing.T) {
+	setup()
+
+	repo := repositories.NewMemoryAPIKeyRepository()
+	kc := NewAPIKeyController(repo)
+
+	// Create
+	rr := postJSON(t, as(alice, kc.CreateAPIKey()), `{"name": "ci", "scopes": ["tasks:read"], "expiresAt": "2999-01-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var created models.CreateAPIKeyResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.NotContains(t, rr.Body.String(), "KeyHash")
+	prefix, ok := auth.ParseAPIKey(created.Key)
+	assert.True(t, ok)
+	assert.Equal(t, created.Prefix, prefix)
+	stored, err := repo.GetByPrefix(context.Background(), prefix)
+	assert.NoError(t, err)
+	assert.True(t, auth.CheckAPIKey(created.Key, stored.KeyHash))
+
+	// Create validation
+	assert.Equal(t, http.StatusUnprocessableEntity, postJSON(t, as(alice, kc.CreateAPIKey()), `{"name": "", "scopes": ["tasks:read"]}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, postJSON(t, as(alice, kc.CreateAPIKey()), `{"name": "ci", "scopes": []}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, postJSON(t, as(alice, kc.CreateAPIKey()), `{"name": "ci", "scopes": ["keys:manage"]}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, postJSON(t, as(alice, kc.CreateAPIKey()), `{"name": "ci", "scopes": ["tasks:any"]}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, postJSON(t, as(alice, kc.CreateAPIKey()), `{"name": "ci", "scopes": ["tasks:read"], "expiresAt": "2000-01-01T00:00:00Z"}`).Code)
+	assert.Equal(t, http.StatusCreated, postJSON(t, as(admin, kc.CreateAPIKey()), `{"name": "ops", "scopes": ["tasks:any"]}`).Code)
+	assert.Equal(t, http.StatusUnauthorized, postJSON(t, kc.CreateAPIKey(), `{"name": "ci", "scopes": ["tasks:read"]}`).Code)
+
+	// List only returns the keys of the user
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	as(alice, kc.GetAPIKeys()).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var keys []models.APIKey
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &keys))
+	assert.Len(t, keys, 1)
+	assert.Empty(t, keys[0].KeyHash)
+
+	// Revoke
+	revoke := func(identity *auth.Identity, id string) int {
+		req, err := http.NewRequest("DELETE", "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		rr := httptest.NewRecorder()
+		as(identity, kc.RevokeAPIKey()).ServeHTTP(rr, req)
+		return rr.Code
+	}
+	id := fmt.Sprintf("%d", created.Id)
+	assert.Equal(t, http.StatusNotFound, revoke(bob, id))
+	assert.Equal(t, http.StatusOK, revoke(alice, id))
+	assert.Equal(t, http.StatusNotFound, revoke(alice, "99"))
+	stored, _ = repo.GetByPrefix(context.Background(), prefix)
+	assert.NotNil(t, stored.RevokedAt)
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
+)
+
+// AuthController represents the controller for handling user registration and token issuance.
+type AuthController struct {
+	users  repositories.UserRepository
+	tokens *auth.TokenManager
+}
+
+// NewAuthController creates a new instance of the AuthController using the given repository and token manager.
+func NewAuthController(users repositories.UserRepository, tokens *auth.TokenManager) *AuthController {
+	return &AuthController{users: users, tokens: tokens}
+}
+
+// issueTokens issues a new access token and refresh token for the given user.
+func (ac *AuthController) issueTokens(ctx context.Context, user models.User) (models.TokenResponse, error) {
+	accessToken, err := ac.tokens.Issue(&auth.Identity{UserID: user.Id, Username: user.Username, Role: user.Role})
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	err = ac.users.SaveRefreshToken(ctx, user.Id, refreshHash, time.Now().Add(ac.tokens.RefreshTokenTTL))
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+	return models.TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(ac.tokens.AccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// Register creates a new user account.
+// Example:
+// HTTP POST http://localhost:8080/api/auth/register
+// Content-Type: application/json
+//
+//	{
+//		"username": "alice",
+//		"password": "correct horse battery staple"
+//	}
+func (ac *AuthController) Register() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("Register")
+
+		var req models.RegisterRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			responses.Error(w, http.StatusBadRequest, "Error decoding request body")
+			logger.Error("Error decoding request body", "error", err)
+			return
+		}
+
+		if !validateRequest(w, r, req) {
+			return
+		}
+
+		hash, err := auth.HashPassword(req.Password)
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error hashing password")
+			logger.Error("Error hashing password", "error", err)
+			return
+		}
+
+		user, err := ac.users.Create(r.Context(), req.Username, hash)
+		if errors.Is(err, repositories.ErrUserExists) {
+			responses.Error(w, http.StatusConflict, "Username is already taken")
+			logger.Error("Username is already taken")
+			return
+		}
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error inserting user into database")
+			logger.Error("Error inserting user into database", "error", err)
+			return
+		}
+
+		logger.Info("User registered successfully")
+
+		responses.JSON(w, http.StatusCreated, user)
+	}
+}
+
+// Login verifies the credentials of a user and issues an access token and a refresh token.
+// Example:
+// HTTP POST http://localhost:8080/api/auth/login
+// Content-Type: application/json
+//
+//	{
+//		"username": "alice",
+//		"password": "correct horse battery staple"
+//	}
+func (ac *AuthController) Login() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("Login")
+
+		var req models.LoginRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			responses.Error(w, http.StatusBadRequest, "Error decoding request body")
+			logger.Error("Error decoding request body", "error", err)
+			return
+		}
+		if !validateRequest(w, r, req) {
+			return
+		}
+
+		user, err := ac.users.GetByUsername(r.Context(), req.Username)
+		if err != nil && !errors.Is(err, repositories.ErrUserNotFound) {
+			responses.Error(w, http.StatusInternalServerError, "Could not get user from database")
+			logger.Error("Error getting user from database", "error", err)
+			return
+		}
+		// Unknown users and wrong passwords are reported the same way, and take as long to check
+		hash := user.PasswordHash
+		if err != nil {
+			hash = auth.DummyPasswordHash
+		}
+		if !auth.CheckPassword(req.Password, hash) || err != nil {
+			responses.Error(w, http.StatusUnauthorized, "Invalid username or password")
+			logger.Error("Invalid credentials", "username", req.Username)
+			return
+		}
+
+		tokens, err := ac.issueTokens(r.Context(), user)
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error issuing tokens")
+			logger.Error("Error issuing tokens", "error", err)
+			return
+		}
+
+		logger.Info("User logged in successfully")
+
+		responses.JSON(w, http.StatusOK, tokens)
+	}
+}
+
+// Refresh exchanges a refresh token for a new access token and refresh token.
+// Each refresh token can only be used once.
+// Example:
+// HTTP POST http://localhost:8080/api/auth/refresh
+// Content-Type: application/json
+//
+//	{
+//		"refresh_token": "..."
+//	}
+func (ac *AuthController) Refresh() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("Refresh")
+
+		var req models.RefreshRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			responses.Error(w, http.StatusBadRequest, "Error decoding request body")
+			logger.Error("Error decoding request body", "error", err)
+			return
+		}
+		if !validateRequest(w, r, req) {
+			return
+		}
+
+		userID, err := ac.users.ConsumeRefreshToken(r.Context(), auth.HashRefreshToken(req.RefreshToken))
+		if errors.Is(err, repositories.ErrRefreshTokenNotFound) {
+			responses.Error(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+			logger.Error("Invalid or expired refresh token")
+			return
+		}
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error consuming refresh token")
+			logger.Error("Error consuming refresh token", "error", err)
+			return
+		}
+
+		user, err := ac.users.Get(r.Context(), userID)
+		if errors.Is(err, repositories.ErrUserNotFound) {
+			responses.Error(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+			logger.Error("Refresh token issued to unknown user")
+			return
+		}
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Could not get user from database")
+			logger.Error("Error getting user from database", "error", err)
+			return
+		}
+
+		tokens, err := ac.issueTokens(r.Context(), user)
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error issuing tokens")
+			logger.Error("Error issuing tokens", "error", err)
+			return
+		}
+
+		logger.Info("Tokens refreshed successfully")
+
+		responses.JSON(w, http.StatusOK, tokens)
+	}
+}
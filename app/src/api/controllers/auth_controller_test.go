@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+// postJSON serves a POST request with the given body and returns the recorded response.
+func postJSON(t *testing.T, handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest("POST", "/", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAuthController(t *testing.T) {
+	setup()
+
+	tokens, err := auth.NewHS256TokenManager([]byte("0123456789abcdef0123456789abcdef"), "test", time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := repositories.NewMemoryUserRepository()
+	ac := NewAuthController(users, tokens)
+
+	// Register
+	rr := postJSON(t, ac.Register(), `{"username": "alice", "password": "correct horse"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "pbkdf2")
+
+	// Register validation
+	assert.Equal(t, http.StatusConflict, postJSON(t, ac.Register(), `{"username": "alice", "password": "correct horse"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, postJSON(t, ac.Register(), `{"username": "a", "password": "correct horse"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, postJSON(t, ac.Register(), `{"username": "bob", "password": "short"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, postJSON(t, ac.Register(), ``).Code)
+
+	// Login
+	assert.Equal(t, http.StatusUnauthorized, postJSON(t, ac.Login(), `{"username": "alice", "password": "wrong horse"}`).Code)
+	assert.Equal(t, http.StatusUnauthorized, postJSON(t, ac.Login(), `{"username": "bob", "password": "correct horse"}`).Code)
+
+	rr = postJSON(t, ac.Login(), `{"username": "alice", "password": "correct horse"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var login models.TokenResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &login))
+	assert.Equal(t, "Bearer", login.TokenType)
+	assert.Equal(t, 60, login.ExpiresIn)
+
+	claims, err := tokens.Verify(login.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", claims.Username)
+
+	// Refresh rotates the refresh token
+	rr = postJSON(t, ac.Refresh(), `{"refresh_token": "`+login.RefreshToken+`"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var refreshed models.TokenResponse
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &refreshed))
+	assert.NotEqual(t, login.RefreshToken, refreshed.RefreshToken)
+
+	// A refresh token cannot be reused
+	assert.Equal(t, http.StatusUnauthorized, postJSON(t, ac.Refresh(), `{"refresh_token": "`+login.RefreshToken+`"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, postJSON(t, ac.Refresh(), `{}`).Code)
+}
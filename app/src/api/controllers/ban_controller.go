@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/limiter"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/gorilla/mux"
+)
+
+// BanController represents the controller for managing the temporary bans of client IP addresses.
+type BanController struct {
+	bans *limiter.Bans
+}
+
+// NewBanController creates a new instance of the BanController for the given bans.
+func NewBanController(bans *limiter.Bans) *BanController {
+	return &BanController{bans: bans}
+}
+
+// GetBans lists the current bans, the latest expiring first.
+// Example:
+// HTTP GET http://localhost:8080/api/admin/bans
+func (bc *BanController) GetBans() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("GetBans")
+
+		bans, err := bc.bans.List(r.Context())
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error listing bans")
+			logger.Error("Error listing bans", "error", err)
+			return
+		}
+
+		responses.JSON(w, http.StatusOK, bans)
+	}
+}
+
+// CreateBan bans a client IP address for the given duration in seconds, or the duration of automatic bans.
+// An existing ban of the address is replaced.
+// Example:
+// HTTP POST http://localhost:8080/api/admin/bans
+// Content-Type: application/json
+//
+//	{
+//		"address": "203.0.113.7",
+//		"reason": "scraping",
+//		"duration": 3600
+//	}
+func (bc *BanController) CreateBan() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("CreateBan")
+
+		var req models.CreateBanRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			responses.Error(w, http.StatusBadRequest, "Error decoding request body")
+			logger.Error("Error decoding request body", "error", err)
+			return
+		}
+		if !validateRequest(w, r, req) {
+			return
+		}
+		duration := bc.bans.Duration()
+		if req.Duration > 0 {
+			duration = time.Duration(req.Duration) * time.Second
+		}
+		if req.Reason == "" {
+			req.Reason = "manual"
+		}
+
+		ban, err := bc.bans.Ban(r.Context(), req.Address, req.Reason, duration)
+		if errors.Is(err, limiter.ErrInvalidAddress) {
+			responses.Error(w, http.StatusBadRequest, "Invalid IP address")
+			logger.Error("Invalid IP address", "address", req.Address)
+			return
+		}
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error creating ban")
+			logger.Error("Error creating ban", "error", err)
+			return
+		}
+
+		logger.Info("Ban created successfully", "address", ban.Address, "expires_at", ban.ExpiresAt)
+
+		responses.JSON(w, http.StatusCreated, ban)
+	}
+}
+
+// DeleteBan lifts the ban of a client IP address.
+// Example:
+// HTTP DELETE http://localhost:8080/api/admin/bans/203.0.113.7
+func (bc *BanController) DeleteBan() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("DeleteBan")
+
+		address := mux.Vars(r)["address"]
+		err := bc.bans.Unban(r.Context(), address)
+		if errors.Is(err, limiter.ErrBanNotFound) {
+			responses.Error(w, http.StatusNotFound, "Ban not found")
+			logger.Error("Ban not found", "address", address)
+			return
+		}
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error deleting ban")
+			logger.Error("Error deleting ban", "error", err)
+			return
+		}
+
+		logger.Info("Ban deleted successfully", "address", address)
+
+		responses.JSON(w, http.StatusOK, nil)
+	}
+}
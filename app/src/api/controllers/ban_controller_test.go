@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/limiter"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBanController(t *testing.T) {
+	setup()
+
+	bans := limiter.NewBans(limiter.NewMemoryStore(), 5, time.Minute, 15*time.Minute)
+	bc := NewBanController(bans)
+
+	rr := postJSON(t, as(admin, bc.CreateBan()), `{"address": "203.0.113.7", "reason": "scraping", "duration": 3600}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var ban models.Ban
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &ban))
+	assert.Equal(t, "203.0.113.7", ban.Address)
+	assert.Equal(t, "scraping", ban.Reason)
+	assert.Equal(t, time.Hour, ban.ExpiresAt.Sub(ban.CreatedAt))
+
+	// The duration of automatic bans is used by default
+	rr = postJSON(t, as(admin, bc.CreateBan()), `{"address": "2001:db8::1"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &ban))
+	assert.Equal(t, "manual", ban.Reason)
+	assert.Equal(t, 15*time.Minute, ban.ExpiresAt.Sub(ban.CreatedAt))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, postJSON(t, as(admin, bc.CreateBan()), `{"address": "example.com"}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, postJSON(t, as(admin, bc.CreateBan()), `{"address": "203.0.113.8", "duration": -1}`).Code)
+	assert.Equal(t, http.StatusBadRequest, postJSON(t, as(admin, bc.CreateBan()), `address`).Code)
+
+	rr = get(t, as(admin, bc.GetBans()))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var list []models.Ban
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &list))
+	assert.Len(t, list, 2)
+	assert.Equal(t, "203.0.113.7", list[0].Address)
+
+	remove := func(address string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", "/", nil)
+		req = mux.SetURLVars(req, map[string]string{"address": address})
+		rr := httptest.NewRecorder()
+		as(admin, bc.DeleteBan())(rr, req)
+		return rr
+	}
+	assert.Equal(t, http.StatusOK, remove("203.0.113.7").Code)
+	assert.Equal(t, http.StatusNotFound, remove("203.0.113.7").Code)
+	_, banned, _ := bans.Get(context.Background(), "203.0.113.7")
+	assert.False(t, banned)
+}
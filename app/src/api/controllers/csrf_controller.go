@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/csrf"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+)
+
+// CSRFController represents the controller for issuing CSRF tokens.
+type CSRFController struct {
+	protector *csrf.Protector
+}
+
+// NewCSRFController creates a new instance of the CSRFController for the given protector.
+func NewCSRFController(protector *csrf.Protector) *CSRFController {
+	return &CSRFController{protector: protector}
+}
+
+// GetCSRFToken returns the CSRF token of the session, issuing one if needed, and sets the CSRF cookies.
+// Example:
+// HTTP GET http://localhost:8080/api/csrf
+func (cc *CSRFController) GetCSRFToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("GetCSRFToken")
+
+		token, err := cc.protector.Token(w, r)
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error issuing CSRF token")
+			logger.Error("Error issuing CSRF token", "error", err)
+			return
+		}
+		w.Header().Set(csrf.HeaderName, token)
+
+		responses.JSON(w, http.StatusOK, models.CSRFToken{Token: token})
+	}
+}
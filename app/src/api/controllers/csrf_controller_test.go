@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/csrf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSRFController(t *testing.T) {
+	setup()
+
+	protector, err := csrf.NewProtector([]byte("0123456789abcdef0123456789abcdef"), false, http.SameSiteStrictMode, time.Hour)
+	assert.NoError(t, err)
+	cc := NewCSRFController(protector)
+
+	rr := get(t, cc.GetCSRFToken())
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var token models.CSRFToken
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &token))
+	assert.NotEmpty(t, token.Token)
+	assert.Equal(t, token.Token, rr.Header().Get(csrf.HeaderName))
+	assert.Len(t, rr.Result().Cookies(), 2)
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/database"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/limiter"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
+)
+
+// HealthController represents the controller for the liveness, readiness and status endpoints.
+// Its handlers do not go through the worker pool, so that they answer even when it is saturated.
+type HealthController struct {
+	db        *sql.DB
+	migrator  *database.Migrator
+	workers   *worker_manager.WorkerManager
+	limiter   *limiter.Limiter
+	startedAt time.Time
+	// Timeout bounds the database checks of a single probe.
+	Timeout time.Duration
+}
+
+// NewHealthController creates a new instance of the HealthController for the given dependencies.
+func NewHealthController(db *sql.DB, migrator *database.Migrator, workers *worker_manager.WorkerManager, limiter *limiter.Limiter) *HealthController {
+	return &HealthController{
+		db:        db,
+		migrator:  migrator,
+		workers:   workers,
+		limiter:   limiter,
+		startedAt: time.Now(),
+		Timeout:   2 * time.Second,
+	}
+}
+
+// pendingMigrations returns the names of the migrations that are not applied yet.
+func (hc *HealthController) pendingMigrations(ctx context.Context) ([]string, error) {
+	pending, err := hc.migrator.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(pending))
+	for i, migration := range pending {
+		names[i] = migration.String()
+	}
+	return names, nil
+}
+
+// Liveness reports that the process is alive. It does not check any dependency.
+// Example:
+// HTTP GET http://localhost:8080/healthz
+func (hc *HealthController) Liveness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responses.JSON(w, http.StatusOK, models.HealthCheck{Status: "ok", Checks: map[string]string{}})
+	}
+}
+
+// Readiness reports whether the application can serve requests: the database answers within the timeout,
+// every migration is applied, and the worker pool queue has room for more jobs. Busy workers alone do not
+// make the application unready, queued jobs wait for them.
+// It answers 503 Service Unavailable with the failed checks otherwise, without the underlying errors
+// since the endpoint is public.
+// Example:
+// HTTP GET http://localhost:8080/readyz
+func (hc *HealthController) Readiness() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		ctx, cancel := context.WithTimeout(r.Context(), hc.Timeout)
+		defer cancel()
+
+		check := models.HealthCheck{Status: "ok", Checks: map[string]string{}}
+		fail := func(name string, reason string) {
+			check.Status = "unavailable"
+			check.Checks[name] = reason
+		}
+
+		check.Checks["database"] = "ok"
+		if err := hc.db.PingContext(ctx); err != nil {
+			fail("database", "unavailable")
+			logger.Error("Error pinging database", "error", err)
+		}
+
+		check.Checks["migrations"] = "ok"
+		if pending, err := hc.pendingMigrations(ctx); err != nil {
+			fail("migrations", "unavailable")
+			logger.Error("Error checking migrations", "error", err)
+		} else if len(pending) > 0 {
+			fail("migrations", "pending migrations: "+pending[0]+"...")
+		}
+
+		check.Checks["workers"] = "ok"
+		if capacity := hc.workers.QueueCapacity(); capacity > 0 && hc.workers.QueueLength() >= capacity {
+			fail("workers", "job queue is full")
+		}
+
+		if check.Status != "ok" {
+			responses.JSON(w, http.StatusServiceUnavailable, check)
+			return
+		}
+		responses.JSON(w, http.StatusOK, check)
+	}
+}
+
+// Status reports the database connection pool statistics, the worker statuses,
+// the pending migrations and the number of clients tracked by the rate limiter.
+// Example:
+// HTTP GET http://localhost:8080/api/status
+func (hc *HealthController) Status() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), hc.Timeout)
+		defer cancel()
+
+		status := models.SystemStatus{
+			Status:   "ok",
+			Uptime:   time.Since(hc.startedAt).Round(time.Second).String(),
+			Database: hc.db.Stats(),
+			Workers:  hc.workers.GetWorkerStatus(),
+		}
+		pending, err := hc.pendingMigrations(ctx)
+		if err != nil || len(pending) > 0 {
+			status.Status = "degraded"
+		}
+		status.LimiterSize, err = hc.limiter.Size(ctx)
+		if err != nil {
+			status.Status = "degraded"
+			logger.FromContext(r.Context()).Error("Error getting rate limiter size", "error", err)
+		}
+		status.PendingMigrations = pending
+
+		responses.JSON(w, http.StatusOK, status)
+	}
+}
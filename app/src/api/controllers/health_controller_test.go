@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/emso-c/konzek-go-assignment/src/database"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/limiter"
+	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
+	"github.com/stretchr/testify/assert"
+)
+
+// get serves a GET request and returns the recorded response.
+func get(t *testing.T, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	req, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+// newLimiter returns a rate limiter kept in memory.
+func newLimiter(t *testing.T) *limiter.Limiter {
+	l, err := limiter.NewLimiter(limiter.NewMemoryStore(), limiter.FixedWindow, 2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestHealthController(t *testing.T) {
+	setup()
+
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	migrator := database.NewMigrator(db, []database.Migration{{Version: 1, Name: "first"}, {Version: 2, Name: "second"}})
+	workers := worker_manager.NewWorkerManager(1, 1)
+	hc := NewHealthController(db, migrator, workers, newLimiter(t))
+
+	// Liveness does not touch the database
+	assert.Equal(t, http.StatusOK, get(t, hc.Liveness()).Code)
+
+	// Ready
+	mock.ExpectPing()
+	mock.ExpectQuery("SELECT version FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1).AddRow(2))
+	rr := get(t, hc.Readiness())
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Not ready with a pending migration and an unreachable database
+	mock.ExpectPing().WillReturnError(assert.AnError)
+	mock.ExpectQuery("SELECT version FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	rr = get(t, hc.Readiness())
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	var check models.HealthCheck
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &check))
+	assert.Equal(t, "ok", check.Checks["workers"])
+	assert.Equal(t, "unavailable", check.Checks["database"])
+	assert.NotContains(t, rr.Body.String(), assert.AnError.Error())
+	assert.Contains(t, check.Checks["migrations"], "0002_second")
+
+	// Busy workers do not make the application unready, a full queue does
+	release := make(chan struct{})
+	workers.AddJob(func() { <-release })
+	assert.Eventually(t, func() bool { return workers.QueueLength() == 0 }, time.Second, time.Millisecond)
+	mock.ExpectPing()
+	mock.ExpectQuery("SELECT version FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1).AddRow(2))
+	assert.Equal(t, http.StatusOK, get(t, hc.Readiness()).Code)
+	workers.AddJob(func() {})
+	mock.ExpectPing()
+	mock.ExpectQuery("SELECT version FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1).AddRow(2))
+	rr = get(t, hc.Readiness())
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), "job queue is full")
+	close(release)
+
+	// Status
+	mock.ExpectQuery("SELECT version FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	rr = get(t, hc.Status())
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var status models.SystemStatus
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	assert.Equal(t, "degraded", status.Status)
+	assert.Equal(t, []string{"0002_second"}, status.PendingMigrations)
+	assert.Len(t, status.Workers, 1)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
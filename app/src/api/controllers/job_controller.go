@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
+)
+
+// JobController represents the controller for inspecting and retrying background jobs.
+type JobController struct {
+	repo repositories.JobRepository
+}
+
+// NewJobController creates a new instance of the JobController using the given repository.
+func NewJobController(repo repositories.JobRepository) *JobController {
+	return &JobController{repo: repo}
+}
+
+// parseJobListOptions reads the pagination and filtering parameters of a job listing request.
+// Invalid pagination values fall back to their defaults, while an invalid status returns an error.
+func parseJobListOptions(r *http.Request) (repositories.JobListOptions, error) {
+	query := r.URL.Query()
+	var opts repositories.JobListOptions
+
+	opts.Limit, opts.Offset = parsePagination(query)
+
+	opts.Status = query.Get("status")
+	if opts.Status != "" && !models.IsValidJobStatus(opts.Status) {
+		return opts, fmt.Errorf("status must be one of: %s", strings.Join(models.JobStatuses, ", "))
+	}
+	opts.Type = query.Get("type")
+	return opts, nil
+}
+
+// GetJobs retrieves a page of background jobs, newest first, optionally filtered by status and type.
+// Example:
+// HTTP GET http://localhost:8080/api/jobs?status=dead&type=email.welcome&page=1&size=10
+func (jc *JobController) GetJobs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("GetJobs")
+
+		opts, err := parseJobListOptions(r)
+		if err != nil {
+			responses.Error(w, http.StatusBadRequest, err.Error())
+			logger.Error("Invalid job list parameters", "error", err)
+			return
+		}
+
+		jobs, err := jc.repo.List(r.Context(), opts)
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error getting jobs from database")
+			logger.Error("Error getting jobs from database", "error", err)
+			return
+		}
+
+		total, err := jc.repo.Count(r.Context(), opts.JobFilter)
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error counting jobs in database")
+			logger.Error("Error counting jobs in database", "error", err)
+			return
+		}
+
+		responses.Paginated(w, r, jobs, responses.Pagination{
+			Page:  opts.Offset/opts.Limit + 1,
+			Size:  opts.Limit,
+			Total: total,
+		})
+	}
+}
+
+// GetJob retrieves a background job by its ID.
+// HTTP GET http://localhost:8080/api/jobs/{id}
+func (jc *JobController) GetJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("GetJob")
+		id, ok := parseID(w, r)
+		if !ok {
+			return
+		}
+
+		job, err := jc.repo.Get(r.Context(), id)
+		if errors.Is(err, repositories.ErrJobNotFound) {
+			responses.Error(w, http.StatusNotFound, "Job not found")
+			logger.Error("Job not found", "id", id)
+			return
+		}
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error getting job from database")
+			logger.Error("Error getting job from database", "error", err)
+			return
+		}
+
+		responses.JSON(w, http.StatusOK, job)
+	}
+}
+
+// RetryJob moves a dead background job back to pending, with its attempts reset, so that it runs again.
+// HTTP POST http://localhost:8080/api/jobs/{id}/retry
+func (jc *JobController) RetryJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("RetryJob")
+		id, ok := parseID(w, r)
+		if !ok {
+			return
+		}
+
+		job, err := jc.repo.Retry(r.Context(), id)
+		if errors.Is(err, repositories.ErrJobNotFound) {
+			responses.Error(w, http.StatusNotFound, "Job not found")
+			logger.Error("Job not found", "id", id)
+			return
+		}
+		if errors.Is(err, repositories.ErrJobNotRetryable) {
+			responses.Error(w, http.StatusConflict, "Only dead jobs can be retried")
+			logger.Error("Job is not dead", "id", id)
+			return
+		}
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error retrying job")
+			logger.Error("Error retrying job", "error", err)
+			return
+		}
+
+		logger.Info("Job retried successfully", "id", id, "type", job.Type)
+
+		responses.JSON(w, http.StatusOK, job)
+	}
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobController(t *testing.T) {
+	setup()
+
+	repo := repositories.NewMemoryJobRepository()
+	jc := NewJobController(repo)
+	ctx := context.Background()
+	completed, _ := repo.Enqueue(ctx, models.Job{Type: "email", MaxAttempts: 1})
+	repo.Claim(ctx)
+	repo.Complete(ctx, completed.Id)
+	dead, _ := repo.Enqueue(ctx, models.Job{Type: "email", MaxAttempts: 1})
+	repo.Claim(ctx)
+	repo.Fail(ctx, dead.Id, "boom", time.Time{})
+
+	list := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/jobs"+query, nil)
+		rr := httptest.NewRecorder()
+		as(admin, jc.GetJobs())(rr, req)
+		return rr
+	}
+	rr := list("?status=dead")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var page struct {
+		responses.PageEnvelope
+		Items []models.Job `json:"items"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+	assert.Equal(t, 1, page.Total)
+	assert.Equal(t, dead.Id, page.Items[0].Id)
+	assert.Equal(t, "boom", page.Items[0].LastError)
+	assert.Equal(t, http.StatusBadRequest, list("?status=lost").Code)
+
+	withID := func(handler http.HandlerFunc, method string, id uint) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/", nil)
+		req = mux.SetURLVars(req, map[string]string{"id": fmt.Sprintf("%d", id)})
+		rr := httptest.NewRecorder()
+		as(admin, handler)(rr, req)
+		return rr
+	}
+	rr = withID(jc.GetJob(), "GET", completed.Id)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var job models.Job
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &job))
+	assert.Equal(t, models.JobCompleted, job.Status)
+	assert.Equal(t, http.StatusNotFound, withID(jc.GetJob(), "GET", 99).Code)
+
+	// Only dead jobs can be retried
+	assert.Equal(t, http.StatusConflict, withID(jc.RetryJob(), "POST", completed.Id).Code)
+	assert.Equal(t, http.StatusNotFound, withID(jc.RetryJob(), "POST", 99).Code)
+	rr = withID(jc.RetryJob(), "POST", dead.Id)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &job))
+	assert.Equal(t, models.JobPending, job.Status)
+	assert.Equal(t, 0, job.Attempts)
+}
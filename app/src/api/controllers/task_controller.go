@@ -1,124 +1,336 @@
 // Package controllers provides HTTP request handlers for managing tasks in the system.
 // It includes methods for retrieving tasks, creating new tasks, updating existing tasks,
-// and deleting tasks.
+// and deleting tasks, as well as registering users, issuing their access tokens and managing their API keys.
 //
 // Usage:
-// Use the TaskController type to create a new instance of the controller.
-// Use the GetTasks, GetTask, CreateTask, UpdateTask, and DeleteTask methods to handle HTTP requests.
+// Use the NewTaskController function to create a new instance of the controller,
+// passing in the repositories.TaskRepository that should be used to store tasks.
+// Use the GetTasks, GetTask, CreateTask, UpdateTask, TransitionTask, and DeleteTask methods to handle HTTP requests.
+// Request bodies are validated against the `validate` tags of their models, see validation.Struct,
+// and invalid fields are reported with a 422 Unprocessable Entity response.
 //
 // Example:
-// tc := NewTaskController()
-// http.HandleFunc("/api/tasks", tc.GetTasks(db))
-// http.HandleFunc("/api/task/{id}", tc.GetTask(db))
-// http.HandleFunc("/api/tasks", tc.CreateTask(db))
-// http.HandleFunc("/api/tasks", tc.UpdateTask(db))
-// http.HandleFunc("/api/task/{id}", tc.DeleteTask(db))
+// tc := NewTaskController(repositories.NewPostgresTaskRepository(db))
+// http.HandleFunc("/api/tasks", tc.GetTasks())
+// http.HandleFunc("/api/task/{id}", tc.GetTask())
+// http.HandleFunc("/api/tasks", tc.CreateTask())
+// http.HandleFunc("/api/tasks", tc.UpdateTask())
+// http.HandleFunc("/api/task/{id}/transition", tc.TransitionTask())
+// http.HandleFunc("/api/task/{id}", tc.DeleteTask())
 package controllers
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/emso-c/konzek-go-assignment/src/api/responses"
 	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
 	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/policy"
+	"github.com/emso-c/konzek-go-assignment/src/modules/validation"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
 	"github.com/gorilla/mux"
 )
 
 // TaskController represents the controller for handling task-related HTTP requests.
-type TaskController struct{}
+type TaskController struct {
+	repo repositories.TaskRepository
+}
 
-// NewTaskController creates a new instance of the TaskController.
-func NewTaskController() *TaskController {
-	return &TaskController{}
+// NewTaskController creates a new instance of the TaskController using the given repository.
+func NewTaskController(repo repositories.TaskRepository) *TaskController {
+	return &TaskController{repo: repo}
 }
 
-// GetTasks retrieves a list of tasks from the database based on pagination parameters.
-// HTTP GET http://localhost:8080/api/tasks
-func (tc *TaskController) GetTasks(db *sql.DB) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var logger = logger.GetLogger()
-		logger.Info("GetTasks")
+// parseID reads and validates the numeric {id} route variable.
+// It writes a 400 Bad Request response and returns false if the ID is missing or invalid.
+func parseID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	var logger = logger.FromContext(r.Context())
+	vars := mux.Vars(r)
+	if vars == nil || vars["id"] == "" {
+		responses.Error(w, http.StatusBadRequest, "ID is required")
+		logger.Error("ID is required")
+		return 0, false
+	}
+	id, err := strconv.ParseUint(vars["id"], 10, 0)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, "ID must be numeric")
+		logger.Error("ID must be numeric")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// validateRequest validates the decoded request body, see validation.Struct.
+// It writes a 422 Unprocessable Entity response listing the invalid fields and returns false if it is invalid.
+func validateRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	errs := validation.Struct(req)
+	if errs != nil {
+		responses.ValidationError(w, errs)
+		logger.FromContext(r.Context()).Error("Invalid request body", "error", errs)
+		return false
+	}
+	return true
+}
 
-		// Parse pagination parameters from the query string
-		pageStr := r.URL.Query().Get("page")
-		sizeStr := r.URL.Query().Get("size")
+// dateLayout is the layout accepted for date-only query parameters.
+const dateLayout = "2006-01-02"
 
-		// Set default values if not provided
-		page, err := strconv.Atoi(pageStr)
-		if err != nil || page < 1 {
-			page = 1
+// parseDate parses an RFC 3339 timestamp or a YYYY-MM-DD date from the given query parameter.
+// Date-only values are treated as the start of the day, or as the end of the day if endOfDay is set,
+// so that both bounds of a date range are inclusive.
+func parseDate(name string, value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", name)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+const (
+	// defaultPageSize is the size of the pages of a listing when the size parameter is not set.
+	defaultPageSize = 10
+	// maxPageSize is the largest size of the pages of a listing, larger sizes are clamped to it.
+	maxPageSize = 100
+	// maxPageOffset is the largest offset of a page, later pages are clamped to it.
+	maxPageOffset = math.MaxInt32
+)
+
+// parsePagination reads the page and size parameters of a listing request and returns the limit and offset of the page.
+// Invalid values fall back to their defaults, the size is clamped to maxPageSize and the page so that its offset
+// is at most maxPageOffset.
+func parsePagination(query url.Values) (int, int) {
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	size, err := strconv.Atoi(query.Get("size"))
+	if err != nil || size < 1 {
+		size = defaultPageSize
+	}
+	if size > maxPageSize {
+		size = maxPageSize
+	}
+	if page > maxPageOffset/size+1 {
+		page = maxPageOffset/size + 1
+	}
+	return size, (page - 1) * size
+}
+
+// parseTaskListOptions reads the pagination, filtering and sorting parameters of a task listing request.
+// Invalid pagination values fall back to their defaults, while invalid filters, sort options
+// and cursors return an error.
+func parseTaskListOptions(r *http.Request) (repositories.TaskListOptions, error) {
+	query := r.URL.Query()
+	var opts repositories.TaskListOptions
+
+	opts.Limit, opts.Offset = parsePagination(query)
+
+	// Filters
+	opts.Status = query.Get("status")
+	opts.Title = query.Get("title")
+	opts.Description = query.Get("description")
+	opts.Query = strings.TrimSpace(query.Get("q"))
+
+	dates := []struct {
+		name     string
+		target   *time.Time
+		endOfDay bool
+	}{
+		{"created_after", &opts.CreatedAfter, false},
+		{"created_before", &opts.CreatedBefore, true},
+		{"updated_after", &opts.UpdatedAfter, false},
+		{"updated_before", &opts.UpdatedBefore, true},
+	}
+	for _, d := range dates {
+		var err error
+		if *d.target, err = parseDate(d.name, query.Get(d.name), d.endOfDay); err != nil {
+			return opts, err
 		}
+	}
 
-		size, err := strconv.Atoi(sizeStr)
-		if err != nil || size < 1 {
-			size = 10 // Default page size
+	// Sorting
+	opts.SortBy = query.Get("sort")
+	if opts.SortBy == "" {
+		opts.SortBy = "id"
+	}
+	if !repositories.IsTaskSortColumn(opts.SortBy) {
+		return opts, fmt.Errorf("sort must be one of: %s", strings.Join(repositories.TaskSortColumns, ", "))
+	}
+	switch strings.ToLower(query.Get("order")) {
+	case "", "asc":
+		opts.SortDesc = false
+	case "desc":
+		opts.SortDesc = true
+	default:
+		return opts, fmt.Errorf("order must be either asc or desc")
+	}
+
+	// Keyset pagination
+	if after := query.Get("after"); after != "" {
+		afterID, err := strconv.ParseUint(after, 10, 0)
+		if err != nil {
+			return opts, fmt.Errorf("after must be a numeric task ID")
+		}
+		if opts.SortBy != "id" {
+			return opts, fmt.Errorf("after can only be used when sorting by id")
 		}
+		opts.AfterID = uint(afterID)
+		opts.Offset = 0
+	}
 
-		// Calculate offset
-		offset := (page - 1) * size
+	return opts, nil
+}
 
-		var tasks []models.Task
+// requireIdentity returns the authenticated identity of the request.
+// It writes a 401 Unauthorized response and returns false for anonymous requests.
+func requireIdentity(w http.ResponseWriter, r *http.Request) (*auth.Identity, bool) {
+	identity := auth.FromContext(r.Context())
+	if identity == nil {
+		responses.Error(w, http.StatusUnauthorized, "Authentication required")
+		logger.FromContext(r.Context()).Error("Anonymous request to a task endpoint")
+		return nil, false
+	}
+	return identity, true
+}
 
-		rows, err := db.Query(fmt.Sprintf("SELECT * FROM tasks ORDER BY id LIMIT %d OFFSET %d", size, offset))
+// ownerScope returns the ID of the user whose tasks the identity is restricted to,
+// or 0 if the identity can access the tasks of every user.
+func ownerScope(identity *auth.Identity) uint {
+	if policy.Allowed(identity, policy.TasksAny) {
+		return 0
+	}
+	return identity.UserID
+}
+
+// findTask retrieves the task with the given ID if it is visible to the identity.
+// Tasks of other users are reported as not found so that their existence is not disclosed.
+// It writes an error response and returns false if the task cannot be retrieved.
+func (tc *TaskController) findTask(w http.ResponseWriter, r *http.Request, identity *auth.Identity, id uint) (models.Task, bool) {
+	var logger = logger.FromContext(r.Context()).With("id", id)
+	task, err := tc.repo.Get(r.Context(), id)
+	if err == nil && ownerScope(identity) != 0 && task.OwnerId != identity.UserID {
+		err = repositories.ErrTaskNotFound
+	}
+	if errors.Is(err, repositories.ErrTaskNotFound) {
+		responses.Error(w, http.StatusNotFound, "Task not found")
+		logger.Error("Task not found")
+		return models.Task{}, false
+	}
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, "Could not get task from database")
+		logger.Error("Error getting task from database", "error", err)
+		return models.Task{}, false
+	}
+	return task, true
+}
+
+// GetTasks retrieves a list of tasks from the database based on pagination, filtering and sorting parameters.
+//
+// Query parameters:
+//   - page, size: pagination, defaults to page 1 with 10 tasks
+//   - status: exact status match
+//   - title, description: case-insensitive substring match
+//   - q: full-text search over the title and description
+//   - created_after, created_before, updated_after, updated_before: inclusive RFC 3339 or YYYY-MM-DD bounds
+//   - sort: one of id, title, description, status, created_at, updated_at, defaults to id
+//   - order: asc or desc, defaults to asc
+//   - after: switches to keyset pagination, returning the tasks past the given ID; requires sorting by id
+//
+// The tasks are returned in a responses.PageEnvelope with the total number of matching tasks
+// and links to the next and previous pages. Cursor mode only provides a next link.
+//
+// HTTP GET http://localhost:8080/api/tasks?status=pending&q=report&sort=created_at&order=desc
+func (tc *TaskController) GetTasks() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("GetTasks")
+		identity, ok := requireIdentity(w, r)
+		if !ok {
+			return
+		}
+
+		opts, err := parseTaskListOptions(r)
+		if err != nil {
+			responses.Error(w, http.StatusBadRequest, err.Error())
+			logger.Error("Invalid task list parameters", "error", err)
+			return
+		}
+		opts.OwnerID = ownerScope(identity)
+
+		cursor := r.URL.Query().Get("after") != ""
+		size := opts.Limit
+		if cursor {
+			// Fetch one extra task to know whether there is a next page
+			opts.Limit++
+		}
+
+		tasks, err := tc.repo.List(r.Context(), opts)
 		if err != nil {
 			responses.Error(w, http.StatusInternalServerError, "Error getting tasks from database")
-			logger.Error("Error getting tasks from database: " + err.Error())
+			logger.Error("Error getting tasks from database", "error", err)
 			return
 		}
-		defer rows.Close()
 
-		for rows.Next() {
-			var task models.Task
-			err = rows.Scan(&task.Id, &task.Title, &task.Description, &task.Status, &task.CreatedAt, &task.UpdatedAt)
-			if err != nil {
-				responses.Error(w, http.StatusInternalServerError, "Error scanning tasks from database")
-				logger.Error("Error scanning tasks from database: " + err.Error())
-				return
-			}
-			tasks = append(tasks, task)
+		total, err := tc.repo.Count(r.Context(), opts.TaskFilter)
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error counting tasks in database")
+			logger.Error("Error counting tasks in database", "error", err)
+			return
 		}
 
 		logger.Info("Tasks retrieved successfully from database")
 
-		if tasks == nil {
-			responses.JSON(w, http.StatusNoContent, tasks)
-			return
+		pagination := responses.Pagination{
+			Page:   opts.Offset/size + 1,
+			Size:   size,
+			Total:  total,
+			Cursor: cursor,
+		}
+		if cursor && len(tasks) > size {
+			tasks = tasks[:size]
+			pagination.NextCursor = strconv.FormatUint(uint64(tasks[size-1].Id), 10)
 		}
 
-		responses.JSON(w, http.StatusOK, tasks)
+		responses.Paginated(w, r, tasks, pagination)
 	}
 }
 
 // GetTask retrieves a task by its ID from the database.
 // HTTP GET http://localhost:8080/api/task/{id}
-func (tc *TaskController) GetTask(db *sql.DB) http.HandlerFunc {
+func (tc *TaskController) GetTask() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var logger = logger.GetLogger()
+		var logger = logger.FromContext(r.Context())
 		logger.Info("GetTask")
-		vars := mux.Vars(r)
-		if vars == nil || vars["id"] == "" {
-			responses.Error(w, http.StatusBadRequest, "ID is required")
-			logger.Error("ID is required")
+		identity, ok := requireIdentity(w, r)
+		if !ok {
 			return
 		}
-		logger.Info("ID is:" + vars["id"])
-		id := vars["id"]
-		if _, err := strconv.Atoi(id); err != nil {
-			responses.Error(w, http.StatusBadRequest, "ID must be numeric")
-			logger.Error("ID must be numeric")
+		id, ok := parseID(w, r)
+		if !ok {
 			return
 		}
+		logger.Debug("Getting task", "id", id)
 
-		var task models.Task
-
-		err := db.QueryRow("SELECT * FROM tasks WHERE id = $1", id).Scan(&task.Id, &task.Title, &task.Description, &task.Status, &task.CreatedAt, &task.UpdatedAt)
-		if err != nil {
-			responses.Error(w, http.StatusInternalServerError, "Could not get task from database")
-			logger.Error("Error getting task from database" + err.Error())
+		task, ok := tc.findTask(w, r, identity, id)
+		if !ok {
 			return
 		}
 
@@ -138,36 +350,61 @@ func (tc *TaskController) GetTask(db *sql.DB) http.HandlerFunc {
 //		"description": "Description of task 1",
 //		"status": "pending"
 //	}
-func (tc *TaskController) CreateTask(db *sql.DB) http.HandlerFunc {
+//
+// The status is optional and new tasks must start as pending.
+func (tc *TaskController) CreateTask() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var logger = logger.GetLogger()
+		var logger = logger.FromContext(r.Context())
 		logger.Info("CreateTask")
+		identity, ok := requireIdentity(w, r)
+		if !ok {
+			return
+		}
 
 		var req models.CreateTaskRequest
 
 		err := json.NewDecoder(r.Body).Decode(&req)
 		if err != nil {
 			responses.Error(w, http.StatusBadRequest, "Error decoding request body")
-			logger.Error("Error decoding request body:" + err.Error())
+			logger.Error("Error decoding request body", "error", err)
+			return
+		}
+		if !validateRequest(w, r, req) {
 			return
 		}
 
-		_, err = db.Exec(fmt.Sprintf("INSERT INTO tasks (title, description, status) VALUES ('%s', '%s', '%s')", req.Title, req.Description, req.Status))
+		if req.Status == "" {
+			req.Status = models.StatusPending
+		}
+		if req.Status != models.StatusPending {
+			message := fmt.Sprintf("new tasks must start as %q", models.StatusPending)
+			if err := models.ValidateTransition("", req.Status); err != nil {
+				message = err.Error()
+			}
+			responses.Error(w, http.StatusUnprocessableEntity, message)
+			logger.Error("Invalid status for new task", "status", req.Status)
+			return
+		}
+
+		task, err := tc.repo.Create(r.Context(), req, identity.UserID)
 		if err != nil {
 			responses.Error(w, http.StatusInternalServerError, "Error inserting task into database")
-			logger.Error("Error inserting task into database:" + err.Error())
+			logger.Error("Error inserting task into database", "error", err)
 			return
 		}
 
 		logger.Info("Task inserted successfully into database")
 
-		responses.JSON(w, http.StatusCreated, nil)
+		responses.JSON(w, http.StatusCreated, task)
 	}
 }
 
 // UpdateTask updates an existing task in the database based on the provided request body.
+// The ID in the route takes precedence over the ID in the request body.
+// An empty status keeps the current one, otherwise the change must be allowed by models.TaskStatusTransitions.
+// It responds with 409 Conflict if the task status was changed by another request in the meantime.
 // Example:
-// HTTP PUT http://localhost:8080/api/tasks
+// HTTP PUT http://localhost:8080/api/task/{id}
 // Content-Type: application/json
 //
 //	{
@@ -176,60 +413,168 @@ func (tc *TaskController) CreateTask(db *sql.DB) http.HandlerFunc {
 //		"description": "Description of task 1",
 //		"status": "completed"
 //	}
-func (tc *TaskController) UpdateTask(db *sql.DB) http.HandlerFunc {
+func (tc *TaskController) UpdateTask() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var logger = logger.GetLogger()
+		var logger = logger.FromContext(r.Context())
 		logger.Info("UpdateTask")
+		identity, ok := requireIdentity(w, r)
+		if !ok {
+			return
+		}
 
 		var task models.Task
 
 		err := json.NewDecoder(r.Body).Decode(&task)
 		if err != nil {
 			responses.Error(w, http.StatusBadRequest, "Error decoding request body")
-			logger.Error("Error decoding request body:" + err.Error())
+			logger.Error("Error decoding request body", "error", err)
 			return
 		}
 
-		// _, err = db.Exec("UPDATE tasks SET title = $1, description = $2, status = $3, updated_at = $4 WHERE id = $5", task.Title, task.Description, task.Status, task.UpdatedAt, task.Id)
-		_, err = db.Exec(fmt.Sprintf("UPDATE tasks SET title = '%s', description = '%s', status = '%s', updated_at = '%s' WHERE id = %d", task.Title, task.Description, task.Status, task.UpdatedAt, task.Id))
+		if !validateRequest(w, r, task) {
+			return
+		}
+
+		if vars := mux.Vars(r); vars != nil && vars["id"] != "" {
+			id, ok := parseID(w, r)
+			if !ok {
+				return
+			}
+			task.Id = id
+		}
+
+		existing, ok := tc.findTask(w, r, identity, task.Id)
+		if !ok {
+			return
+		}
 
+		if task.Status == "" {
+			task.Status = existing.Status
+		}
+		if err := models.ValidateTransition(existing.Status, task.Status); err != nil {
+			responses.Error(w, http.StatusUnprocessableEntity, err.Error())
+			logger.Error("Invalid status transition", "error", err)
+			return
+		}
+
+		// Update the task only if it is still in the status the transition was validated from
+		updated, err := tc.repo.Update(r.Context(), task, existing.Status)
+		if errors.Is(err, repositories.ErrTaskNotFound) {
+			responses.Error(w, http.StatusNotFound, "Task not found")
+			logger.Error("Task not found")
+			return
+		}
+		if errors.Is(err, repositories.ErrTaskStatusChanged) {
+			responses.Error(w, http.StatusConflict, "Task status was changed by another request, please retry")
+			logger.Error("Task status changed concurrently")
+			return
+		}
 		if err != nil {
 			responses.Error(w, http.StatusInternalServerError, "Error updating task in database")
-			logger.Error("Error updating task in database:" + err.Error())
+			logger.Error("Error updating task in database", "error", err)
 			return
 		}
 
 		logger.Info("Task updated successfully in database")
 
-		responses.JSON(w, http.StatusOK, task)
+		responses.JSON(w, http.StatusOK, updated)
+	}
+}
+
+// TransitionTask moves a task to a new status if the change is allowed by models.TaskStatusTransitions.
+// It responds with 422 Unprocessable Entity describing the allowed transitions if it is not,
+// and with 409 Conflict if the task status was changed by another request in the meantime.
+// Example:
+// HTTP POST http://localhost:8080/api/task/{id}/transition
+// Content-Type: application/json
+//
+//	{
+//		"status": "in_progress"
+//	}
+func (tc *TaskController) TransitionTask() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("TransitionTask")
+		identity, ok := requireIdentity(w, r)
+		if !ok {
+			return
+		}
+		id, ok := parseID(w, r)
+		if !ok {
+			return
+		}
+
+		var req models.TransitionTaskRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			responses.Error(w, http.StatusBadRequest, "Error decoding request body")
+			logger.Error("Error decoding request body", "error", err)
+			return
+		}
+		if !validateRequest(w, r, req) {
+			return
+		}
+
+		task, ok := tc.findTask(w, r, identity, id)
+		if !ok {
+			return
+		}
+
+		if err := models.ValidateTransition(task.Status, req.Status); err != nil {
+			responses.Error(w, http.StatusUnprocessableEntity, err.Error())
+			logger.Error("Invalid status transition", "error", err)
+			return
+		}
+
+		updated, err := tc.repo.Transition(r.Context(), id, task.Status, req.Status)
+		if errors.Is(err, repositories.ErrTaskStatusChanged) {
+			responses.Error(w, http.StatusConflict, "Task status was changed by another request, please retry")
+			logger.Error("Task status changed concurrently")
+			return
+		}
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error updating task in database")
+			logger.Error("Error updating task in database", "error", err)
+			return
+		}
+
+		logger.Info("Task status updated successfully in database")
+
+		responses.JSON(w, http.StatusOK, updated)
 	}
 }
 
 // DeleteTask deletes a task from the database based on its ID.
 // Example:
 // HTTP DELETE http://localhost:8080/api/task/{id}
-func (tc *TaskController) DeleteTask(db *sql.DB) http.HandlerFunc {
+func (tc *TaskController) DeleteTask() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		var logger = logger.GetLogger()
+		var logger = logger.FromContext(r.Context())
 		logger.Info("DeleteTask")
-		vars := mux.Vars(r)
-		if vars == nil || vars["id"] == "" {
-			responses.Error(w, http.StatusBadRequest, "ID is required")
-			logger.Error("ID is required")
+		identity, ok := requireIdentity(w, r)
+		if !ok {
+			return
+		}
+		id, ok := parseID(w, r)
+		if !ok {
 			return
 		}
-		id := vars["id"]
-		if _, err := strconv.Atoi(id); err != nil {
-			responses.Error(w, http.StatusBadRequest, "ID must be numeric")
-			logger.Error("ID must be numeric")
+
+		if _, ok := tc.findTask(w, r, identity, id); !ok {
 			return
 		}
 
-		_, err := db.Exec(fmt.Sprintf("DELETE FROM tasks WHERE id = %s", id))
+		err := tc.repo.Delete(r.Context(), id)
+		if errors.Is(err, repositories.ErrTaskNotFound) {
+			responses.Error(w, http.StatusNotFound, "Task not found")
+			logger.Error("Task not found")
+			return
+		}
 		if err != nil {
 			responses.Error(w, http.StatusInternalServerError, "Error deleting task from database")
-			logger.Error("Error deleting task from database:" + err.Error())
+			logger.Error("Error deleting task from database", "error", err)
 			return
 		}
 
@@ -2,19 +2,23 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
-	"regexp"
+	"strings"
 	"testing"
-	"time"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/emso-c/konzek-go-assignment/config"
 	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
+	"github.com/emso-c/konzek-go-assignment/src/modules/policy"
+	"github.com/emso-c/konzek-go-assignment/src/modules/validation"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 )
@@ -27,20 +31,43 @@ func setup() {
 	os.Setenv("LOGGER_DISABLED", "true")
 }
 
-func TestCreateTask(t *testing.T) {
-	setup()
+// Identities used to call the handlers.
+var (
+	alice = &auth.Identity{UserID: 1, Username: "alice", Role: policy.RoleUser}
+	bob   = &auth.Identity{UserID: 2, Username: "bob", Role: policy.RoleUser}
+	admin = &auth.Identity{UserID: 3, Username: "admin", Role: policy.RoleAdmin}
+)
+
+// as wraps a handler so that it is called by the given identity, as if authenticated by AuthMiddleware.
+func as(identity *auth.Identity, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r.WithContext(auth.NewContext(r.Context(), identity)))
+	}
+}
 
-	db, mock, err := sqlmock.New()
+// seedTask creates a task owned by alice in the given repository and fails the test on error.
+func seedTask(t *testing.T, repo repositories.TaskRepository) models.Task {
+	task, err := repo.Create(context.Background(), models.CreateTaskRequest{
+		Title:       "Test Task",
+		Description: "Test Description",
+		Status:      "pending",
+	}, alice.UserID)
 	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		t.Fatal(err)
 	}
-	defer db.Close()
+	return task
+}
+
+func TestCreateTask(t *testing.T) {
+	setup()
+
+	repo := repositories.NewMemoryTaskRepository()
 
 	// Create a new task
 	task := models.CreateTaskRequest{
 		Title:       "Test Task",
 		Description: "Test Description",
-		Status:      "Pending",
+		Status:      "pending",
 	}
 	// Convert task to JSON
 	taskJSON, err := json.Marshal(task)
@@ -48,11 +75,8 @@ func TestCreateTask(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	expectedQuery := fmt.Sprintf("INSERT INTO tasks (title, description, status) VALUES ('%s', '%s', '%s')", task.Title, task.Description, task.Status)
-	mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).WillReturnResult(sqlmock.NewResult(1, 1))
-
-	tc := NewTaskController()
-	handler := http.HandlerFunc(tc.CreateTask(db))
+	tc := NewTaskController(repo)
+	handler := as(alice, tc.CreateTask())
 
 	// Valid request
 	req, err := http.NewRequest("POST", "/", bytes.NewBufferString(string(taskJSON)))
@@ -63,6 +87,36 @@ func TestCreateTask(t *testing.T) {
 	handler.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusCreated, rr.Code)
 
+	var created models.Task
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, uint(1), created.Id)
+	assert.Equal(t, task.Title, created.Title)
+
+	count, _ := repo.Count(context.Background(), repositories.TaskFilter{})
+	assert.Equal(t, 1, count)
+
+	// Status defaults to pending
+	req, err = http.NewRequest("POST", "/", bytes.NewBufferString(`{"title": "Test Task", "description": "Test Description"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, models.StatusPending, created.Status)
+
+	// New tasks must start as pending
+	for _, status := range []string{"completed", "unknown"} {
+		req, err = http.NewRequest("POST", "/", bytes.NewBufferString(`{"title": "Test Task", "status": "`+status+`"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code, status)
+	}
+
 	// Bad request
 	req, err = http.NewRequest("POST", "/", bytes.NewBuffer([]byte("")))
 	if err != nil {
@@ -80,33 +134,56 @@ func TestCreateTask(t *testing.T) {
 	rr = httptest.NewRecorder()
 	handler.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
-
-	mock.ExpectationsWereMet()
 }
 
-func TestUpdateTask(t *testing.T) {
+func TestCreateTaskValidation(t *testing.T) {
 	setup()
 
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
-	}
-	defer db.Close()
+	handler := as(alice, NewTaskController(repositories.NewMemoryTaskRepository()).CreateTask())
 
-	// Create a new task
-	task := models.Task{
-		Title:       "Test Task",
-		Description: "Test Description",
-		Status:      "Pending",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	// SQL keywords are valid text
+	assert.Equal(t, http.StatusCreated, postJSON(t, handler, `{"title": "UPDATE docs", "description": "SELECT the best option"}`).Code)
+
+	// Every invalid field is reported
+	rr := postJSON(t, handler, `{"title": " ", "description": "`+strings.Repeat("a", 10001)+`", "status": "archived"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	var body struct {
+		Error struct {
+			Fields validation.Errors
+		}
 	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, validation.Errors{
+		{Field: "title", Rule: "required", Message: "is required"},
+		{Field: "description", Rule: "max", Message: "must have at most 10000 characters"},
+		{Field: "status", Rule: "oneof", Message: "must be one of: pending, in_progress, completed, cancelled"},
+	}, body.Error.Fields)
+
+	// Titles are a single line
+	assert.Equal(t, http.StatusUnprocessableEntity, postJSON(t, handler, `{"title": "Line\nbreak"}`).Code)
+}
+
+// staleTaskRepository returns tasks in an outdated status, as if it was changed by another request after they were read.
+type staleTaskRepository struct {
+	repositories.TaskRepository
+	status string
+}
 
-	expectedQuery := fmt.Sprintf("UPDATE tasks SET title = '%s', description = '%s', status = '%s', updated_at = '.*' WHERE id = %d", task.Title, task.Description, task.Status, task.Id)
-	mock.ExpectExec(expectedQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+func (r staleTaskRepository) Get(ctx context.Context, id uint) (models.Task, error) {
+	task, err := r.TaskRepository.Get(ctx, id)
+	task.Status = r.status
+	return task, err
+}
 
-	tc := NewTaskController()
-	handler := http.HandlerFunc(tc.UpdateTask(db))
+func TestUpdateTask(t *testing.T) {
+	setup()
+
+	repo := repositories.NewMemoryTaskRepository()
+	task := seedTask(t, repo)
+	task.Status = "in_progress"
+
+	tc := NewTaskController(repo)
+	handler := as(alice, tc.UpdateTask())
 	// Convert task to JSON
 	taskJSON, err := json.Marshal(task)
 	if err != nil {
@@ -118,10 +195,45 @@ func TestUpdateTask(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	req = mux.SetURLVars(req, map[string]string{"id": fmt.Sprintf("%d", task.Id)})
 	rr := httptest.NewRecorder()
 	handler.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusOK, rr.Code)
 
+	updated, _ := repo.Get(context.Background(), task.Id)
+	assert.Equal(t, "in_progress", updated.Status)
+
+	// Illegal status transition
+	req, err = http.NewRequest("PUT", "/1", bytes.NewBuffer([]byte(`{"title": "Test Task", "status": "pending-review"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": fmt.Sprintf("%d", task.Id)})
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	// Status changed by another request after it was read
+	stale := NewTaskController(staleTaskRepository{repo, "pending"})
+	req, err = http.NewRequest("PUT", "/1", bytes.NewBuffer([]byte(`{"title": "Test Task", "status": "in_progress"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": fmt.Sprintf("%d", task.Id)})
+	rr = httptest.NewRecorder()
+	as(alice, stale.UpdateTask()).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// Unknown task
+	req, err = http.NewRequest("PUT", "/99", bytes.NewBuffer(taskJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": "99"})
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
 	// Bad request
 	req, err = http.NewRequest("PUT", "/1", bytes.NewBuffer([]byte("")))
 	if err != nil {
@@ -141,35 +253,56 @@ func TestUpdateTask(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 }
 
-func TestGetTask(t *testing.T) {
+func TestTransitionTask(t *testing.T) {
 	setup()
 
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	repo := repositories.NewMemoryTaskRepository()
+	task := seedTask(t, repo)
+
+	tc := NewTaskController(repo)
+	handler := as(alice, tc.TransitionTask())
+
+	transition := func(id string, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/task/"+id+"/transition", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
 	}
-	defer db.Close()
-
-	// Create a new task
-	task := models.Task{
-		Id:          1,
-		Title:       "Test Task",
-		Description: "Test Description",
-		Status:      "Pending",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	id := fmt.Sprintf("%d", task.Id)
+
+	// Legal transitions, including a reopen
+	for _, status := range []string{"in_progress", "completed", "in_progress"} {
+		rr := transition(id, `{"status": "`+status+`"}`)
+		assert.Equal(t, http.StatusOK, rr.Code, status)
+		var updated models.Task
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &updated))
+		assert.Equal(t, status, updated.Status)
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "title", "description", "status", "created_at", "updated_at"}).
-		AddRow(task.Id, task.Title, task.Description, task.Status, task.CreatedAt, task.UpdatedAt)
+	// Illegal transition
+	repo.Transition(context.Background(), task.Id, "in_progress", "cancelled")
+	rr := transition(id, `{"status": "completed"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Contains(t, rr.Body.String(), `illegal status transition from \"cancelled\" to \"completed\"`)
+
+	// Unknown status, unknown task and bad request
+	assert.Equal(t, http.StatusUnprocessableEntity, transition(id, `{"status": "archived"}`).Code)
+	assert.Equal(t, http.StatusNotFound, transition("99", `{"status": "pending"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, transition(id, ``).Code)
+}
+
+func TestGetTask(t *testing.T) {
+	setup()
 
-	expectedQuery := "SELECT * FROM tasks WHERE id = $1"
-	mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
-		WithArgs(fmt.Sprintf("%d", task.Id)).
-		WillReturnRows(rows)
+	repo := repositories.NewMemoryTaskRepository()
+	task := seedTask(t, repo)
 
-	tc := NewTaskController()
-	handler := http.HandlerFunc(tc.GetTask(db))
+	tc := NewTaskController(repo)
+	handler := as(alice, tc.GetTask())
 
 	// Valid request
 	req, err := http.NewRequest("GET", fmt.Sprintf("/tasks/%d", task.Id), nil)
@@ -184,6 +317,16 @@ func TestGetTask(t *testing.T) {
 	handler.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusOK, rr.Code)
 
+	// Unknown task
+	req, err = http.NewRequest("GET", "/tasks/99", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": "99"})
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
 	// Bad request
 	req, err = http.NewRequest("GET", fmt.Sprintf("/tasks/%d", task.Id), nil)
 	if err != nil {
@@ -197,70 +340,127 @@ func TestGetTask(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 }
 
-func TestGetTasks(t *testing.T) {
-	setup()
+// taskPage mirrors responses.PageEnvelope with typed items.
+type taskPage struct {
+	Items []models.Task
+	Total int
+	Page  int
+	Size  int
+	Next  string
+	Prev  string
+}
 
-	db, mock, err := sqlmock.New()
+// getTasksPage serves a GetTasks request for the given query and decodes the response.
+func getTasksPage(t *testing.T, handler http.Handler, query string) (int, taskPage) {
+	req, err := http.NewRequest("GET", "/tasks?"+query, nil)
 	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		t.Fatal(err)
 	}
-	defer db.Close()
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
 
-	// Create a new task
-	task := models.Task{
-		Id:          1,
-		Title:       "Test Task",
-		Description: "Test Description",
-		Status:      "Pending",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	var page taskPage
+	if rr.Code == http.StatusOK {
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
 	}
+	return rr.Code, page
+}
+
+func TestGetTasks(t *testing.T) {
+	setup()
 
-	rows := sqlmock.NewRows([]string{"id", "title", "description", "status", "created_at", "updated_at"}).
-		AddRow(task.Id, task.Title, task.Description, task.Status, task.CreatedAt, task.UpdatedAt)
+	repo := repositories.NewMemoryTaskRepository()
 
-	expectedQuery := "SELECT * FROM tasks"
-	mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
-		WillReturnRows(rows)
+	tc := NewTaskController(repo)
+	handler := as(alice, tc.GetTasks())
 
-	tc := NewTaskController()
-	handler := http.HandlerFunc(tc.GetTasks(db))
+	// Empty repository
+	code, page := getTasksPage(t, handler, "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotNil(t, page.Items)
+	assert.Empty(t, page.Items)
+	assert.Equal(t, 0, page.Total)
+
+	seedTask(t, repo)
+	seedTask(t, repo)
+	seedTask(t, repo)
 
 	// Valid request
-	req, err := http.NewRequest("GET", "/tasks", nil)
-	if err != nil {
-		t.Fatal(err)
+	code, page = getTasksPage(t, handler, "page=2&size=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, uint(2), page.Items[0].Id)
+	assert.Equal(t, 3, page.Total)
+	assert.Equal(t, 2, page.Page)
+	assert.Equal(t, "/tasks?page=3&size=1", page.Next)
+	assert.Equal(t, "/tasks?page=1&size=1", page.Prev)
+
+	// Cursor pagination
+	code, page = getTasksPage(t, handler, "after=0&size=2")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, "/tasks?after=2&size=2", page.Next)
+	assert.Empty(t, page.Prev)
+
+	code, page = getTasksPage(t, handler, "after=2&size=2")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, uint(3), page.Items[0].Id)
+	assert.Empty(t, page.Next)
+
+	// Huge sizes and pages are clamped
+	for _, query := range []string{"size=1000000", "page=3&size=9223372036854775807", "page=9223372036854775807&size=1000", "after=0&size=9223372036854775807"} {
+		code, page = getTasksPage(t, handler, query)
+		assert.Equal(t, http.StatusOK, code, query)
+		assert.Equal(t, maxPageSize, page.Size, query)
 	}
 
-	rr := httptest.NewRecorder()
-	handler.ServeHTTP(rr, req)
-	assert.Equal(t, http.StatusOK, rr.Code)
+	// Filtering and sorting
+	repo.Update(context.Background(), models.Task{Id: 1, Title: "Write weekly report", Description: "Test Description", Status: "completed"}, "pending")
+	code, page = getTasksPage(t, handler, "status=completed&q=report&created_after=2000-01-01&sort=title&order=desc")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, uint(1), page.Items[0].Id)
+	assert.Equal(t, 1, page.Total)
+
+	// Invalid parameters
+	for _, query := range []string{"sort=password", "order=sideways", "created_before=yesterday", "after=abc", "after=1&sort=title"} {
+		code, _ = getTasksPage(t, handler, query)
+		assert.Equal(t, http.StatusBadRequest, code, query)
+	}
 }
 
-func TestDeleteTask(t *testing.T) {
-	setup()
-
-	db, mock, err := sqlmock.New()
-	if err != nil {
-		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		query  string
+		limit  int
+		offset int
+	}{
+		{"", defaultPageSize, 0},
+		{"page=3&size=20", 20, 40},
+		{"page=0&size=-1", defaultPageSize, 0},
+		{"page=abc&size=abc", defaultPageSize, 0},
+		{"size=1000000", maxPageSize, 0},
+		{"page=3&size=9223372036854775807", maxPageSize, 200},
+		{"page=9223372036854775807&size=100", maxPageSize, maxPageOffset / maxPageSize * maxPageSize},
 	}
-	defer db.Close()
-
-	// Create a new task
-	task := models.Task{
-		Id:          1,
-		Title:       "Test Task",
-		Description: "Test Description",
-		Status:      "Pending",
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		assert.NoError(t, err)
+		limit, offset := parsePagination(query)
+		assert.Equal(t, tt.limit, limit, tt.query)
+		assert.Equal(t, tt.offset, offset, tt.query)
 	}
+}
+
+func TestDeleteTask(t *testing.T) {
+	setup()
 
-	expectedQuery := fmt.Sprintf("DELETE FROM tasks WHERE id = %d", task.Id)
-	mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).WillReturnResult(sqlmock.NewResult(1, 1))
+	repo := repositories.NewMemoryTaskRepository()
+	task := seedTask(t, repo)
 
-	tc := NewTaskController()
-	handler := http.HandlerFunc(tc.DeleteTask(db))
+	tc := NewTaskController(repo)
+	handler := as(alice, tc.DeleteTask())
 
 	// Valid request
 	req, err := http.NewRequest("DELETE", fmt.Sprintf("/tasks/%d", task.Id), nil)
@@ -275,6 +475,11 @@ func TestDeleteTask(t *testing.T) {
 	handler.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusOK, rr.Code)
 
+	// Deleting again should not find the task
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
 	// Bad request
 	req, err = http.NewRequest("DELETE", fmt.Sprintf("/tasks/%d", task.Id), nil)
 	if err != nil {
@@ -287,3 +492,47 @@ func TestDeleteTask(t *testing.T) {
 	handler.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 }
+
+func TestTaskOwnership(t *testing.T) {
+	setup()
+
+	repo := repositories.NewMemoryTaskRepository()
+	task := seedTask(t, repo)
+	id := fmt.Sprintf("%d", task.Id)
+	tc := NewTaskController(repo)
+
+	serve := func(handler http.HandlerFunc, method string, body string) int {
+		req, err := http.NewRequest(method, "/task/"+id, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = mux.SetURLVars(req, map[string]string{"id": id})
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// Other users cannot see the task
+	_, page := getTasksPage(t, as(bob, tc.GetTasks()), "")
+	assert.Empty(t, page.Items)
+	assert.Equal(t, http.StatusNotFound, serve(as(bob, tc.GetTask()), "GET", ""))
+	assert.Equal(t, http.StatusNotFound, serve(as(bob, tc.UpdateTask()), "PUT", `{"title": "Mine now"}`))
+	assert.Equal(t, http.StatusNotFound, serve(as(bob, tc.TransitionTask()), "POST", `{"status": "cancelled"}`))
+	assert.Equal(t, http.StatusNotFound, serve(as(bob, tc.DeleteTask()), "DELETE", ""))
+
+	// Tasks are created for the caller
+	rr := postJSON(t, as(bob, tc.CreateTask()), `{"title": "Bob's task"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var created models.Task
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	assert.Equal(t, bob.UserID, created.OwnerId)
+
+	// Admins can see and manage every task
+	_, page = getTasksPage(t, as(admin, tc.GetTasks()), "")
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, http.StatusOK, serve(as(admin, tc.GetTask()), "GET", ""))
+	assert.Equal(t, http.StatusOK, serve(as(admin, tc.DeleteTask()), "DELETE", ""))
+
+	// Anonymous requests are rejected
+	assert.Equal(t, http.StatusUnauthorized, serve(tc.GetTask(), "GET", ""))
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
+)
+
+// WorkerController represents the controller for administrating the worker pool.
+// Its handlers do not go through the worker pool, so that it can be resized while it is saturated.
+type WorkerController struct {
+	workers *worker_manager.WorkerManager
+}
+
+// NewWorkerController creates a new instance of the WorkerController for the given worker manager.
+func NewWorkerController(workers *worker_manager.WorkerManager) *WorkerController {
+	return &WorkerController{workers: workers}
+}
+
+// workerPool returns the current state of the worker pool.
+func (wc *WorkerController) workerPool() models.WorkerPool {
+	minWorkers, maxWorkers := wc.workers.Bounds()
+	return models.WorkerPool{
+		Size:          wc.workers.Size(),
+		MinWorkers:    minWorkers,
+		MaxWorkers:    maxWorkers,
+		QueueLength:   wc.workers.QueueLength(),
+		QueueCapacity: wc.workers.QueueCapacity(),
+		Workers:       wc.workers.GetWorkerStatus(),
+	}
+}
+
+// GetWorkerPool reports the size, bounds, queue and worker statuses of the worker pool.
+// Example:
+// HTTP GET http://localhost:8080/api/admin/workers
+func (wc *WorkerController) GetWorkerPool() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("GetWorkerPool")
+
+		responses.JSON(w, http.StatusOK, wc.workerPool())
+	}
+}
+
+// ResizeWorkerPool sets the bounds of the worker pool, and resizes the pool into them.
+// The bounds are set rather than a size so that the autoscaler does not undo the change,
+// equal bounds fix the pool size. Removed workers finish their running job before they stop.
+// Example:
+// HTTP PUT http://localhost:8080/api/admin/workers
+// Content-Type: application/json
+//
+//	{
+//		"min": 4,
+//		"max": 8
+//	}
+func (wc *WorkerController) ResizeWorkerPool() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var logger = logger.FromContext(r.Context())
+		logger.Info("ResizeWorkerPool")
+
+		var req models.ResizeWorkerPoolRequest
+
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			responses.Error(w, http.StatusBadRequest, "Error decoding request body")
+			logger.Error("Error decoding request body", "error", err)
+			return
+		}
+		if !validateRequest(w, r, req) {
+			return
+		}
+
+		err = wc.workers.SetBounds(req.Min, req.Max)
+		if errors.Is(err, worker_manager.ErrInvalidPoolSize) {
+			responses.Error(w, http.StatusBadRequest, err.Error())
+			logger.Error("Invalid worker pool bounds", "min", req.Min, "max", req.Max)
+			return
+		}
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, "Error resizing worker pool")
+			logger.Error("Error resizing worker pool", "error", err)
+			return
+		}
+
+		logger.Info("Worker pool resized successfully", "min", req.Min, "max", req.Max)
+
+		responses.JSON(w, http.StatusOK, wc.workerPool())
+	}
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerController(t *testing.T) {
+	setup()
+
+	workers := worker_manager.NewWorkerManager(2, 8)
+	assert.NoError(t, workers.SetBounds(1, 4))
+	wc := NewWorkerController(workers)
+
+	rr := get(t, as(admin, wc.GetWorkerPool()))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var pool models.WorkerPool
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &pool))
+	assert.Equal(t, models.WorkerPool{Size: 2, MinWorkers: 1, MaxWorkers: 4, QueueCapacity: 8,
+		Workers: map[string]bool{"0": true, "1": true}}, pool)
+
+	resize := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		as(admin, wc.ResizeWorkerPool())(rr, req)
+		return rr
+	}
+	// The pool is resized into the new bounds
+	rr = resize(`{"min": 4, "max": 6}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &pool))
+	assert.Equal(t, 4, pool.Size)
+	assert.Equal(t, 4, pool.MinWorkers)
+	assert.Equal(t, 6, pool.MaxWorkers)
+	assert.Equal(t, 4, workers.Size())
+
+	assert.Equal(t, http.StatusBadRequest, resize(`{"min": 5, "max": 4}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, resize(`{"min": 0, "max": 4}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, resize(`{"size": 4}`).Code)
+	assert.Equal(t, http.StatusBadRequest, resize(`size`).Code)
+	assert.Equal(t, 4, workers.Size())
+}
@@ -1,12 +1,17 @@
 // Package api provides functionality for initializing HTTP API routes and registering
-// middleware handlers for handling various tasks such as CORS, CSRF protection, rate limiting,
-// and SQL injection prevention.
+// middleware handlers for handling various tasks such as CORS, CSRF protection, IP filtering, rate limiting,
+// SQL injection detection, authentication and authorization, and for serving them with graceful shutdown.
 package api
 
 import (
 	"github.com/emso-c/konzek-go-assignment/src/api/middlewares"
 	"github.com/emso-c/konzek-go-assignment/src/api/routers"
+	"github.com/emso-c/konzek-go-assignment/src/database"
+	"github.com/emso-c/konzek-go-assignment/src/modules/clientip"
+	"github.com/emso-c/konzek-go-assignment/src/modules/csrf"
 	"github.com/emso-c/konzek-go-assignment/src/modules/limiter"
+	"github.com/emso-c/konzek-go-assignment/src/modules/waf"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
 	"github.com/gorilla/mux"
 )
 
@@ -16,18 +21,57 @@ var router *mux.Router
 func Init() {
 	router = mux.NewRouter()
 
-	router = router.PathPrefix("/api").Subrouter()
+	// Register probes and metrics outside of the API middlewares
+	routers.RegisterHealthRouter(router)
+	routers.RegisterMetricsRouter(router)
+
+	apiRouter := router.PathPrefix("/api").Subrouter()
+
+	policies := limiter.GetPolicies()
+
+	// Register public routers
+	public := apiRouter.PathPrefix("/").Subrouter()
+	public.Use(middlewares.RateLimitMiddleware(policies))
+	routers.RegisterAuthRouter(public)
+	routers.RegisterCSRFRouter(public)
+
+	apiKeys := repositories.NewPostgresAPIKeyRepository(database.GetDatabase())
+	users := repositories.NewPostgresUserRepository(database.GetDatabase())
+
+	// Register the status router, which requires authentication but is not rate limited,
+	// so that admins can poll it during an incident
+	status := apiRouter.PathPrefix("/").Subrouter()
+	status.Use(middlewares.AuthMiddleware(apiKeys, users))
+	status.Use(middlewares.AuthorizationMiddleware())
+	routers.RegisterStatusRouter(status)
+
+	// Register routers that require authentication
+	protected := apiRouter.PathPrefix("/").Subrouter()
+	// Rate limit by address before authentication, so that requests with invalid credentials are limited too
+	protected.Use(middlewares.AddressRateLimitMiddleware(policies))
+	protected.Use(middlewares.AuthMiddleware(apiKeys, users))
+	// Rate limit after authentication, so that the policies of the identity apply
+	protected.Use(middlewares.RateLimitMiddleware(policies))
+	protected.Use(middlewares.AuthorizationMiddleware())
+	routers.RegisterTasksRouter(protected)
+	routers.RegisterAPIKeysRouter(protected)
+	routers.RegisterWorkersRouter(protected)
+	routers.RegisterJobsRouter(protected)
+	routers.RegisterBansRouter(protected)
 
-	// Register routers
-	routers.RegisterTasksRouter(router)
 	limiter.GetLimiter().Initialize()
 
-	// TODO: Add authentication & authorization middlewares.
-	router.NotFoundHandler = middlewares.NotFoundMiddleware()
-	router.Use(middlewares.CORSMiddleware())
-	router.Use(middlewares.CSRFMiddleware())
-	router.Use(middlewares.RateLimitMiddleware())
-	router.Use(middlewares.SQLInjectionMiddleware())
+	router.Use(middlewares.RequestIDMiddleware())
+	router.Use(middlewares.ClientIPMiddleware(clientip.GetResolver()))
+	router.Use(middlewares.MetricsMiddleware())
+	router.Use(middlewares.RecoveryMiddleware())
+	router.NotFoundHandler = middlewares.RequestIDMiddleware()(middlewares.MetricsMiddleware()(middlewares.NotFoundMiddleware()))
+	apiRouter.NotFoundHandler = router.NotFoundHandler
+	apiRouter.Use(middlewares.IPFilterMiddleware(clientip.GetFilter(), limiter.GetBans()))
+	apiRouter.Use(middlewares.TimeoutMiddleware())
+	apiRouter.Use(middlewares.CORSMiddleware())
+	apiRouter.Use(middlewares.CSRFMiddleware(csrf.GetProtector()))
+	apiRouter.Use(middlewares.SQLInjectionMiddleware(waf.GetDetector()))
 }
 
 // GetRouter retrieves the initialized router instance.
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/emso-c/konzek-go-assignment/config"
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
 	"github.com/joho/godotenv"
 )
 
@@ -21,18 +22,38 @@ func TestInit(t *testing.T) {
 	}
 	os.Setenv("LOGGER_DISABLED", "true")
 	os.Setenv("HTTP_RATE_LIMIT", "9999")
+	os.Setenv("WAF_MODE", "block")
 
 	// Call the Init function to initialize the router
 	Init()
 
-	// Create a new HTTP request for testing
+	// Test if AuthMiddleware is set
 	req, err := http.NewRequest("GET", "/api/tasks", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusUnauthorized {
+		t.Errorf("handler returned wrong status code without token: got %v want %v",
+			status, http.StatusUnauthorized)
+	}
+
+	token, err := auth.GetTokenManager().Issue(&auth.Identity{UserID: 1, Username: "test", Role: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorization := "Bearer " + token
+
+	// Create a new HTTP request for testing
+	req, err = http.NewRequest("GET", "/api/tasks", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", authorization)
 
 	// Create a response recorder to record the response
-	rr := httptest.NewRecorder()
+	rr = httptest.NewRecorder()
 
 	// Serve the request using the router
 	router.ServeHTTP(rr, req)
@@ -43,6 +64,28 @@ func TestInit(t *testing.T) {
 			status, http.StatusOK)
 	}
 
+	// Test if the status route is not rate limited
+	adminToken, err := auth.GetTokenManager().Issue(&auth.Identity{UserID: 1, Username: "test", Role: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err = http.NewRequest("GET", "/api/status", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code for status: got %v want %v",
+			status, http.StatusOK)
+	}
+	for _, header := range []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"} {
+		if rr.Header().Get(header) != "" {
+			t.Errorf("status route is rate limited, %s header set", header)
+		}
+	}
+
 	// Test if CORS middleware is applied
 	req, err = http.NewRequest("OPTIONS", "/api/tasks", nil)
 	if err != nil {
@@ -71,6 +114,7 @@ func TestInit(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.Header.Set("Authorization", authorization)
 	rr = httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 	if status := rr.Code; status != http.StatusOK {
@@ -83,6 +127,7 @@ func TestInit(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	req.Header.Set("Authorization", authorization)
 	rr = httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 	if status := rr.Code; status != http.StatusOK {
@@ -94,7 +139,7 @@ func TestInit(t *testing.T) {
 	}
 
 	// Test if SQLInjectionMiddleware is set
-	req, err = http.NewRequest("GET", "/api/tasks?page=1&size=1%20OR%201=1", nil)
+	req, err = http.NewRequest("GET", "/api/tasks?title=x%27%20OR%201=1--", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -14,7 +14,6 @@
 package middlewares
 
 import (
-	"fmt"
 	"net/http"
 	"os"
 	"strings"
@@ -35,7 +34,7 @@ func _contains(s string, e string) bool {
 func CORSMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			logger := logger.GetLogger()
+			logger := logger.FromContext(r.Context())
 			origin := r.Header.Get("Origin")
 			allowedOrigins := strings.Split(",", os.Getenv("HTTP_ALLOWED_ORIGINS"))
 			if origin != "" {
@@ -51,7 +50,7 @@ func CORSMiddleware() func(http.Handler) http.Handler {
 			if os.Getenv("HTTP_ALLOWED_METHODS") != "*" {
 				if !_contains(r.Method, os.Getenv("HTTP_ALLOWED_METHODS")) {
 					http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-					logger.Error(fmt.Sprintf("Method Not Allowed: %s", r.Method))
+					logger.Error("Method Not Allowed", "method", r.Method)
 					return
 				}
 			}
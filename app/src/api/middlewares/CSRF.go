@@ -1,86 +1,70 @@
 // Package middlewares provides protection against Cross-Site Request Forgery (CSRF) attacks.
 //
 // Usage:
-// Use the CSRFMiddleware function as a middleware in your HTTP handlers to protect against CSRF attacks.
+// Use the CSRFMiddleware function as a middleware in your HTTP handlers to protect against CSRF attacks
+// with the signed double-submit tokens of the given protector, see the csrf module.
 //
 // Example:
 //
-// http.Handle("/api/tasks", middlewares.CSRFMiddleware()(http.HandlerFunc(handler)))
+// http.Handle("/api/tasks", middlewares.CSRFMiddleware(csrf.GetProtector())(http.HandlerFunc(handler)))
 //
-// The middleware generates a CSRF token for the current request, and sets it in the response header and cookie.
-// It checks the CSRF token in the request header or cookie for POST, PUT, and DELETE requests.
+// The middleware issues a CSRF token for safe requests that do not carry a valid one, sets it in the `csrf_token`
+// cookie, readable by JavaScript, and echoes it in the `X-CSRF-Token` response header. Clients can also get it
+// from the `GET /api/csrf` endpoint. POST, PUT, PATCH and DELETE requests must submit the token of the cookie
+// in the `X-CSRF-Token` request header, the request body is not read.
 //
 // If the CSRF token is missing or invalid, the middleware returns a 403 Forbidden error.
+// Requests authenticated with an access token or an API key in the Authorization header are not checked,
+// as browsers do not attach it to cross-site requests by themselves.
 package middlewares
 
 import (
-	"crypto/rand"
-	"encoding/base64"
 	"net/http"
-)
 
-const (
-	csrfTokenLength = 32
-	csrfHeaderName  = "X-CSRF-Token"
-	csrfCookieName  = "csrf_token"
-	csrfMaxAge      = 3600 // 1 hour
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/modules/csrf"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
 )
 
-// generateCSRFToken generates a CSRF token for the current request.
-func generateCSRFToken(r *http.Request) (string, error) {
-	// Attempt to read from cookie
-	cookie, err := r.Cookie(csrfCookieName)
-	if err == nil && cookie != nil && cookie.Value != "" {
-		return cookie.Value, nil
-	}
-
-	// Generate new token
-	token := make([]byte, csrfTokenLength)
-	_, err = rand.Read(token)
-	if err != nil {
-		return "", err
+// isUnsafeMethod reports whether the method can change the state of the server, and must be protected.
+func isUnsafeMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
 	}
-
-	// Encode token
-	return base64.URLEncoding.EncodeToString(token), nil
+	return false
 }
 
 // CSRFMiddleware returns a middleware that protects against Cross-Site Request Forgery (CSRF) attacks.
-func CSRFMiddleware() func(http.Handler) http.Handler {
+func CSRFMiddleware(protector *csrf.Protector) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Generate CSRF token if not present
-			csrfToken, err := generateCSRFToken(r)
-			if err != nil {
-				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			logger := logger.FromContext(r.Context())
+
+			// Bearer and API key callers cannot be forged into sending their credentials
+			if hasCredentials(r) {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Set CSRF token in response header
-			w.Header().Set(csrfHeaderName, csrfToken)
-
-			// Set CSRF token in cookie
-			http.SetCookie(w, &http.Cookie{
-				Name:     csrfCookieName,
-				Value:    csrfToken,
-				HttpOnly: true,
-				MaxAge:   csrfMaxAge,
-				SameSite: http.SameSiteStrictMode,
-			})
-
-			// Check CSRF token in request header or cookie
-			if r.Method == "POST" || r.Method == "PUT" || r.Method == "DELETE" {
-				clientToken := r.Header.Get(csrfHeaderName)
-				if clientToken == "" {
-					clientToken = r.FormValue(csrfCookieName)
-				}
-				if clientToken != csrfToken {
-					http.Error(w, "CSRF Token Invalid", http.StatusForbidden)
+			if isUnsafeMethod(r.Method) {
+				if !protector.Verify(r) {
+					responses.Error(w, http.StatusForbidden, "CSRF Token Invalid")
+					logger.Info("CSRF token missing or invalid")
 					return
 				}
+				next.ServeHTTP(w, r)
+				return
 			}
 
-			next.ServeHTTP(w, r)
+			token, err := protector.Token(w, r)
+			if err != nil {
+				responses.Error(w, http.StatusInternalServerError, "Internal Server Error")
+				logger.Error("Error issuing CSRF token", "error", err)
+				return
+			}
+			w.Header().Set(csrf.HeaderName, token)
+			next.ServeHTTP(w, r.WithContext(csrf.NewContext(r.Context(), token)))
 		})
 	}
 }
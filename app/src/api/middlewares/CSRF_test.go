@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/csrf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	t.Setenv("LOGGER_DISABLED", "true")
+
+	protector, err := csrf.NewProtector([]byte("0123456789abcdef0123456789abcdef"), false, http.SameSiteStrictMode, time.Hour)
+	require.NoError(t, err)
+	var body string
+	handler := CSRFMiddleware(protector)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Safe requests get a token
+	rr := serve(httptest.NewRequest("GET", "/api/tasks", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	token := rr.Header().Get(csrf.HeaderName)
+	assert.NotEmpty(t, token)
+	cookies := rr.Result().Cookies()
+
+	unsafe := func(method string, token string) *http.Request {
+		req := httptest.NewRequest(method, "/api/task", bytes.NewBufferString(`{"title": "csrf_token=x"}`))
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		if token != "" {
+			req.Header.Set(csrf.HeaderName, token)
+		}
+		return req
+	}
+	for _, method := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+		assert.Equal(t, http.StatusForbidden, serve(unsafe(method, "")).Code, method)
+		assert.Equal(t, http.StatusOK, serve(unsafe(method, token)).Code, method)
+	}
+	// The body is left for the handler
+	assert.Equal(t, `{"title": "csrf_token=x"}`, body)
+
+	// Requests with credentials in the Authorization header are exempt
+	for _, authorization := range []string{"Bearer token", "ApiKey key"} {
+		req := httptest.NewRequest("POST", "/api/task", nil)
+		req.Header.Set("Authorization", authorization)
+		assert.Equal(t, http.StatusOK, serve(req).Code, authorization)
+	}
+	req := httptest.NewRequest("POST", "/api/task", nil)
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	assert.Equal(t, http.StatusForbidden, serve(req).Code)
+}
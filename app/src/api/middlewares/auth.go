@@ -0,0 +1,144 @@
+// Package middlewares provides authentication for the API.
+//
+// Usage:
+// Use the AuthMiddleware function as a middleware in your HTTP handlers to require either a valid
+// access token issued by the auth module, read from the `Authorization: Bearer <token>` header,
+// or a valid API key, read from the `Authorization: ApiKey <key>` header. The authenticated identity
+// is stored in the request context. Handlers can retrieve it with `auth.FromContext(r.Context())`.
+// Identities authenticated with an API key carry the key's scopes, see policy.Allowed.
+//
+// Example:
+//
+// http.Handle("/api/tasks", middlewares.AuthMiddleware(apiKeys, users)(http.HandlerFunc(handler)))
+//
+// The middleware returns a 401 Unauthorized error if the token or key is missing, invalid, expired or revoked.
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
+)
+
+const (
+	bearerScheme = "Bearer"
+	apiKeyScheme = "ApiKey"
+)
+
+// credentials returns the scheme and the credentials of the Authorization header.
+// The scheme is empty if the header is missing or malformed.
+func credentials(r *http.Request) (string, string) {
+	scheme, value, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	value = strings.TrimSpace(value)
+	if !found || value == "" {
+		return "", ""
+	}
+	switch {
+	case strings.EqualFold(scheme, bearerScheme):
+		return bearerScheme, value
+	case strings.EqualFold(scheme, apiKeyScheme):
+		return apiKeyScheme, value
+	}
+	return "", ""
+}
+
+// hasCredentials reports whether the request claims to be authenticated with an access token or an API key.
+// The credentials themselves are verified by AuthMiddleware.
+func hasCredentials(r *http.Request) bool {
+	scheme, _ := credentials(r)
+	return scheme == bearerScheme || scheme == apiKeyScheme
+}
+
+// unauthorized writes a 401 Unauthorized response with a WWW-Authenticate challenge.
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	responses.Error(w, http.StatusUnauthorized, message)
+}
+
+// authenticateAPIKey returns the identity of the owner of a valid API key, limited to the key's scopes.
+func authenticateAPIKey(ctx context.Context, apiKeys repositories.APIKeyRepository, users repositories.UserRepository, key string) (*auth.Identity, error) {
+	prefix, ok := auth.ParseAPIKey(key)
+	if !ok {
+		return nil, errors.New("malformed api key")
+	}
+	apiKey, err := apiKeys.GetByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.CheckAPIKey(key, apiKey.KeyHash) {
+		return nil, errors.New("api key hash mismatch")
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, errors.New("api key revoked")
+	}
+	if apiKey.ExpiresAt != nil && !time.Now().Before(*apiKey.ExpiresAt) {
+		return nil, errors.New("api key expired")
+	}
+	user, err := users.Get(ctx, apiKey.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Identity{
+		UserID:   user.Id,
+		Username: user.Username,
+		Role:     user.Role,
+		APIKeyID: apiKey.Id,
+		Scopes:   apiKey.Scopes,
+	}, nil
+}
+
+// AuthMiddleware returns a middleware that requires a valid bearer access token or API key.
+// API keys are looked up in apiKeys, and their owner in users.
+func AuthMiddleware(apiKeys repositories.APIKeyRepository, users repositories.UserRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := logger.FromContext(r.Context())
+
+			scheme, token := credentials(r)
+			if scheme == "" {
+				unauthorized(w, "Missing bearer token")
+				logger.Error("Missing bearer token")
+				return
+			}
+
+			if scheme == apiKeyScheme {
+				identity, err := authenticateAPIKey(r.Context(), apiKeys, users, token)
+				if err != nil {
+					unauthorized(w, "Invalid API key")
+					logger.Error("Invalid API key", "error", err)
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), identity)))
+				return
+			}
+
+			claims, err := auth.GetTokenManager().Verify(token)
+			if errors.Is(err, auth.ErrTokenExpired) {
+				unauthorized(w, "Token expired")
+				logger.Error("Token expired")
+				return
+			}
+			if err != nil {
+				unauthorized(w, "Invalid token")
+				logger.Error("Invalid token", "error", err)
+				return
+			}
+
+			identity, err := auth.IdentityFromClaims(claims)
+			if err != nil {
+				unauthorized(w, "Invalid token")
+				logger.Error("Invalid token subject", "subject", claims.Subject)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), identity)))
+		})
+	}
+}
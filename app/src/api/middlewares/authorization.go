@@ -0,0 +1,63 @@
+// Package middlewares provides authorization for the API.
+//
+// Usage:
+// Use the AuthorizationMiddleware function as a middleware on a Gorilla Mux router whose routes
+// are named, after AuthMiddleware. The middleware looks up the permission required by the matched
+// route in the policy module and checks it against the role of the authenticated identity.
+// Routes without a declared permission are denied.
+//
+// Example:
+//
+// router.Use(middlewares.AuthMiddleware())
+// router.Use(middlewares.AuthorizationMiddleware())
+// router.HandleFunc("/tasks", handler).Methods("GET").Name("tasks.list")
+//
+// The middleware returns a 401 Unauthorized error for anonymous requests, and a 403 Forbidden error
+// if the identity does not have the required permission.
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/policy"
+	"github.com/gorilla/mux"
+)
+
+// AuthorizationMiddleware returns a middleware that enforces the permissions declared in the policy module.
+func AuthorizationMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := logger.FromContext(r.Context())
+
+			identity := auth.FromContext(r.Context())
+			if identity == nil {
+				unauthorized(w, "Authentication required")
+				logger.Error("Anonymous request to a protected route")
+				return
+			}
+
+			var routeName string
+			if route := mux.CurrentRoute(r); route != nil {
+				routeName = route.GetName()
+			}
+			permission, ok := policy.RoutePermission(routeName)
+			if !ok {
+				responses.Error(w, http.StatusForbidden, "Forbidden")
+				logger.Error("No permission declared for route", "route", routeName)
+				return
+			}
+
+			if !policy.Allowed(identity, permission) {
+				responses.Error(w, http.StatusForbidden, fmt.Sprintf("Forbidden, %s permission required", permission))
+				logger.Error("Permission denied", "username", identity.Username, "permission", permission, "route", routeName)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
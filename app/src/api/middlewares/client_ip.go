@@ -0,0 +1,47 @@
+// Package middlewares provides client IP resolution for the API.
+//
+// Usage:
+// Use the ClientIPMiddleware function as a middleware in your HTTP handlers to resolve the IP address of the
+// client of every request with the given resolver. The address of the connection is used, unless it is a
+// trusted proxy, in which case the address it forwarded in the forwarded header of the resolver is used.
+// The client IP is stored in the request context with `clientip.NewContext`, so that the rate limiter and the IP filter
+// identify clients with it, and the logger returned by `logger.FromContext(r.Context())` attaches it to every log line.
+//
+// Example:
+//
+// http.Handle("/api/tasks", middlewares.ClientIPMiddleware(clientip.GetResolver())(http.HandlerFunc(handler)))
+//
+// Handlers can retrieve the client IP with the ClientIP function:
+//
+// ip := middlewares.ClientIP(r)
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/clientip"
+)
+
+// untrusted resolves the client IP of requests that did not go through ClientIPMiddleware, trusting no proxy.
+var untrusted = clientip.NewResolver(nil, clientip.HeaderXForwardedFor)
+
+// ClientIP returns the client IP of the request resolved by ClientIPMiddleware, or the address of the
+// connection if the request did not go through it. It returns an empty string if the address is not valid.
+func ClientIP(r *http.Request) string {
+	if ip := clientip.FromContext(r.Context()); ip != "" {
+		return ip
+	}
+	return untrusted.Resolve(r)
+}
+
+// ClientIPMiddleware returns a middleware that resolves the client IP of every request with the given resolver.
+func ClientIPMiddleware(resolver *clientip.Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ip := resolver.Resolve(r); ip != "" {
+				r = r.WithContext(clientip.NewContext(r.Context(), ip))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
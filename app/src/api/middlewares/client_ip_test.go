@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/clientip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIPMiddleware(t *testing.T) {
+	trusted, err := clientip.ParseNetworks("10.0.0.0/8")
+	require.NoError(t, err)
+
+	var got string
+	handler := ClientIPMiddleware(clientip.NewResolver(trusted, clientip.HeaderXForwardedFor))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = ClientIP(r)
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "198.51.100.1", got)
+
+	// Without the middleware the forwarding headers are not trusted
+	assert.Equal(t, "10.0.0.1", ClientIP(req))
+}
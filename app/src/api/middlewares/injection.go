@@ -1,116 +1,154 @@
-// Package middlewares provides protection against SQL injection attacks.
+// Package middlewares provides detection of SQL injection attempts.
 //
 // Usage:
-// Use the SQLInjectionMiddleware function as a middleware in your HTTP handlers to protect against SQL injection attacks.
+// Use the SQLInjectionMiddleware function as a middleware in your HTTP handlers to detect SQL injection attempts
+// with the given waf.Detector. The queries of the repositories are parameterized and the request bodies are
+// validated by the controllers, so the middleware is a defense in depth that can be turned off.
 //
 // Example:
 //
-// http.Handle("/api/tasks", middlewares.SQLInjectionMiddleware()(http.HandlerFunc(handler)))
+// http.Handle("/api/tasks", middlewares.SQLInjectionMiddleware(waf.GetDetector())(http.HandlerFunc(handler)))
 //
-// The middleware checks for potential SQL injection patterns in the URL parameters, request body, and form data.
-// If a potential SQL injection pattern is detected, the middleware returns a 400 Bad Request error.
+// The middleware inspects the URL parameters, and the string values of JSON and form request bodies.
+// In log mode, detections are logged and counted, and the request is let through. In block mode,
+// the middleware returns a 400 Bad Request error, and the request is counted as an offense of the client
+// by IPFilterMiddleware.
 package middlewares
 
 import (
 	"bytes"
-	"errors"
+	"encoding/json"
 	"io"
-	"io/ioutil"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/emso-c/konzek-go-assignment/src/api/responses"
 	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/metrics"
+	"github.com/emso-c/konzek-go-assignment/src/modules/waf"
 )
 
-// isSQLInjection checks if the provided string contains potential SQL injection patterns.
-func isSQLInjection(value string) bool {
-	patterns := []string{
-		"SELECT",
-		"DELETE",
-		"DROP TABLE",
-		"INSERT INTO",
-		"UPDATE",
-		"1=1",
-		"OR 1=1",
-		"AND 1=1",
-		"OR '1'='1",
-		"AND '1'='1",
-		"OR 1=1--",
-		"AND 1=1--",
-		"OR '1'='1--",
-		"AND '1'='1--",
+// maxInspectedBodySize is the number of bytes of the request body inspected, the rest is passed on unread.
+const maxInspectedBodySize = 1 << 20 // 1 MB
+
+// maxLoggedValueSize is the number of bytes of a suspicious value that are logged.
+const maxLoggedValueSize = 128
+
+// loggedValue returns a quoted prefix of the attacker-controlled value that is safe to log:
+// at most maxLoggedValueSize bytes, with control characters and invalid UTF-8 escaped.
+func loggedValue(value string) string {
+	if len(value) > maxLoggedValueSize {
+		value = value[:maxLoggedValueSize] + "..."
+	}
+	return strconv.Quote(value)
+}
+
+// readCloser reads the inspected part of a request body followed by the rest, and closes the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyValues returns the values of the request body to inspect, and the part of the request they come from:
+// the string values of JSON bodies, the values of form bodies, or the whole body otherwise.
+// Multipart bodies are not inspected. The body is restored so that it can be read again by the handler.
+func bodyValues(r *http.Request) (string, []string, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return "", nil, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if strings.HasPrefix(mediaType, "multipart/") {
+		return "", nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxInspectedBodySize))
+	if err != nil {
+		return "", nil, err
+	}
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+	if mediaType == "application/x-www-form-urlencoded" {
+		form, err := url.ParseQuery(string(body))
+		if err == nil {
+			var values []string
+			for _, v := range form {
+				values = append(values, v...)
+			}
+			return "form", values, nil
+		}
 	}
-	for _, pattern := range patterns {
-		if strings.Contains(value, pattern) {
-			return true
+	// Only inspect the string values of JSON documents, their syntax is made of quotes
+	var document interface{}
+	if json.Unmarshal(body, &document) == nil {
+		return "body", jsonStrings(document, nil), nil
+	}
+	return "body", []string{string(body)}, nil
+}
+
+// jsonStrings appends the string values of the decoded JSON document to values.
+func jsonStrings(document interface{}, values []string) []string {
+	switch v := document.(type) {
+	case string:
+		values = append(values, v)
+	case []interface{}:
+		for _, item := range v {
+			values = jsonStrings(item, values)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			values = jsonStrings(item, values)
 		}
 	}
-	return false
+	return values
 }
 
-// SQLInjectionMiddleware returns a middleware that protects against SQL injection attacks.
-func SQLInjectionMiddleware() func(http.Handler) http.Handler {
+// SQLInjectionMiddleware returns a middleware that detects SQL injection attempts with the given detector,
+// and logs or blocks them depending on its mode.
+func SQLInjectionMiddleware(detector *waf.Detector) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
+		if detector.Mode() == waf.ModeOff {
+			return next
+		}
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get logger
-			var logger = logger.GetLogger()
+			var logger = logger.FromContext(r.Context())
 
-			// Get request parameters
-			params := r.URL.Query()
-			for _, values := range params {
-				for _, value := range values {
-					if isSQLInjection(value) {
-						responses.Error(w, http.StatusBadRequest, "Potential SQL Injection Detected")
-						logger.Error("Potential SQL Injection Detected in URL parameter: " + value)
-						return
-					}
-				}
+			var queryValues []string
+			for _, values := range r.URL.Query() {
+				queryValues = append(queryValues, values...)
 			}
-
-			// Get request body
-			if r.Body != nil {
-				maxBodySize := int64(1 << 20) // 1 MB
-				body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize))
-				if err != nil {
-					responses.Error(w, http.StatusInternalServerError, "Internal Server Error")
-					logger.Error("Error reading request body: " + err.Error())
-					return
-				}
-				defer r.Body.Close()
-
-				// Check for SQL injection in the request body
-				if isSQLInjection(string(body)) {
-					responses.Error(w, http.StatusBadRequest, "Potential SQL Injection Detected")
-					logger.Error("Potential SQL Injection Detected in request body")
-					return
-				}
-
-				// Reset the body to its original state
-				r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
-			}
-
-			// Get request form data
-			if err := r.ParseForm(); err != nil {
-				// Ignore error if there is no form data
-				if !errors.Is(err, http.ErrNotMultipart) {
-					responses.Error(w, http.StatusInternalServerError, "Internal Server Error")
-					logger.Error("Error parsing form data: " + err.Error())
-					return
-				}
+			source, values, err := bodyValues(r)
+			if err != nil {
+				responses.Error(w, http.StatusInternalServerError, "Internal Server Error")
+				logger.Error("Error reading request body", "error", err)
+				return
 			}
 
-			// Check for SQL injection in form data
-			if len(r.PostForm) > 0 {
-				for _, values := range r.PostForm {
-					for _, value := range values {
-						if isSQLInjection(value) {
-							responses.Error(w, http.StatusBadRequest, "Potential SQL Injection Detected")
-							logger.Error("Potential SQL Injection Detected in form data: " + value)
-							return
-						}
+			inspect := func(source string, values []string) bool {
+				for _, value := range values {
+					rule, found := detector.Detect(value)
+					if !found {
+						continue
+					}
+					if detector.Mode() == waf.ModeBlock {
+						metrics.SQLInjectionDetections.WithLabelValues(source, rule, "blocked").Inc()
+						reportOffense(r, offenseSQLInjection)
+						responses.Error(w, http.StatusBadRequest, "Potential SQL Injection Detected")
+						logger.Error("Potential SQL injection blocked", "source", source, "rule", rule, "value", loggedValue(value))
+						return false
 					}
+					metrics.SQLInjectionDetections.WithLabelValues(source, rule, "logged").Inc()
+					logger.Warn("Potential SQL injection detected", "source", source, "rule", rule, "value", loggedValue(value))
+					// Report each part of the request once
+					return true
 				}
+				return true
+			}
+			if !inspect("query", queryValues) || !inspect(source, values) {
+				return
 			}
 
 			next.ServeHTTP(w, r)
@@ -0,0 +1,55 @@
+package middlewares
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/waf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLInjectionMiddleware(t *testing.T) {
+	t.Setenv("LOGGER_DISABLED", "true")
+
+	var body string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	})
+	serve := func(mode waf.Mode, target string, contentType string, payload string) int {
+		req := httptest.NewRequest("POST", target, bytes.NewBufferString(payload))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		SQLInjectionMiddleware(waf.NewDetector(mode, waf.Rules))(next).ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// Injections are blocked whatever their case
+	assert.Equal(t, http.StatusBadRequest, serve(waf.ModeBlock, "/tasks?title=x%27+or+1%3D1--", "", ""))
+	assert.Equal(t, http.StatusBadRequest, serve(waf.ModeBlock, "/task", "application/json", `{"title": "x' union select password from users"}`))
+	assert.Equal(t, http.StatusBadRequest, serve(waf.ModeBlock, "/task", "application/x-www-form-urlencoded", "title=admin%27--"))
+
+	// SQL keywords and JSON syntax are not injections, and the body is left for the handler
+	payload := `{"title": "UPDATE docs", "description": "-- select the #1 option"}`
+	assert.Equal(t, http.StatusOK, serve(waf.ModeBlock, "/task?q=DROP+TABLE", "application/json", payload))
+	assert.Equal(t, payload, body)
+
+	// Bodies past the inspected size are passed on whole
+	payload = `{"description": "` + strings.Repeat("a", maxInspectedBodySize) + `"}`
+	assert.Equal(t, http.StatusOK, serve(waf.ModeBlock, "/task", "application/json", payload))
+	assert.Equal(t, payload, body)
+
+	// Injections are only reported in log mode, and ignored when off
+	for _, mode := range []waf.Mode{waf.ModeLog, waf.ModeOff} {
+		assert.Equal(t, http.StatusOK, serve(mode, "/task", "application/json", `{"title": "x' or 'a'='a"}`), mode)
+		assert.Equal(t, `{"title": "x' or 'a'='a"}`, body, mode)
+	}
+
+	assert.Equal(t, `"x' or 1=1\n--"`, loggedValue("x' or 1=1\n--"))
+	logged := loggedValue(strings.Repeat("' or 1=1--", 1000))
+	assert.Len(t, logged, maxLoggedValueSize+len(`"..."`))
+}
@@ -0,0 +1,100 @@
+// Package middlewares provides IP filtering and automatic bans for the API.
+//
+// Usage:
+// Use the IPFilterMiddleware function as a middleware in your HTTP handlers to refuse the requests of clients
+// whose IP address is denied by the given filter or is banned. Register it before RateLimitMiddleware and
+// SQLInjectionMiddleware: the requests they refuse are counted as offenses of the client, and the client
+// is banned for a cooldown period once it committed too many offenses, see limiter.Bans.
+//
+// Example:
+//
+// router.Use(middlewares.IPFilterMiddleware(clientip.GetFilter(), limiter.GetBans()))
+//
+// The middleware returns a 403 Forbidden error if the client IP is denied, and a 403 Forbidden error with
+// a Retry-After header, in seconds, if the client is banned. Requests are let through if the ban store fails.
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/modules/clientip"
+	"github.com/emso-c/konzek-go-assignment/src/modules/limiter"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/metrics"
+)
+
+// Offenses counted towards a ban.
+const (
+	offenseRateLimit    = "rate_limit"
+	offenseSQLInjection = "sql_injection"
+)
+
+// offenseKey is the context key of the offense of a request, set by the middlewares refusing it.
+type offenseKey struct{}
+
+// reportOffense records that the request was refused for the given offense, so that IPFilterMiddleware
+// counts it towards a ban of the client. The offense is stored atomically since the request may be
+// handled in another goroutine, see TimeoutMiddleware.
+func reportOffense(r *http.Request, offense string) {
+	if reported, ok := r.Context().Value(offenseKey{}).(*atomic.Pointer[string]); ok {
+		reported.Store(&offense)
+	}
+}
+
+// IPFilterMiddleware returns a middleware that refuses the requests of denied and banned clients, and bans
+// the clients that committed too many offenses.
+func IPFilterMiddleware(filter *clientip.Filter, bans *limiter.Bans) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger := logger.FromContext(r.Context())
+			clientIP := ClientIP(r)
+			if clientIP == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !filter.Allowed(clientIP) {
+				metrics.IPFilterRejections.WithLabelValues("denied").Inc()
+				responses.Error(w, http.StatusForbidden, "Forbidden")
+				logger.Info("Client IP denied")
+				return
+			}
+
+			ban, banned, err := bans.Get(r.Context(), clientIP)
+			if err != nil {
+				// Fail open, an unavailable store should not take the API down
+				logger.Error("Error checking ban", "error", err)
+			}
+			if banned {
+				metrics.IPFilterRejections.WithLabelValues("banned").Inc()
+				retryAfter := secondsUntil(ban.ExpiresAt)
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				responses.Error(w, http.StatusForbidden, "Forbidden, temporarily banned")
+				logger.Info("Client IP banned", "reason", ban.Reason, "expires_at", ban.ExpiresAt)
+				return
+			}
+
+			reported := &atomic.Pointer[string]{}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), offenseKey{}, reported)))
+
+			if offense := reported.Load(); offense != nil {
+				ban, banned, err := bans.Offend(r.Context(), clientIP, *offense)
+				if err != nil {
+					logger.Error("Error recording offense", "offense", *offense, "error", err)
+					return
+				}
+				if banned {
+					metrics.BansIssued.WithLabelValues(*offense).Inc()
+					logger.Warn("Client IP banned", "offense", *offense, "expires_at", ban.ExpiresAt)
+				}
+			}
+		})
+	}
+}
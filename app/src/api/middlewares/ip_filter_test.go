@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/clientip"
+	"github.com/emso-c/konzek-go-assignment/src/modules/limiter"
+	"github.com/emso-c/konzek-go-assignment/src/modules/waf"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPFilterMiddleware(t *testing.T) {
+	t.Setenv("LOGGER_DISABLED", "true")
+
+	store := limiter.NewMemoryStore()
+	fallback, err := limiter.NewLimiter(store, limiter.FixedWindow, 1, time.Minute)
+	require.NoError(t, err)
+	deny, err := clientip.ParseNetworks("198.51.100.0/24")
+	require.NoError(t, err)
+	bans := limiter.NewBans(store, 2, time.Minute, time.Hour)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/list", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	router.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	router.Use(IPFilterMiddleware(clientip.NewFilter(nil, deny), bans))
+	router.Use(SQLInjectionMiddleware(waf.NewDetector(waf.ModeBlock, waf.Rules)))
+	router.Use(RateLimitMiddleware(limiter.NewPolicies(fallback)))
+
+	serve := func(path, addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = addr
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Denied addresses are refused
+	assert.Equal(t, http.StatusForbidden, serve("/list", "198.51.100.7:443").Code)
+
+	// Clients are banned after repeated 429s
+	assert.Equal(t, http.StatusOK, serve("/list", "192.0.2.1:443").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve("/list", "192.0.2.1:443").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve("/list", "192.0.2.1:443").Code)
+	rr := serve("/list", "192.0.2.1:443")
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	retryAfter, err := strconv.Atoi(rr.Header().Get("Retry-After"))
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Hour.Seconds(), retryAfter, 5)
+
+	// and after repeated SQL injection blocks
+	assert.Equal(t, http.StatusBadRequest, serve("/search?q=%27+OR+1%3D1--", "192.0.2.2:443").Code)
+	assert.Equal(t, http.StatusBadRequest, serve("/search?q=%27+OR+1%3D1--", "192.0.2.2:443").Code)
+	ban, banned, err := bans.Get(context.Background(), "192.0.2.2")
+	assert.NoError(t, err)
+	assert.True(t, banned)
+	assert.Equal(t, "sql_injection", ban.Reason)
+	assert.Equal(t, http.StatusForbidden, serve("/list", "192.0.2.2:443").Code)
+}
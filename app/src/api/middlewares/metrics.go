@@ -0,0 +1,75 @@
+// Package middlewares provides request instrumentation for the API.
+//
+// Usage:
+// Use the MetricsMiddleware function as a middleware on a Gorilla Mux router to count requests and
+// observe their latency by route template, method and status code. The route template is used rather
+// than the path so that the number of series stays bounded, requests that match no route are
+// reported with the "unmatched" route.
+//
+// Example:
+//
+// router.Use(middlewares.MetricsMiddleware())
+//
+// The metrics are served by the handler of the metrics module registry, e.g. on /metrics.
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/metrics"
+	"github.com/gorilla/mux"
+)
+
+// statusRecorder is a http.ResponseWriter that records the status code of the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code and writes it.
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the body, with an implicit 200 OK status if none was written.
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// routeTemplate returns the path template of the route matched by the request.
+// Subrouter routes have no handler, they are only current when no route inside them matched.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil && route.GetHandler() != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
+
+// MetricsMiddleware returns a middleware that records the count and latency of requests.
+func MetricsMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w}
+
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			route := routeTemplate(r)
+			metrics.HTTPRequests.WithLabelValues(route, r.Method, strconv.Itoa(recorder.status)).Inc()
+			metrics.HTTPRequestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/metrics"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	sub := router.PathPrefix("/metrics-sub").Subrouter()
+	sub.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	router.Use(MetricsMiddleware())
+	router.NotFoundHandler = MetricsMiddleware()(NotFoundMiddleware())
+	sub.NotFoundHandler = router.NotFoundHandler
+
+	for _, path := range []string{"/metrics-test/1", "/metrics-test/2", "/metrics-sub/ok", "/metrics-sub/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, metrics.GetRegistry().Write(&out))
+	lines := out.String()
+	assert.Contains(t, lines, `http_requests_total{route="/metrics-test/{id}",method="GET",status="418"} 2`)
+	assert.Contains(t, lines, `http_requests_total{route="/metrics-sub/ok",method="GET",status="200"} 1`)
+	assert.Contains(t, lines, `http_requests_total{route="unmatched",method="GET",status="404"} 1`)
+	assert.False(t, strings.Contains(lines, `route="/metrics-sub"`))
+}
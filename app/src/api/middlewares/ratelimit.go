@@ -1,75 +1,123 @@
 // Package middlewares provides HTTP middlewares for the API.
 //
 // Usage:
-// Use the RateLimitMiddleware function as a middleware in your HTTP handlers to limit the
-// number of requests from a single remote address. The middleware retrieves the remote address
-// from the request header, or from the environment variable MOCK_REMOTE_ADDR if the environment
-// variable ENV_LOCAL is set to "true". The middleware uses a limiter to count and limit the number
-// of requests from each remote address.
+// Use the RateLimitMiddleware function as a middleware on a Gorilla Mux router to limit the number of
+// requests of each client with the given rate limit policies. Authenticated clients are identified by
+// their API key or user, and anonymous clients by their IP address, see ClientIPMiddleware.
+// Register the middleware after AuthMiddleware, so that the policy of the client's tier applies:
+// `api_key` for clients authenticated with an API key, then the role of the user, e.g. `admin`.
+// The policy of the matched route, if any, applies in addition. Requests from allowlisted addresses are
+// not limited, and requests are let through if the limiter store fails.
 //
-// Example:
-//
-// Use the RateLimitMiddleware function as a middleware in your HTTP handlers:
-//
-// http.Handle("/api/tasks", middlewares.RateLimitMiddleware()(http.HandlerFunc(handler)))
+// Use the AddressRateLimitMiddleware function in front of AuthMiddleware to limit the requests of each
+// IP address with the default policy, i.e. the tier of anonymous clients, before their credentials are verified,
+// so that requests with missing or invalid credentials are limited too.
 //
-// The middleware returns a 400 Bad Request error if the remote address is missing, and a 429 Too Many
-// Requests error if the remote address has exceeded the rate limit.
-//
-// The GetRemoteAddr function retrieves the remote address from the request header, or from the
-// environment variable MOCK_REMOTE_ADDR if the environment variable ENV_LOCAL is set to "true".
-// If the remote address is missing, GetRemoteAddr returns an empty string.
+// Every limited response carries the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers,
+// the latter in seconds, so that clients can pace their requests.
 //
 // Example:
-// Get the remote address from the request:
 //
-// remoteAddr := middlewares.GetRemoteAddr(r)
+// router.Use(middlewares.AddressRateLimitMiddleware(limiter.GetPolicies()))
+// router.Use(middlewares.AuthMiddleware(apiKeys, users))
+// router.Use(middlewares.RateLimitMiddleware(limiter.GetPolicies()))
 //
-// You can use the remote address to identify the client making the request, or to implement custom
-// rate limiting logic.
+// The middleware returns a 400 Bad Request error if the client IP cannot be resolved, and a 429 Too Many
+// Requests error with a Retry-After header, in seconds, if the client has exceeded the rate limit.
 package middlewares
 
 import (
+	"math"
 	"net/http"
-	"os"
+	"strconv"
+	"time"
 
 	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
 	"github.com/emso-c/konzek-go-assignment/src/modules/limiter"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/metrics"
+	"github.com/gorilla/mux"
 )
 
-// GetRemoteAddr retrieves the remote address from the request header, or from the environment
-// if the environment variable ENV_LOCAL is set to "true".
-func GetRemoteAddr(r *http.Request) string {
-	remoteAddr := r.Header.Get("REMOTE_ADDR")
-	if remoteAddr == "" {
-		remoteAddr = r.Header.Get("X-Forwarded-For")
+// rateLimitClient returns the client identifier and the tiers of the request, in order of precedence.
+func rateLimitClient(r *http.Request, clientIP string) (string, []string) {
+	identity := auth.FromContext(r.Context())
+	switch {
+	case identity == nil:
+		return "addr:" + clientIP, nil
+	case identity.APIKeyID != 0:
+		return "key:" + strconv.FormatUint(uint64(identity.APIKeyID), 10), []string{"api_key", identity.Role}
+	default:
+		return "user:" + strconv.FormatUint(uint64(identity.UserID), 10), []string{identity.Role}
 	}
-	// Use mock remote address for local testing
-	if os.Getenv("ENV_LOCAL") == "true" {
-		remoteAddr = os.Getenv("MOCK_REMOTE_ADDR")
+}
+
+// secondsUntil returns the number of seconds until t, rounded up.
+func secondsUntil(t time.Time) int {
+	seconds := int(math.Ceil(time.Until(t).Seconds()))
+	if seconds < 0 {
+		return 0
 	}
-	return remoteAddr
+	return seconds
+}
+
+// RateLimitMiddleware returns a middleware that limits the number of requests of each client with the given policies.
+func RateLimitMiddleware(policies *limiter.Policies) func(http.Handler) http.Handler {
+	return rateLimit(policies, true)
+}
+
+// AddressRateLimitMiddleware returns a middleware that limits the number of requests of each client IP address
+// with the default policy of the given policies, regardless of the identity of the client and of the route.
+func AddressRateLimitMiddleware(policies *limiter.Policies) func(http.Handler) http.Handler {
+	return rateLimit(policies, false)
 }
 
-// RateLimitMiddleware returns a middleware that limits the number of requests from a single remote address.
-func RateLimitMiddleware() func(http.Handler) http.Handler {
+// rateLimit returns a middleware that limits the number of requests of each client with the given policies,
+// identified by its identity, tiers and route if byIdentity is set, or by its IP address only otherwise.
+func rateLimit(policies *limiter.Policies, byIdentity bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			remoteAddr := GetRemoteAddr(r)
-			if remoteAddr == "" {
-				err := responses.Error(w, http.StatusBadRequest, "Bad request, missing remote address")
-				if err != nil {
-					panic(err)
+			clientIP := ClientIP(r)
+			if clientIP == "" {
+				if err := responses.Error(w, http.StatusBadRequest, "Bad request, invalid client address"); err != nil {
+					logger.FromContext(r.Context()).Error("Error writing response", "error", err)
 				}
 				return
 			}
+			if policies.IsAllowlisted(clientIP) {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-			l := limiter.GetLimiter()
-			l.Increment(remoteAddr)
-			if l.ExceedsLimit(remoteAddr) {
-				err := responses.Error(w, http.StatusTooManyRequests, "Too many requests")
-				if err != nil {
-					panic(err)
+			client, route, tiers := "addr:"+clientIP, "", []string(nil)
+			if byIdentity {
+				if current := mux.CurrentRoute(r); current != nil {
+					route = current.GetName()
+				}
+				client, tiers = rateLimitClient(r, clientIP)
+			}
+			result, err := policies.Allow(r.Context(), client, route, tiers...)
+			if err != nil {
+				// Fail open, an unavailable limiter store should not take the API down
+				logger.FromContext(r.Context()).Error("Error checking rate limit", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reset := secondsUntil(result.ResetAt)
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(reset))
+			if !result.Allowed {
+				metrics.RateLimitRejections.Inc()
+				reportOffense(r, offenseRateLimit)
+				if reset < 1 {
+					reset = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(reset))
+				if err := responses.Error(w, http.StatusTooManyRequests, "Too many requests"); err != nil {
+					logger.FromContext(r.Context()).Error("Error writing response", "error", err)
 				}
 				return
 			}
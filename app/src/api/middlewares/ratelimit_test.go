@@ -0,0 +1,125 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
+	"github.com/emso-c/konzek-go-assignment/src/modules/clientip"
+	"github.com/emso-c/konzek-go-assignment/src/modules/limiter"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Setenv("LOGGER_DISABLED", "true")
+
+	fallback, err := limiter.NewLimiter(limiter.NewMemoryStore(), limiter.FixedWindow, 2, time.Minute)
+	require.NoError(t, err)
+	policies := limiter.NewPolicies(fallback)
+	require.NoError(t, policies.SetRoute("test.create", limiter.Policy{Limit: 1, Window: time.Minute}))
+	require.NoError(t, policies.SetTier("api_key", limiter.Policy{Limit: 3, Window: time.Minute}))
+	require.NoError(t, policies.Allowlist("10.0.0.0/8"))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/list", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET").Name("test.list")
+	router.HandleFunc("/create", func(w http.ResponseWriter, r *http.Request) {}).Methods("POST").Name("test.create")
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Test-Key") != "" {
+				r = r.WithContext(auth.NewContext(r.Context(), &auth.Identity{UserID: 1, Role: "user", APIKeyID: 7}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Use(RateLimitMiddleware(policies))
+
+	serve := func(method, path, addr string, apiKey bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = addr
+		if apiKey {
+			req.Header.Set("X-Test-Key", "yes")
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Every limited response carries the rate limit headers
+	rr := serve("GET", "/list", "192.0.2.1:443", false)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, rr.Header().Get("RateLimit-Reset"))
+
+	// The route policy applies in addition to the default limit
+	rr = serve("POST", "/create", "192.0.2.1:443", false)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	rr = serve("POST", "/create", "192.0.2.2:443", false)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = serve("POST", "/create", "192.0.2.2:443", false)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Limit"))
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+
+	// The default limit of the first client is exhausted
+	rr = serve("GET", "/list", "192.0.2.1:443", false)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+	// API keys are limited by their tier, regardless of the address
+	for i := 0; i < 3; i++ {
+		rr = serve("GET", "/list", "192.0.2.1:443", true)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "3", rr.Header().Get("RateLimit-Limit"))
+	}
+	rr = serve("GET", "/list", "192.0.2.3:443", true)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+	// Allowlisted addresses are not limited
+	for i := 0; i < 5; i++ {
+		rr = serve("GET", "/list", "10.1.2.3:443", false)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("RateLimit-Limit"))
+	}
+
+	// Requests without a valid client address are rejected
+	rr = serve("GET", "/list", "", false)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestAddressRateLimitMiddleware(t *testing.T) {
+	t.Setenv("LOGGER_DISABLED", "true")
+
+	store := limiter.NewMemoryStore()
+	fallback, err := limiter.NewLimiter(store, limiter.FixedWindow, 2, time.Minute)
+	require.NoError(t, err)
+	policies := limiter.NewPolicies(fallback)
+	bans := limiter.NewBans(store, 2, time.Minute, time.Hour)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/tasks", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+	router.Use(IPFilterMiddleware(clientip.NewFilter(nil, nil), bans))
+	router.Use(AddressRateLimitMiddleware(policies))
+	router.Use(AuthMiddleware(nil, nil))
+	router.Use(RateLimitMiddleware(policies))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/tasks", nil)
+		req.RemoteAddr = "192.0.2.1:443"
+		req.Header.Set("Authorization", "ApiKey not-a-key")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Requests with invalid credentials are limited before they are verified, and count towards a ban
+	assert.Equal(t, http.StatusUnauthorized, serve().Code)
+	assert.Equal(t, http.StatusUnauthorized, serve().Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve().Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve().Code)
+	assert.Equal(t, http.StatusForbidden, serve().Code)
+}
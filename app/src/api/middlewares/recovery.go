@@ -0,0 +1,57 @@
+// Package middlewares provides panic recovery for the API.
+//
+// Usage:
+// Use the RecoveryMiddleware function as a middleware on a Gorilla Mux router so that a panicking handler
+// answers its request with 500 Internal Server Error instead of crashing the process. The panic is logged
+// with its stack trace and the request ID, and counted by the panics_recovered_total metric.
+//
+// Handlers running in another goroutine than the one serving the request, such as the jobs of the worker
+// pool, are not covered by the middleware: defer the Recover function at the start of such goroutines.
+//
+// Example:
+//
+// router.Use(middlewares.RecoveryMiddleware())
+//
+//	go func() {
+//		defer middlewares.Recover(w, r)
+//		handler.ServeHTTP(w, r)
+//	}()
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/metrics"
+)
+
+// Recover recovers from a panic of a handler serving the request and answers it with 500 Internal Server Error.
+// It must be called directly by a deferred call. http.ErrAbortHandler panics are not recovered,
+// as they are the way to abort a response on purpose.
+func Recover(w http.ResponseWriter, r *http.Request) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	if err, ok := p.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+		panic(p)
+	}
+
+	metrics.PanicsRecovered.WithLabelValues("http").Inc()
+	logger.FromContext(r.Context()).Error("Recovered from panic",
+		"panic", p, "method", r.Method, "path", r.URL.Path, "stack", string(debug.Stack()))
+	responses.Error(w, http.StatusInternalServerError, "Internal server error")
+}
+
+// RecoveryMiddleware returns a middleware that recovers from the panics of the next handlers.
+func RecoveryMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer Recover(w, r)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	t.Setenv("LOGGER_DISABLED", "true")
+	t.Setenv("TIMEOUTS_DEFAULT", "0")
+	t.Setenv("TIMEOUTS_TEST_BOUNDED", "5")
+
+	panicky := func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/panic", panicky).Name("test.panic")
+	router.HandleFunc("/bounded", panicky).Name("test.bounded")
+	router.HandleFunc("/abort", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}).Name("test.abort")
+	router.Use(RecoveryMiddleware())
+	router.Use(TimeoutMiddleware())
+
+	// Panics are answered with the standard error body, also from the goroutine of a timed out route
+	for _, path := range []string{"/panic", "/bounded"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusInternalServerError, rr.Code, path)
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.NotEmpty(t, body)
+	}
+
+	// Aborted handlers are left to net/http
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+	})
+}
@@ -0,0 +1,50 @@
+// Package middlewares provides request correlation for the API.
+//
+// Usage:
+// Use the RequestIDMiddleware function as a middleware in your HTTP handlers to give every request an ID.
+// The ID is read from the `X-Request-ID` request header if it is set by a proxy and valid, or generated otherwise.
+// It is echoed in the `X-Request-ID` response header and stored in the request context, so that the logger
+// returned by `logger.FromContext(r.Context())` attaches it to every log line of the request.
+//
+// Example:
+//
+// http.Handle("/api/tasks", middlewares.RequestIDMiddleware()(http.HandlerFunc(handler)))
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+)
+
+const requestIDHeaderName = "X-Request-ID"
+
+// requestIDPattern restricts incoming request IDs so that they cannot forge log lines.
+var requestIDPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,128}$`)
+
+// newRequestID generates a random request ID.
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(id)
+}
+
+// RequestIDMiddleware returns a middleware that assigns an ID to every request.
+func RequestIDMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(requestIDHeaderName)
+			if !requestIDPattern.MatchString(requestID) {
+				requestID = newRequestID()
+			}
+
+			w.Header().Set(requestIDHeaderName, requestID)
+			next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), requestID)))
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := RequestIDMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logger.RequestID(r.Context())
+	}))
+
+	// Generated
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	assert.Len(t, seen, 32)
+	assert.Equal(t, seen, rr.Header().Get("X-Request-ID"))
+
+	// Propagated from the caller
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "upstream-42")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, "upstream-42", seen)
+	assert.Equal(t, "upstream-42", rr.Header().Get("X-Request-ID"))
+
+	// Replaced when invalid
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "forged\nINFO: line")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Len(t, seen, 32)
+}
@@ -0,0 +1,155 @@
+// Package middlewares provides per-route request timeouts for the API.
+//
+// Usage:
+// Use the TimeoutMiddleware function as a middleware on a Gorilla Mux router to bound the time spent
+// on every request. The timeout of a route is read from the TIMEOUTS_<ROUTE NAME> environment variable,
+// in seconds, with the dots of the route name replaced by underscores (e.g. TIMEOUTS_TASKS_LIST for the
+// "tasks.list" route), and defaults to TIMEOUTS_DEFAULT. A timeout of 0 disables it.
+//
+// The request context is cancelled once the timeout expires or the client disconnects, which aborts the
+// database queries of the request and lets queued worker jobs be dropped. Requests that time out are
+// answered with 504 Gateway Timeout, anything the handler writes afterwards is discarded.
+//
+// Example:
+//
+// router.Use(middlewares.TimeoutMiddleware())
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/gorilla/mux"
+)
+
+// routeTimeout returns the timeout of the route matched by the request, 0 if it has none.
+func routeTimeout(r *http.Request) time.Duration {
+	value := ""
+	if route := mux.CurrentRoute(r); route != nil && route.GetName() != "" {
+		value = os.Getenv("TIMEOUTS_" + strings.ToUpper(strings.ReplaceAll(route.GetName(), ".", "_")))
+	}
+	if value == "" {
+		value = os.Getenv("TIMEOUTS_DEFAULT")
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// timeoutWriter buffers a response until the handler returns, so that it can be dropped
+// in favor of a timeout response. Writes after the timeout fail with http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	ctx         context.Context
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// expired reports whether the request timed out. The context of the handler is checked as well as timedOut,
+// since the handler can wake up on its cancellation before the middleware marks the writer as timed out.
+func (tw *timeoutWriter) expired() bool {
+	return tw.timedOut || tw.ctx.Err() != nil
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired() || tw.wroteHeader {
+		return
+	}
+	tw.status = status
+	tw.wroteHeader = true
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.status = http.StatusOK
+		tw.wroteHeader = true
+	}
+	return tw.body.Write(b)
+}
+
+// TimeoutMiddleware returns a middleware that applies the configured timeout of each route.
+func TimeoutMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := routeTimeout(r)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{ctx: ctx, header: make(http.Header)}
+			done := make(chan struct{})
+			aborted := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					// Recover lets aborted handlers panic, abort the response from the serving goroutine
+					if p := recover(); p != nil {
+						aborted <- p
+						return
+					}
+					close(done)
+				}()
+				// The handler runs in its own goroutine, where RecoveryMiddleware cannot recover its panics
+				defer Recover(tw, r)
+				next.ServeHTTP(tw, r)
+			}()
+
+			select {
+			case p := <-aborted:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				for key, values := range tw.header {
+					w.Header()[key] = values
+				}
+				if !tw.wroteHeader {
+					tw.status = http.StatusOK
+				}
+				w.WriteHeader(tw.status)
+				w.Write(tw.body.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				logger := logger.FromContext(r.Context())
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					responses.Error(w, http.StatusGatewayTimeout, "Request timed out")
+					logger.Warn("Request timed out", "timeout", timeout)
+					return
+				}
+				// The client disconnected, there is no one to answer
+				logger.Info("Client disconnected", "error", ctx.Err())
+			}
+		})
+	}
+}
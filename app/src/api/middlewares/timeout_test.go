@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutMiddleware(t *testing.T) {
+	t.Setenv("LOGGER_DISABLED", "true")
+	t.Setenv("TIMEOUTS_DEFAULT", "5")
+	t.Setenv("TIMEOUTS_TEST_SLOW", "0.05")
+	t.Setenv("TIMEOUTS_TEST_UNBOUNDED", "0")
+
+	late := make(chan error, 1)
+	router := mux.NewRouter()
+	router.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}).Name("test.fast")
+	router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		_, err := w.Write([]byte("too late"))
+		late <- err
+	}).Name("test.slow")
+	router.HandleFunc("/unbounded", func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.Context().Deadline()
+		assert.False(t, ok)
+	}).Name("test.unbounded")
+	router.Use(TimeoutMiddleware())
+
+	// The buffered response is written once the handler returns
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/fast", nil))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "yes", rr.Header().Get("X-Test"))
+	assert.Equal(t, "created", rr.Body.String())
+
+	// Timed out requests are answered with 504 and late writes are discarded
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/slow", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	assert.ErrorIs(t, <-late, http.ErrHandlerTimeout)
+	assert.NotContains(t, rr.Body.String(), "too late")
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/unbounded", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
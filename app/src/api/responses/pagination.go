@@ -0,0 +1,92 @@
+// This package contains the response functions for the API
+//
+// # This file contains the Paginated response function
+//
+// Usage:
+// Use the Paginated function to return a page of a list endpoint wrapped in an envelope
+// with the total number of items and links to the next and previous pages.
+// Offset pagination is used by default. Set Pagination.Cursor to use keyset pagination,
+// in which case the next link carries an `after` cursor instead of a page number.
+//
+// Example:
+//
+// err := responses.Paginated(w, r, tasks, responses.Pagination{Page: 1, Size: 10, Total: 42})
+//
+//	if err != nil {
+//		panic(err)
+//	}
+package responses
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// Pagination describes the page being returned by a list endpoint.
+type Pagination struct {
+	// Page is the 1-based page number, ignored in cursor mode.
+	Page int
+	// Size is the maximum number of items in a page.
+	Size int
+	// Total is the number of items across all pages.
+	Total int
+	// Cursor enables keyset pagination.
+	Cursor bool
+	// NextCursor is the `after` value of the next page in cursor mode, empty if this is the last page.
+	NextCursor string
+}
+
+// PageEnvelope is the JSON body returned by Paginated.
+type PageEnvelope struct {
+	Items interface{} `json:"items"`
+	Total int         `json:"total"`
+	Page  int         `json:"page,omitempty"`
+	Size  int         `json:"size"`
+	Next  string      `json:"next,omitempty"`
+	Prev  string      `json:"prev,omitempty"`
+}
+
+// pageLink returns the URL of the current request with the given query parameters replaced.
+func pageLink(r *http.Request, params map[string]string) string {
+	query := r.URL.Query()
+	for key, value := range params {
+		if value == "" {
+			query.Del(key)
+		} else {
+			query.Set(key, value)
+		}
+	}
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// Paginated writes a 200 OK response containing the given items in a PageEnvelope.
+// A nil slice of items is written as an empty JSON array.
+func Paginated(w http.ResponseWriter, r *http.Request, items interface{}, p Pagination) error {
+	if v := reflect.ValueOf(items); !v.IsValid() || (v.Kind() == reflect.Slice && v.IsNil()) {
+		items = []interface{}{}
+	}
+
+	envelope := PageEnvelope{
+		Items: items,
+		Total: p.Total,
+		Size:  p.Size,
+	}
+	size := strconv.Itoa(p.Size)
+
+	if p.Cursor {
+		if p.NextCursor != "" {
+			envelope.Next = pageLink(r, map[string]string{"after": p.NextCursor, "size": size, "page": ""})
+		}
+		return JSON(w, http.StatusOK, envelope)
+	}
+
+	envelope.Page = p.Page
+	if p.Page*p.Size < p.Total {
+		envelope.Next = pageLink(r, map[string]string{"page": strconv.Itoa(p.Page + 1), "size": size})
+	}
+	if p.Page > 1 {
+		envelope.Prev = pageLink(r, map[string]string{"page": strconv.Itoa(p.Page - 1), "size": size})
+	}
+	return JSON(w, http.StatusOK, envelope)
+}
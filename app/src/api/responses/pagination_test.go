@@ -0,0 +1,44 @@
+package responses
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginated(t *testing.T) {
+	// Offset pagination
+	req := httptest.NewRequest("GET", "/api/tasks?page=2&size=10&status=pending", nil)
+	rr := httptest.NewRecorder()
+	assert.NoError(t, Paginated(rr, req, []int{1, 2}, Pagination{Page: 2, Size: 10, Total: 25}))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var envelope PageEnvelope
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &envelope))
+	assert.Equal(t, 25, envelope.Total)
+	assert.Equal(t, 2, envelope.Page)
+	assert.Equal(t, "/api/tasks?page=3&size=10&status=pending", envelope.Next)
+	assert.Equal(t, "/api/tasks?page=1&size=10&status=pending", envelope.Prev)
+
+	// Last page has no next link
+	req = httptest.NewRequest("GET", "/api/tasks?page=3&size=10", nil)
+	rr = httptest.NewRecorder()
+	assert.NoError(t, Paginated(rr, req, nil, Pagination{Page: 3, Size: 10, Total: 25}))
+	envelope = PageEnvelope{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &envelope))
+	assert.Empty(t, envelope.Next)
+	assert.JSONEq(t, `{"items": [], "total": 25, "page": 3, "size": 10, "prev": "/api/tasks?page=2&size=10"}`, rr.Body.String())
+
+	// Cursor pagination
+	req = httptest.NewRequest("GET", "/api/tasks?after=10&size=5&page=4", nil)
+	rr = httptest.NewRecorder()
+	assert.NoError(t, Paginated(rr, req, []int{11, 12, 13, 14, 15}, Pagination{Size: 5, Total: 100, Cursor: true, NextCursor: "15"}))
+	envelope = PageEnvelope{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &envelope))
+	assert.Equal(t, 0, envelope.Page)
+	assert.Equal(t, "/api/tasks?after=15&size=5", envelope.Next)
+	assert.Empty(t, envelope.Prev)
+}
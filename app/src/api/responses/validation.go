@@ -0,0 +1,30 @@
+// This package contains the response functions for the API
+//
+// # This file contains the ValidationError response function
+//
+// Usage:
+// Use the ValidationError function to return a 422 Unprocessable Entity error response
+// listing the invalid fields of a request, see validation.Struct
+//
+// Example:
+//
+// err := responses.ValidationError(w, validation.Struct(req))
+//
+//	if err != nil {
+//		panic(err)
+//	}
+package responses
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/validation"
+)
+
+func ValidationError(w http.ResponseWriter, errs validation.Errors) error {
+	code := http.StatusUnprocessableEntity
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	return json.NewEncoder(w).Encode(map[string]map[string]interface{}{"error": {"code": code, "message": "Validation failed", "fields": errs}})
+}
@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"github.com/emso-c/konzek-go-assignment/src/api/controllers"
+	"github.com/emso-c/konzek-go-assignment/src/database"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
+	"github.com/gorilla/mux"
+)
+
+// RegisterAPIKeysRouter registers the routes related to the API keys of the authenticated user.
+func RegisterAPIKeysRouter(router *mux.Router) {
+	logger := logger.GetLogger()
+	kc := controllers.NewAPIKeyController(repositories.NewPostgresAPIKeyRepository(database.GetDatabase()))
+
+	keyRouter := router.PathPrefix("/keys").Subrouter()
+	keyRouter.HandleFunc("", enqueueJob(kc.GetAPIKeys())).Methods("GET").Name("keys.list")
+	keyRouter.HandleFunc("", enqueueJob(kc.CreateAPIKey())).Methods("POST").Name("keys.create")
+	keyRouter.HandleFunc("/{id}", enqueueJob(kc.RevokeAPIKey())).Methods("DELETE").Name("keys.revoke")
+
+	logger.Info("API keys router registered")
+}
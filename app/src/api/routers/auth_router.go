@@ -0,0 +1,27 @@
+package routers
+
+import (
+	"github.com/emso-c/konzek-go-assignment/src/api/controllers"
+	"github.com/emso-c/konzek-go-assignment/src/database"
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
+	"github.com/gorilla/mux"
+)
+
+// RegisterAuthRouter registers the routes related to user registration and authentication.
+// These routes do not require authentication.
+func RegisterAuthRouter(router *mux.Router) {
+	logger := logger.GetLogger()
+	ac := controllers.NewAuthController(
+		repositories.NewPostgresUserRepository(database.GetDatabase()),
+		auth.GetTokenManager(),
+	)
+
+	authRouter := router.PathPrefix("/auth").Subrouter()
+	authRouter.HandleFunc("/register", enqueueJob(ac.Register())).Methods("POST").Name("auth.register")
+	authRouter.HandleFunc("/login", enqueueJob(ac.Login())).Methods("POST").Name("auth.login")
+	authRouter.HandleFunc("/refresh", enqueueJob(ac.Refresh())).Methods("POST").Name("auth.refresh")
+
+	logger.Info("Auth router registered")
+}
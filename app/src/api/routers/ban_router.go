@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/emso-c/konzek-go-assignment/src/api/controllers"
+	"github.com/emso-c/konzek-go-assignment/src/modules/limiter"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/gorilla/mux"
+)
+
+// RegisterBansRouter registers the routes managing the temporary bans of client IP addresses.
+func RegisterBansRouter(router *mux.Router) {
+	logger := logger.GetLogger()
+	bc := controllers.NewBanController(limiter.GetBans())
+
+	banRouter := router.PathPrefix("/admin/bans").Subrouter()
+	banRouter.HandleFunc("", bc.GetBans()).Methods("GET").Name("bans.list")
+	banRouter.HandleFunc("", bc.CreateBan()).Methods("POST").Name("bans.create")
+	banRouter.HandleFunc("/{address}", bc.DeleteBan()).Methods("DELETE").Name("bans.delete")
+
+	logger.Info("Bans router registered")
+}
@@ -0,0 +1,19 @@
+package routers
+
+import (
+	"github.com/emso-c/konzek-go-assignment/src/api/controllers"
+	"github.com/emso-c/konzek-go-assignment/src/modules/csrf"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/gorilla/mux"
+)
+
+// RegisterCSRFRouter registers the route issuing CSRF tokens.
+// This route does not require authentication.
+func RegisterCSRFRouter(router *mux.Router) {
+	logger := logger.GetLogger()
+	cc := controllers.NewCSRFController(csrf.GetProtector())
+
+	router.HandleFunc("/csrf", cc.GetCSRFToken()).Methods("GET").Name("csrf.token")
+
+	logger.Info("CSRF router registered")
+}
@@ -0,0 +1,49 @@
+package routers
+
+import (
+	"github.com/emso-c/konzek-go-assignment/src/api/controllers"
+	"github.com/emso-c/konzek-go-assignment/src/database"
+	"github.com/emso-c/konzek-go-assignment/src/modules/limiter"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
+	"github.com/gorilla/mux"
+)
+
+// newHealthController creates the HealthController of the shared database, worker pool and rate limiter.
+func newHealthController() *controllers.HealthController {
+	migrations, err := database.Migrations()
+	if err != nil {
+		logger.GetLogger().Fatal("Error loading migrations", "error", err)
+	}
+	db := database.GetDatabase()
+	return controllers.NewHealthController(
+		db,
+		database.NewMigrator(db, migrations),
+		worker_manager.GetWorkerManager(),
+		limiter.GetLimiter(),
+	)
+}
+
+// RegisterHealthRouter registers the liveness and readiness routes.
+// They should be registered on the root router, before the /api subrouter,
+// so that they are not rate limited and do not require a CSRF token.
+func RegisterHealthRouter(router *mux.Router) {
+	logger := logger.GetLogger()
+	hc := newHealthController()
+
+	router.HandleFunc("/healthz", hc.Liveness()).Methods("GET").Name("health.liveness")
+	router.HandleFunc("/readyz", hc.Readiness()).Methods("GET").Name("health.readiness")
+
+	logger.Info("Health router registered")
+}
+
+// RegisterStatusRouter registers the system status route, which exposes internals of the application
+// and should be registered on a router that requires authentication, but is not rate limited.
+func RegisterStatusRouter(router *mux.Router) {
+	logger := logger.GetLogger()
+	hc := newHealthController()
+
+	router.HandleFunc("/status", hc.Status()).Methods("GET").Name("health.status")
+
+	logger.Info("Status router registered")
+}
@@ -0,0 +1,22 @@
+package routers
+
+import (
+	"github.com/emso-c/konzek-go-assignment/src/api/controllers"
+	"github.com/emso-c/konzek-go-assignment/src/database"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
+	"github.com/gorilla/mux"
+)
+
+// RegisterJobsRouter registers the routes inspecting and retrying background jobs.
+func RegisterJobsRouter(router *mux.Router) {
+	logger := logger.GetLogger()
+	jc := controllers.NewJobController(repositories.NewPostgresJobRepository(database.GetDatabase()))
+
+	jobRouter := router.PathPrefix("/jobs").Subrouter()
+	jobRouter.HandleFunc("", jc.GetJobs()).Methods("GET").Name("jobs.list")
+	jobRouter.HandleFunc("/{id}", jc.GetJob()).Methods("GET").Name("jobs.get")
+	jobRouter.HandleFunc("/{id}/retry", jc.RetryJob()).Methods("POST").Name("jobs.retry")
+
+	logger.Info("Jobs router registered")
+}
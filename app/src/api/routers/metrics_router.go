@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/metrics"
+	"github.com/gorilla/mux"
+)
+
+// RegisterMetricsRouter registers the Prometheus metrics route.
+// Like the health router, it should be registered on the root router, before the /api subrouter.
+func RegisterMetricsRouter(router *mux.Router) {
+	logger := logger.GetLogger()
+
+	router.Handle("/metrics", metrics.GetRegistry().Handler()).Methods("GET").Name("metrics")
+
+	logger.Info("Metrics router registered")
+}
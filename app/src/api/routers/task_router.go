@@ -1,58 +1,116 @@
 // Package routers provides functions for registering HTTP routers and handlers for various endpoints.
 //
 // Endpoints:
+// GET /healthz - Reports that the process is alive.
+// GET /readyz - Reports whether the database, migrations and worker pool are ready to serve requests.
+// GET /status - Reports database pool statistics, worker statuses and the rate limiter size, to admins.
+// GET /metrics - Serves the metrics in the Prometheus text exposition format.
+// POST /auth/register - Creates a new user account.
+// POST /auth/login - Issues an access token and a refresh token for valid credentials.
+// POST /auth/refresh - Exchanges a refresh token for a new pair of tokens.
+// GET /csrf - Issues the CSRF token to submit in the X-CSRF-Token header of unsafe requests.
 // GET /tasks - Retrieves a list of tasks from the database based on pagination parameters.
 // GET /task/{id} - Retrieves a task from the database based on the provided ID.
 // POST /task - Creates a new task in the database.
 // PUT /task/{id} - Updates an existing task in the database based on the provided ID.
+// POST /task/{id}/transition - Moves a task to a new status following the task status lifecycle.
 // DELETE /task/{id} - Deletes a task from the database based on the provided ID.
+// GET /keys - Lists the API keys of the authenticated user.
+// POST /keys - Creates an API key, the key is only shown in the response.
+// DELETE /keys/{id} - Revokes an API key.
+// GET /admin/workers - Reports the size, bounds and queue of the worker pool.
+// PUT /admin/workers - Resizes the worker pool within its bounds.
+// GET /jobs - Lists the background jobs, optionally filtered by status and type.
+// GET /jobs/{id} - Retrieves a background job, including its attempts and last error.
+// POST /jobs/{id}/retry - Moves a dead background job back to pending.
+// GET /admin/bans - Lists the current bans of client IP addresses.
+// POST /admin/bans - Bans a client IP address for a cooldown period.
+// DELETE /admin/bans/{address} - Lifts the ban of a client IP address.
 //
 // Usage:
-// Use the RegisterTasksRouter function to register the tasks router with the provided Gorilla Mux router.
+// Use the RegisterHealthRouter, RegisterMetricsRouter, RegisterAuthRouter, RegisterCSRFRouter, RegisterTasksRouter,
+// RegisterAPIKeysRouter, RegisterWorkersRouter, RegisterJobsRouter and RegisterBansRouter functions to register the routers with the provided Gorilla Mux router. The health and
+// metrics routers should be registered on the root router, the other routers on the /api subrouter. The tasks, API keys, workers, jobs and bans routers should be registered
+// on a router protected by middlewares.AuthMiddleware and middlewares.AuthorizationMiddleware.
+// Route names are used by the policy module to look up the permission each route requires,
+// and by the timeout and rate limit middlewares to look up the settings of each route.
 //
 // Example:
-// RegisterTasksRouter(router)
+// RegisterHealthRouter(rootRouter)
+// RegisterMetricsRouter(rootRouter)
+// RegisterAuthRouter(router)
+// RegisterCSRFRouter(router)
+// RegisterTasksRouter(protectedRouter)
+// RegisterAPIKeysRouter(protectedRouter)
+// RegisterWorkersRouter(protectedRouter)
+// RegisterJobsRouter(protectedRouter)
+// RegisterBansRouter(protectedRouter)
 package routers
 
 import (
 	"net/http"
-	"sync"
 
 	"github.com/emso-c/konzek-go-assignment/src/api/controllers"
+	"github.com/emso-c/konzek-go-assignment/src/api/middlewares"
+	"github.com/emso-c/konzek-go-assignment/src/api/responses"
 	"github.com/emso-c/konzek-go-assignment/src/database"
 	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/metrics"
 	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
 	"github.com/gorilla/mux"
 )
 
 // RegisterTasksRouter registers the routes related to tasks management.
 func RegisterTasksRouter(router *mux.Router) {
 	logger := logger.GetLogger()
-	tc := controllers.NewTaskController()
-	db := database.GetDatabase()
+	tc := controllers.NewTaskController(repositories.NewPostgresTaskRepository(database.GetDatabase()))
 
 	taskRouter := router.PathPrefix("/").Subrouter()
-	taskRouter.HandleFunc("/tasks", enqueueJob(tc.GetTasks(db))).Methods("GET")
-	taskRouter.HandleFunc("/task/{id}", enqueueJob(tc.GetTask(db))).Methods("GET")
-	taskRouter.HandleFunc("/task", enqueueJob(tc.CreateTask(db))).Methods("POST")
-	taskRouter.HandleFunc("/task/{id}", enqueueJob(tc.UpdateTask(db))).Methods("PUT")
-	taskRouter.HandleFunc("/task/{id}", enqueueJob(tc.DeleteTask(db))).Methods("DELETE")
+	taskRouter.HandleFunc("/tasks", enqueueJob(tc.GetTasks())).Methods("GET").Name("tasks.list")
+	taskRouter.HandleFunc("/task/{id}", enqueueJob(tc.GetTask())).Methods("GET").Name("tasks.get")
+	taskRouter.HandleFunc("/task", enqueueJob(tc.CreateTask())).Methods("POST").Name("tasks.create")
+	taskRouter.HandleFunc("/task/{id}", enqueueJob(tc.UpdateTask())).Methods("PUT").Name("tasks.update")
+	taskRouter.HandleFunc("/task/{id}/transition", enqueueJob(tc.TransitionTask())).Methods("POST").Name("tasks.transition")
+	taskRouter.HandleFunc("/task/{id}", enqueueJob(tc.DeleteTask())).Methods("DELETE").Name("tasks.delete")
 
 	logger.Info("Tasks router registered")
 }
 
+// queueFullRetryAfter is the Retry-After header, in seconds, of the responses to requests rejected because the worker queue is full.
+const queueFullRetryAfter = "1"
+
 // enqueueJob is a middleware function that enqueues the incoming HTTP handler function as a job to be processed by a worker.
 // This allows handling requests concurrently while maintaining order.
+// Requests are answered with 503 Service Unavailable and a Retry-After header when the worker queue is full.
+// The job runs with the request context, and is dropped without running if the context is done by the time
+// a worker picks it up, e.g. because the client disconnected or the request timed out.
+// A panic of the handler is recovered in the job and answered with 500 Internal Server Error.
 func enqueueJob(handlerFunc http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var wg sync.WaitGroup
-		wg.Add(1)
+	return enqueueJobWith(worker_manager.GetWorkerManager(), handlerFunc)
+}
 
-		worker_manager.GetWorkerManager().AddJob(func() {
-			defer wg.Done()
+// enqueueJobWith is enqueueJob with the given worker manager.
+func enqueueJobWith(workers *worker_manager.WorkerManager, handlerFunc http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		done := make(chan struct{})
+		err := workers.TryAddJob(func() {
+			defer close(done)
+			defer middlewares.Recover(w, r)
+			if err := r.Context().Err(); err != nil {
+				metrics.WorkerJobsDropped.Inc()
+				logger.FromContext(r.Context()).Warn("Dropping job of a finished request", "error", err)
+				return
+			}
 			handlerFunc(w, r)
 		})
+		if err != nil {
+			w.Header().Set("Retry-After", queueFullRetryAfter)
+			responses.Error(w, http.StatusServiceUnavailable, "Server is busy, try again later")
+			logger.FromContext(r.Context()).Warn("Rejecting request", "error", err)
+			return
+		}
 
-		wg.Wait() // Wait until all jobs are done
+		<-done // Wait until the job is done
 	}
 }
@@ -1,12 +1,14 @@
 package routers
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/emso-c/konzek-go-assignment/config"
+	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 )
@@ -14,6 +16,7 @@ import (
 func TestEnqueueJob(t *testing.T) {
 	os.Setenv("LOGGER_DISABLED", "true")
 	os.Setenv("HTTP_WORKER_POOL_SIZE", "2")
+	os.Setenv("HTTP_WORKER_QUEUE_SIZE", "2")
 	// Create a mock HTTP handler function
 	mockHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -41,6 +44,68 @@ func TestEnqueueJob(t *testing.T) {
 	}
 }
 
+func TestEnqueueJobQueueFull(t *testing.T) {
+	os.Setenv("LOGGER_DISABLED", "true")
+	// A single worker without queue, kept busy by a running job
+	workers := worker_manager.NewWorkerManager(1, 0)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go workers.AddJob(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	defer close(release)
+
+	handler := enqueueJobWith(workers, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	rr := httptest.NewRecorder()
+	handler(rr, httptest.NewRequest("GET", "/", nil))
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusServiceUnavailable)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+}
+
+func TestEnqueueJobDropsFinishedRequests(t *testing.T) {
+	os.Setenv("LOGGER_DISABLED", "true")
+	workers := worker_manager.NewWorkerManager(1, 1)
+	called := false
+	handler := enqueueJobWith(workers, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	// The client is gone before a worker picks up the job
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+
+	if called {
+		t.Error("Expected the job of a cancelled request to be dropped")
+	}
+}
+
+func TestEnqueueJobRecoversPanics(t *testing.T) {
+	os.Setenv("LOGGER_DISABLED", "true")
+	workers := worker_manager.NewWorkerManager(1, 1)
+	handler := enqueueJobWith(workers, func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	// The request is answered and the worker keeps serving
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest("GET", "/", nil))
+		if rr.Code != http.StatusInternalServerError {
+			t.Errorf("handler returned wrong status code: got %v want %v", rr.Code, http.StatusInternalServerError)
+		}
+	}
+}
+
 func TestRegisterTasksRouter(t *testing.T) {
 	err := godotenv.Load("../../../.env")
 	if err != nil {
@@ -0,0 +1,21 @@
+package routers
+
+import (
+	"github.com/emso-c/konzek-go-assignment/src/api/controllers"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
+	"github.com/gorilla/mux"
+)
+
+// RegisterWorkersRouter registers the routes administrating the worker pool.
+// The routes are not enqueued, so that the pool can be resized while it is saturated.
+func RegisterWorkersRouter(router *mux.Router) {
+	logger := logger.GetLogger()
+	wc := controllers.NewWorkerController(worker_manager.GetWorkerManager())
+
+	workerRouter := router.PathPrefix("/admin/workers").Subrouter()
+	workerRouter.HandleFunc("", wc.GetWorkerPool()).Methods("GET").Name("workers.get")
+	workerRouter.HandleFunc("", wc.ResizeWorkerPool()).Methods("PUT").Name("workers.resize")
+
+	logger.Info("Workers router registered")
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
+)
+
+// Server is the HTTP server of the API. It is configured from the SERVER_* environment variables:
+// SERVER_READ_TIMEOUT, SERVER_READ_HEADER_TIMEOUT, SERVER_WRITE_TIMEOUT, SERVER_IDLE_TIMEOUT
+// and SERVER_SHUTDOWN_TIMEOUT in seconds, and SERVER_MAX_HEADER_BYTES.
+type Server struct {
+	HTTPServer      *http.Server
+	ShutdownTimeout time.Duration
+	tasks           []func(ctx context.Context)
+}
+
+// envSeconds reads a duration in seconds from the given environment variable.
+func envSeconds(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("%s must be a positive number of seconds", name)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// NewServer creates a new Server listening on the given address and serving the given handler.
+// Unset settings fall back to defaults, invalid settings return an error.
+func NewServer(addr string, handler http.Handler) (*Server, error) {
+	readTimeout, err := envSeconds("SERVER_READ_TIMEOUT", 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	readHeaderTimeout, err := envSeconds("SERVER_READ_HEADER_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	writeTimeout, err := envSeconds("SERVER_WRITE_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	idleTimeout, err := envSeconds("SERVER_IDLE_TIMEOUT", 60*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	shutdownTimeout, err := envSeconds("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	maxHeaderBytes := http.DefaultMaxHeaderBytes
+	if value := os.Getenv("SERVER_MAX_HEADER_BYTES"); value != "" {
+		maxHeaderBytes, err = strconv.Atoi(value)
+		if err != nil || maxHeaderBytes <= 0 {
+			return nil, fmt.Errorf("SERVER_MAX_HEADER_BYTES must be a positive number of bytes")
+		}
+	}
+
+	return &Server{
+		HTTPServer: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadTimeout:       readTimeout,
+			ReadHeaderTimeout: readHeaderTimeout,
+			WriteTimeout:      writeTimeout,
+			IdleTimeout:       idleTimeout,
+			MaxHeaderBytes:    maxHeaderBytes,
+		},
+		ShutdownTimeout: shutdownTimeout,
+	}, nil
+}
+
+// Go registers a background task, e.g. the job runner, that Serve runs until the server shuts down.
+// The context of the task is done once shutdown starts, and Serve waits for the task to return before
+// draining the worker jobs, so that the task adds no job while they are drained. It must be called before Serve.
+func (s *Server) Go(task func(ctx context.Context)) {
+	s.tasks = append(s.tasks, task)
+}
+
+// wait waits for the wait group, or returns the context error if it is done first.
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Serve accepts connections on the listener and runs the background tasks until the context is done,
+// then shuts down gracefully: it stops accepting connections and the background tasks, and waits for
+// in-flight requests, background tasks and queued worker jobs to finish, for at most ShutdownTimeout.
+// It returns an error if the server fails or the shutdown deadline is exceeded.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	logger := logger.GetLogger()
+
+	tasksCtx, stopTasks := context.WithCancel(ctx)
+	defer stopTasks()
+	var tasks sync.WaitGroup
+	for _, task := range s.tasks {
+		tasks.Add(1)
+		go func(task func(ctx context.Context)) {
+			defer tasks.Done()
+			task(tasksCtx)
+		}(task)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.HTTPServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		stopTasks()
+		stopCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+		defer cancel()
+		if waitErr := wait(stopCtx, &tasks); waitErr != nil {
+			logger.Error("Error stopping background tasks", "error", waitErr)
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+
+	// Stop accepting connections and wait for in-flight requests
+	if err := s.HTTPServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("error draining requests: %w", err)
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	// Wait for the background tasks, which stopped with the context, so that no job is added while draining
+	if err := wait(shutdownCtx, &tasks); err != nil {
+		return fmt.Errorf("error stopping background tasks: %w", err)
+	}
+
+	// Wait for the jobs that are still queued or running
+	if err := worker_manager.GetWorkerManager().Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("error draining worker jobs: %w", err)
+	}
+
+	logger.Info("Server shut down gracefully")
+	return nil
+}
+
+// ListenAndServe listens on the server address and serves until the context is done, see Serve.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.HTTPServer.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewServer(t *testing.T) {
+	os.Setenv("SERVER_READ_TIMEOUT", "7")
+	os.Setenv("SERVER_MAX_HEADER_BYTES", "4096")
+	defer os.Unsetenv("SERVER_READ_TIMEOUT")
+	defer os.Unsetenv("SERVER_MAX_HEADER_BYTES")
+
+	server, err := NewServer(":0", http.NotFoundHandler())
+	assert.NoError(t, err)
+	assert.Equal(t, 7*time.Second, server.HTTPServer.ReadTimeout)
+	assert.Equal(t, 5*time.Second, server.HTTPServer.ReadHeaderTimeout)
+	assert.Equal(t, 4096, server.HTTPServer.MaxHeaderBytes)
+
+	os.Setenv("SERVER_READ_TIMEOUT", "soon")
+	_, err = NewServer(":0", http.NotFoundHandler())
+	assert.Error(t, err)
+}
+
+func TestServerGracefulShutdown(t *testing.T) {
+	os.Setenv("LOGGER_DISABLED", "true")
+	os.Setenv("HTTP_WORKER_POOL_SIZE", "1")
+
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server, err := NewServer(":0", handler)
+	assert.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ctx, listener) }()
+
+	// The in-flight request completes although shutdown starts while it is handled
+	response := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			response <- 0
+			return
+		}
+		resp.Body.Close()
+		response <- resp.StatusCode
+	}()
+	<-started
+	cancel()
+
+	assert.Equal(t, http.StatusNoContent, <-response)
+	assert.NoError(t, <-served)
+
+	// No new connections are accepted
+	_, err = http.Get("http://" + listener.Addr().String())
+	assert.Error(t, err)
+}
+
+func TestServerStopsBackgroundTasksBeforeDraining(t *testing.T) {
+	os.Setenv("LOGGER_DISABLED", "true")
+	os.Setenv("HTTP_WORKER_POOL_SIZE", "1")
+
+	server, err := NewServer(":0", http.NotFoundHandler())
+	assert.NoError(t, err)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A job added by a background task while it stops is drained with the worker jobs
+	var ran atomic.Bool
+	server.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		worker_manager.GetWorkerManager().AddJob(func() {
+			time.Sleep(50 * time.Millisecond)
+			ran.Store(true)
+		})
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ctx, listener) }()
+	cancel()
+
+	assert.NoError(t, <-served)
+	assert.True(t, ran.Load())
+}
@@ -1,5 +1,8 @@
 // Package database provides functionality for establishing and managing connections
 // to a PostgreSQL database, as well as handling tasks related to database operations.
+//
+// The schema is managed by the versioned migrations in the migrations directory,
+// see Migrate and Migrator. Connect does not change the schema.
 package database
 
 import (
@@ -8,6 +11,7 @@ import (
 	"os"
 
 	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/metrics"
 	_ "github.com/lib/pq"
 )
 
@@ -51,22 +55,6 @@ func (d *DatabaseConnection) Connect() *sql.DB {
 		logger.Fatal(errStr)
 	}
 
-	// Create tasks table if it does not exist
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS tasks (
-			id SERIAL PRIMARY KEY,
-			title TEXT NOT NULL,
-			description TEXT NOT NULL,
-			status TEXT NOT NULL,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-		);
-	`)
-	if err != nil {
-		errStr := fmt.Sprintf("Error creating tasks table: %s", err)
-		logger.Fatal(errStr)
-	}
-
 	logger.Info("Successfully connected to database")
 	return db
 }
@@ -79,10 +67,28 @@ func GetDatabase() *sql.DB {
 	if dbInstance == nil {
 		dbConn := _NewDatabaseConnection()
 		dbInstance = dbConn.Connect()
+		registerMetrics(dbInstance)
 	}
 	return dbInstance
 }
 
+// registerMetrics registers the connection pool statistics of the database as metrics.
+func registerMetrics(db *sql.DB) {
+	registry := metrics.GetRegistry()
+	registry.NewGaugeFunc("db_max_open_connections", "Maximum number of open connections to the database.",
+		func() float64 { return float64(db.Stats().MaxOpenConnections) })
+	registry.NewGaugeFunc("db_open_connections", "Number of established connections to the database.",
+		func() float64 { return float64(db.Stats().OpenConnections) })
+	registry.NewGaugeFunc("db_in_use_connections", "Number of connections currently in use.",
+		func() float64 { return float64(db.Stats().InUse) })
+	registry.NewGaugeFunc("db_idle_connections", "Number of idle connections.",
+		func() float64 { return float64(db.Stats().Idle) })
+	registry.NewCounterFunc("db_wait_count_total", "Total number of connections waited for.",
+		func() float64 { return float64(db.Stats().WaitCount) })
+	registry.NewCounterFunc("db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.",
+		func() float64 { return db.Stats().WaitDuration.Seconds() })
+}
+
 // Close closes the database connection.
 func Close() {
 	if dbInstance != nil {
@@ -26,14 +26,12 @@ func TestConnect(t *testing.T) {
 }
 
 func TestGetDatabase(t *testing.T) {
-	db, mock, err := sqlmock.New()
+	db, _, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 	defer db.Close()
 
-	mock.ExpectExec("CREATE TABLE IF NOT EXISTS tasks").WillReturnResult(sqlmock.NewResult(1, 1))
-
 	sqlDB := db
 	dbInstance = sqlDB
 
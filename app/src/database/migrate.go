@@ -0,0 +1,315 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+)
+
+// migrationFiles holds the schema migrations. Each migration is a pair of
+// `<version>_<name>.up.sql` and `<version>_<name>.down.sql` files, applied in version order.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the key of the PostgreSQL advisory lock held while migrating,
+// so that several instances of the application starting at once do not race.
+const migrationLockKey int64 = 7_262_871_034
+
+// Migration is a versioned schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// String returns the file name of the migration without its direction and extension.
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// MigrationStatus reports whether a migration has been applied, and when.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrations returns the embedded schema migrations in version order.
+func Migrations() ([]Migration, error) {
+	dir, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return LoadMigrations(dir)
+}
+
+// LoadMigrations reads the migrations in the root of the given file system and returns them in version order.
+// Every migration must have both an up and a down file, and versions must be unique.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		base := strings.TrimSuffix(entry.Name(), ".sql")
+		direction := ""
+		switch {
+		case strings.HasSuffix(base, ".up"):
+			direction, base = "up", strings.TrimSuffix(base, ".up")
+		case strings.HasSuffix(base, ".down"):
+			direction, base = "down", strings.TrimSuffix(base, ".down")
+		default:
+			return nil, fmt.Errorf("migration %s must end with .up.sql or .down.sql", entry.Name())
+		}
+		prefix, name, found := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !found || err != nil || version <= 0 || name == "" {
+			return nil, fmt.Errorf("migration %s must be named <version>_<name>", entry.Name())
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", migration, entry.Name(), version)
+		}
+		if direction == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %s must have both an up and a down file", migration)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies and rolls back schema migrations, recording the applied versions
+// in the schema_migrations table.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a new Migrator for the given database connection and migrations.
+func NewMigrator(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Migrate applies the pending embedded migrations to the database.
+func Migrate(db *sql.DB) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	_, err = NewMigrator(db, migrations).Up()
+	return err
+}
+
+// lock acquires the migration advisory lock on a dedicated connection and makes sure
+// the schema_migrations table exists. The connection must be released with unlock.
+func (m *Migrator) lock(ctx context.Context) (*sql.Conn, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		m.unlock(ctx, conn)
+		return nil, err
+	}
+	return conn, nil
+}
+
+// unlock releases the migration advisory lock and the connection holding it.
+func (m *Migrator) unlock(ctx context.Context, conn *sql.Conn) {
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+		logger.GetLogger().Error("Error releasing migration lock", "error", err)
+	}
+	conn.Close()
+}
+
+// applied returns the applied migration versions and when they were applied.
+func applied(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+	return versions, rows.Err()
+}
+
+// run executes a migration script and records it with the given statement in a single transaction,
+// so that a failed migration leaves neither schema changes nor a record behind.
+func run(ctx context.Context, conn *sql.Conn, script string, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Up applies every pending migration in version order and returns the number of applied migrations.
+func (m *Migrator) Up() (int, error) {
+	ctx := context.Background()
+	conn, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer m.unlock(ctx, conn)
+
+	versions, err := applied(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, migration := range m.migrations {
+		if _, ok := versions[migration.Version]; ok {
+			continue
+		}
+		err := run(ctx, conn, migration.Up,
+			"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+		if err != nil {
+			return count, fmt.Errorf("error applying migration %s: %w", migration, err)
+		}
+		logger.GetLogger().Info("Applied migration", "migration", migration)
+		count++
+	}
+	return count, nil
+}
+
+// Down rolls back the given number of most recently applied migrations and returns the number of rolled back migrations.
+func (m *Migrator) Down(steps int) (int, error) {
+	ctx := context.Background()
+	conn, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer m.unlock(ctx, conn)
+
+	versions, err := applied(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := versions[migration.Version]; !ok {
+			continue
+		}
+		err := run(ctx, conn, migration.Down, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+		if err != nil {
+			return count, fmt.Errorf("error rolling back migration %s: %w", migration, err)
+		}
+		logger.GetLogger().Info("Rolled back migration", "migration", migration)
+		count++
+	}
+	return count, nil
+}
+
+// Status returns the status of every migration in version order.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	ctx := context.Background()
+	conn, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer m.unlock(ctx, conn)
+
+	versions, err := applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		appliedAt, ok := versions[migration.Version]
+		statuses[i] = MigrationStatus{Migration: migration, Applied: ok, AppliedAt: appliedAt}
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet, in version order.
+// Unlike the other methods it does not wait for the migration lock, so that it can be used by health checks.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	rows, err := m.db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versions[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if !versions[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, "migration versions must be contiguous")
+	}
+	assert.Equal(t, "0001_create_tasks", migrations[0].String())
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("up 2")},
+		"0002_second.down.sql": {Data: []byte("down 2")},
+		"0001_first.up.sql":    {Data: []byte("up 1")},
+		"0001_first.down.sql":  {Data: []byte("down 1")},
+		"README.md":            {Data: []byte("ignored")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "first", Up: "up 1", Down: "down 1"},
+		{Version: 2, Name: "second", Up: "up 2", Down: "down 2"},
+	}, migrations)
+
+	invalid := []fstest.MapFS{
+		{"0001_first.up.sql": {Data: []byte("up")}},
+		{"0001_first.sql": {Data: []byte("up")}},
+		{"first.up.sql": {Data: []byte("up")}, "first.down.sql": {Data: []byte("down")}},
+		{
+			"0001_first.up.sql": {Data: []byte("up")}, "0001_first.down.sql": {Data: []byte("down")},
+			"0001_other.up.sql": {Data: []byte("up")}, "0001_other.down.sql": {Data: []byte("down")},
+		},
+	}
+	for _, fsys := range invalid {
+		_, err := LoadMigrations(fsys)
+		assert.Error(t, err)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	os.Setenv("LOGGER_DISABLED", "true")
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	migrator := NewMigrator(db, []Migration{
+		{Version: 1, Name: "first", Up: "CREATE TABLE first", Down: "DROP TABLE first"},
+		{Version: 2, Name: "second", Up: "CREATE TABLE second", Down: "DROP TABLE second"},
+	})
+	expectLock := func(versions ...int) {
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).WithArgs(migrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+		rows := sqlmock.NewRows([]string{"version", "applied_at"})
+		for _, version := range versions {
+			rows.AddRow(version, time.Now())
+		}
+		mock.ExpectQuery("SELECT version, applied_at FROM schema_migrations").WillReturnRows(rows)
+	}
+	expectUnlock := func() {
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).WithArgs(migrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	// Up only applies pending migrations
+	expectLock(1)
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE second").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")).
+		WithArgs(2, "second").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock()
+	count, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// A failed migration is rolled back and stops the run
+	expectLock()
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE first").WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+	expectUnlock()
+	count, err = migrator.Up()
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Equal(t, 0, count)
+
+	// Down rolls back the most recent migrations first
+	expectLock(1, 2)
+	mock.ExpectBegin()
+	mock.ExpectExec("DROP TABLE second").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = $1")).
+		WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectUnlock()
+	count, err = migrator.Down(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// Status
+	expectLock(1)
+	expectUnlock()
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+
+	// Pending does not take the lock
+	mock.ExpectQuery("SELECT version FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	pending, err := migrator.Pending(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []Migration{migrator.migrations[1]}, pending)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,5 @@
+ALTER TABLE tasks
+    ALTER COLUMN title DROP NOT NULL,
+    ALTER COLUMN description DROP NOT NULL,
+    ALTER COLUMN status DROP NOT NULL,
+    ALTER COLUMN status DROP DEFAULT;
@@ -0,0 +1,10 @@
+-- Databases created by an older db/init.sql have nullable task columns
+UPDATE tasks SET title = '' WHERE title IS NULL;
+UPDATE tasks SET description = '' WHERE description IS NULL;
+UPDATE tasks SET status = 'pending' WHERE status IS NULL;
+
+ALTER TABLE tasks
+    ALTER COLUMN title SET NOT NULL,
+    ALTER COLUMN description SET NOT NULL,
+    ALTER COLUMN status SET NOT NULL,
+    ALTER COLUMN status SET DEFAULT 'pending';
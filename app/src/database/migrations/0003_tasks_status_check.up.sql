@@ -0,0 +1,5 @@
+-- Restrict statuses to the task lifecycle, see models.TaskStatuses.
+-- The constraint is NOT VALID so that rows created before it are not rejected.
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_status_check;
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_check
+CHECK (status IN ('pending', 'in_progress', 'completed', 'cancelled')) NOT VALID;
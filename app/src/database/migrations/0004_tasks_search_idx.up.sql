@@ -0,0 +1,4 @@
+-- Full-text search index used by the task listing's `q` parameter,
+-- it must match repositories.taskSearchVector
+CREATE INDEX IF NOT EXISTS tasks_search_idx ON tasks
+USING GIN (to_tsvector('english', title || ' ' || description));
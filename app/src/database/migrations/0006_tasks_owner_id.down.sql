@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS tasks_owner_id_idx;
+ALTER TABLE tasks DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,3 @@
+-- Tasks created before ownership existed have no owner and are only visible to admins
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS owner_id INTEGER REFERENCES users (id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS tasks_owner_id_idx ON tasks (owner_id);
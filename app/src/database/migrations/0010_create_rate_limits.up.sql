@@ -0,0 +1,9 @@
+-- Rate limit counters are short lived and can be lost on a crash, they are not worth the write-ahead log
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits (
+    key TEXT NOT NULL,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (key, window_start)
+);
+CREATE INDEX IF NOT EXISTS rate_limits_expires_at_idx ON rate_limits (expires_at);
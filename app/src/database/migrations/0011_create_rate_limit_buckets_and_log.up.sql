@@ -0,0 +1,15 @@
+-- State of the token bucket and sliding window log rate limiting algorithms, see 0010_create_rate_limits
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_expires_at_idx ON rate_limit_buckets (expires_at);
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_log (
+    key TEXT NOT NULL,
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS rate_limit_log_key_idx ON rate_limit_log (key, requested_at);
+CREATE INDEX IF NOT EXISTS rate_limit_log_expires_at_idx ON rate_limit_log (expires_at);
@@ -0,0 +1,9 @@
+-- Temporary bans of client IP addresses, kept with the rate limit state, see 0010_create_rate_limits.
+-- The table is logged, unlike the rate limit state, so that bans survive a crash of the database.
+CREATE TABLE IF NOT EXISTS rate_limit_bans (
+    address TEXT PRIMARY KEY,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+CREATE INDEX IF NOT EXISTS rate_limit_bans_expires_at_idx ON rate_limit_bans (expires_at);
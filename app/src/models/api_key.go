@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+type APIKey struct {
+	Id        uint // auto-increment by default
+	UserId    uint
+	Name      string
+	Prefix    string     // public part of the key, used to look it up
+	KeyHash   string     `json:"-"` // see auth.HashAPIKey, never exposed
+	Scopes    []string   // see policy.APIKeyScopes
+	ExpiresAt *time.Time // nil if the key does not expire
+	RevokedAt *time.Time // nil until the key is revoked
+	CreatedAt time.Time  // server default is: TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `validate:"required,max=64,format=line"`
+	Scopes    []string   `validate:"required"` // see policy.APIKeyScopes, checked against the role of the owner
+	ExpiresAt *time.Time `validate:"future"`
+}
+
+// CreateAPIKeyResponse is returned once when an API key is created, it is the only time the key is shown.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string
+}
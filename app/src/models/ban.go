@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Ban is a temporary ban of a client IP address, whose requests are refused until it expires.
+type Ban struct {
+	Address   string
+	Reason    string // why the client was banned, e.g. rate_limit, sql_injection or the reason given by an admin
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type CreateBanRequest struct {
+	Address  string `validate:"required,format=ip"`
+	Reason   string `validate:"max=255,format=line"`
+	Duration int    `validate:"min=0,max=31536000"` // seconds, defaults to the automatic ban duration
+}
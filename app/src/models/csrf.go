@@ -0,0 +1,6 @@
+package models
+
+// CSRFToken is the CSRF token to submit in the X-CSRF-Token header of unsafe requests.
+type CSRFToken struct {
+	Token string
+}
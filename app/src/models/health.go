@@ -0,0 +1,22 @@
+package models
+
+import (
+	"database/sql"
+)
+
+// HealthCheck is the result of a liveness or readiness probe.
+// Checks maps the name of each check to "ok" or to the reason it failed.
+type HealthCheck struct {
+	Status string
+	Checks map[string]string
+}
+
+// SystemStatus is a detailed report of the state of the application.
+type SystemStatus struct {
+	Status            string
+	Uptime            string
+	Database          sql.DBStats
+	Workers           map[string]bool // worker ID to availability, see worker_manager.WorkerManager.GetWorkerStatus
+	PendingMigrations []string
+	LimiterSize       int // number of clients tracked by the rate limiter
+}
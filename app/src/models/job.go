@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job statuses. A job is pending until a worker claims it and running while its handler runs.
+// A failed job goes back to pending with a later RunAt until it runs out of attempts,
+// then it is moved to the dead state where it stays until it is retried manually.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobDead      = "dead"
+)
+
+// JobStatuses lists every valid job status.
+var JobStatuses = []string{JobPending, JobRunning, JobCompleted, JobDead}
+
+// IsValidJobStatus reports whether the given status is one of JobStatuses.
+func IsValidJobStatus(status string) bool {
+	for _, s := range JobStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+type Job struct {
+	Id          uint // auto-increment by default
+	Type        string
+	Payload     json.RawMessage // JSON encoded payload, decoded by the handler of the job type
+	Status      string          // see JobStatuses
+	Attempts    int             // number of times the job was claimed
+	MaxAttempts int             // the job is dead once it failed this many times
+	LastError   string
+	RunAt       time.Time  // the job is not claimed before this time
+	LockedAt    *time.Time // time the job was claimed, nil unless it is running
+	CreatedAt   time.Time  // server default is: TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+	UpdatedAt   time.Time  // server default is: TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+}
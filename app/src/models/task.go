@@ -5,16 +5,21 @@ import (
 )
 
 type Task struct {
-	Id          uint // auto-increment by default
-	Title       string
-	Description string
-	Status      string
+	Id          uint      // auto-increment by default
+	Title       string    `validate:"required,max=255,format=line"`
+	Description string    `validate:"max=10000,format=text"`
+	Status      string    `validate:"oneof=pending in_progress completed cancelled"` // one of TaskStatuses, see TaskStatusTransitions
 	CreatedAt   time.Time // server default is: TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 	UpdatedAt   time.Time // server default is: TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+	OwnerId     uint      // ID of the user who created the task, 0 for tasks created before ownership existed
 }
 
 type CreateTaskRequest struct {
-	Title       string
-	Description string
-	Status      string
+	Title       string `validate:"required,max=255,format=line"`
+	Description string `validate:"max=10000,format=text"`
+	Status      string `validate:"oneof=pending in_progress completed cancelled"` // defaults to StatusPending, new tasks must start as pending
+}
+
+type TransitionTaskRequest struct {
+	Status string `validate:"required,oneof=pending in_progress completed cancelled"`
 }
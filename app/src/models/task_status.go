@@ -0,0 +1,67 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Task statuses. A task starts as pending, moves to in_progress and ends up
+// completed or cancelled. Completed and cancelled tasks can be reopened.
+const (
+	StatusPending    = "pending"
+	StatusInProgress = "in_progress"
+	StatusCompleted  = "completed"
+	StatusCancelled  = "cancelled"
+)
+
+// TaskStatuses lists every valid task status.
+var TaskStatuses = []string{StatusPending, StatusInProgress, StatusCompleted, StatusCancelled}
+
+// TaskStatusTransitions maps each status to the statuses it can transition to.
+var TaskStatusTransitions = map[string][]string{
+	StatusPending:    {StatusInProgress, StatusCancelled},
+	StatusInProgress: {StatusPending, StatusCompleted, StatusCancelled},
+	StatusCompleted:  {StatusInProgress},
+	StatusCancelled:  {StatusPending},
+}
+
+// IsValidTaskStatus reports whether the given status is one of TaskStatuses.
+func IsValidTaskStatus(status string) bool {
+	_, ok := TaskStatusTransitions[status]
+	return ok
+}
+
+// TransitionError describes a status change that is not allowed by TaskStatusTransitions.
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	if !IsValidTaskStatus(e.To) {
+		return fmt.Sprintf("invalid status %q, must be one of: %s", e.To, strings.Join(TaskStatuses, ", "))
+	}
+	allowed := TaskStatusTransitions[e.From]
+	return fmt.Sprintf(
+		"illegal status transition from %q to %q, allowed transitions: %s",
+		e.From, e.To, strings.Join(allowed, ", "),
+	)
+}
+
+// ValidateTransition returns a *TransitionError if a task cannot move from one status to another.
+// Keeping the same status is always allowed for valid statuses, and tasks with a legacy status
+// that predates the lifecycle can move to any valid status.
+func ValidateTransition(from string, to string) error {
+	if !IsValidTaskStatus(to) {
+		return &TransitionError{From: from, To: to}
+	}
+	if from == to || !IsValidTaskStatus(from) {
+		return nil
+	}
+	for _, status := range TaskStatusTransitions[from] {
+		if status == to {
+			return nil
+		}
+	}
+	return &TransitionError{From: from, To: to}
+}
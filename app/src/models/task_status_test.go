@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTransition(t *testing.T) {
+	allowed := [][2]string{
+		{StatusPending, StatusInProgress},
+		{StatusPending, StatusCancelled},
+		{StatusInProgress, StatusCompleted},
+		{StatusInProgress, StatusPending},
+		{StatusCompleted, StatusInProgress},
+		{StatusCancelled, StatusPending},
+		{StatusCompleted, StatusCompleted},
+		{"Legacy", StatusPending},
+	}
+	for _, tr := range allowed {
+		assert.NoError(t, ValidateTransition(tr[0], tr[1]), "%s -> %s", tr[0], tr[1])
+	}
+
+	denied := [][2]string{
+		{StatusPending, StatusCompleted},
+		{StatusCompleted, StatusCancelled},
+		{StatusCancelled, StatusInProgress},
+		{StatusPending, ""},
+		{StatusPending, "Pending"},
+	}
+	for _, tr := range denied {
+		err := ValidateTransition(tr[0], tr[1])
+		var transitionErr *TransitionError
+		assert.True(t, errors.As(err, &transitionErr), "%s -> %s", tr[0], tr[1])
+	}
+
+	assert.EqualError(t, ValidateTransition(StatusPending, StatusCompleted),
+		`illegal status transition from "pending" to "completed", allowed transitions: in_progress, cancelled`)
+}
+
+func TestTaskStatusTags(t *testing.T) {
+	// The status rules of the request models must list every status
+	oneof := "oneof=" + strings.Join(TaskStatuses, " ")
+	for _, model := range []interface{}{Task{}, CreateTaskRequest{}, TransitionTaskRequest{}} {
+		field, _ := reflect.TypeOf(model).FieldByName("Status")
+		assert.Contains(t, strings.Split(field.Tag.Get("validate"), ","), oneof, "%T", model)
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+type User struct {
+	Id           uint // auto-increment by default
+	Username     string
+	PasswordHash string    `json:"-"` // see auth.HashPassword, never exposed
+	Role         string    // see policy.RoleUser and policy.RoleAdmin, defaults to user
+	CreatedAt    time.Time // server default is: TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+}
+
+type RegisterRequest struct {
+	Username string `validate:"required,min=3,max=32,format=identifier"`
+	Password string `validate:"required,min=8,max=1024"`
+}
+
+type LoginRequest struct {
+	Username string `validate:"required"`
+	Password string `validate:"required"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenResponse follows the OAuth 2.0 access token response format (RFC 6749 section 5.1).
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// WorkerPool reports the size, bounds and queue of the worker pool.
+type WorkerPool struct {
+	Size          int
+	MinWorkers    int
+	MaxWorkers    int
+	QueueLength   int
+	QueueCapacity int
+	Workers       map[string]bool // worker ID to availability, see worker_manager.WorkerManager.GetWorkerStatus
+}
+
+// ResizeWorkerPoolRequest sets the bounds of the worker pool, which is autoscaled within them.
+// Setting both to the same value fixes the pool size.
+type ResizeWorkerPoolRequest struct {
+	Min int `validate:"required,min=1"`
+	Max int `validate:"required,min=1"`
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// API keys have the form `kz_<prefix>_<secret>`. The prefix is stored in clear to look the key up,
+// while only the SHA-256 hash of the whole key is stored. Since the secret is random and long,
+// a fast hash is sufficient.
+const (
+	apiKeyScheme       = "kz"
+	apiKeyPrefixLength = 6  // random bytes, 12 hex characters
+	apiKeySecretLength = 32 // random bytes
+)
+
+// NewAPIKey generates a new API key. It returns the key to show to its owner once,
+// the prefix to look it up by, and the hash to store.
+func NewAPIKey() (key string, prefix string, hash string, err error) {
+	prefixBytes := make([]byte, apiKeyPrefixLength)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", err
+	}
+	secret := make([]byte, apiKeySecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(prefixBytes)
+	key = apiKeyScheme + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// ParseAPIKey returns the prefix of an API key, and false if the key is malformed.
+func ParseAPIKey(key string) (string, bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyScheme || len(parts[1]) != 2*apiKeyPrefixLength || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// HashAPIKey returns the value under which an API key is stored.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckAPIKey reports whether the key matches a hash created by HashAPIKey, in constant time.
+func CheckAPIKey(key string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(hash)) == 1
+}
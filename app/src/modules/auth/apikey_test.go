@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey(t *testing.T) {
+	key, prefix, hash, err := NewAPIKey()
+	assert.NoError(t, err)
+	assert.Len(t, prefix, 12)
+
+	parsed, ok := ParseAPIKey(key)
+	assert.True(t, ok)
+	assert.Equal(t, prefix, parsed)
+	assert.True(t, CheckAPIKey(key, hash))
+	assert.False(t, CheckAPIKey(key+"x", hash))
+
+	other, _, _, _ := NewAPIKey()
+	assert.NotEqual(t, key, other)
+
+	for _, malformed := range []string{"", "kz_", "kz_abc_secret", "xx_" + prefix + "_secret", "kz_" + prefix + "_"} {
+		_, ok := ParseAPIKey(malformed)
+		assert.False(t, ok, malformed)
+	}
+}
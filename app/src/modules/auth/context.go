@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+)
+
+// Identity represents the authenticated caller of a request.
+type Identity struct {
+	UserID   uint
+	Username string
+	Role     string
+	// APIKeyID is the ID of the API key used to authenticate, 0 for access tokens.
+	APIKeyID uint
+	// Scopes restricts the permissions granted by the role when authenticated with an API key.
+	Scopes []string
+}
+
+// identityKey is the context key of the authenticated Identity.
+type identityKey struct{}
+
+// NewContext returns a copy of the context carrying the given identity.
+func NewContext(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext returns the identity carried by the context, or nil for anonymous requests.
+func FromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
+
+// IdentityFromClaims builds the identity described by verified access token claims.
+func IdentityFromClaims(claims *Claims) (*Identity, error) {
+	id, err := strconv.ParseUint(claims.Subject, 10, 0)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return &Identity{UserID: uint(id), Username: claims.Username, Role: claims.Role}, nil
+}
@@ -0,0 +1,340 @@
+// Package auth provides authentication primitives: signed JSON Web Tokens (HS256 and RS256),
+// password hashing, API keys, and helpers to carry the authenticated identity in a request context.
+//
+// Usage:
+// Configure the token manager with the environment variables below, then use
+// `GetTokenManager` to issue and verify access tokens.
+//
+//   - AUTH_ALGORITHM: HS256 (default) or RS256
+//   - AUTH_JWT_SECRET: the HMAC secret, required for HS256
+//   - AUTH_PRIVATE_KEY_FILE, AUTH_PUBLIC_KEY_FILE: PEM encoded RSA keys, required for RS256
+//   - AUTH_ISSUER: the `iss` claim of issued tokens
+//   - AUTH_ACCESS_TOKEN_TTL: access token lifetime in seconds
+//   - AUTH_REFRESH_TOKEN_TTL: refresh token lifetime in seconds
+//
+// Example:
+// Issue an access token:
+// token, err := auth.GetTokenManager().Issue(&auth.Identity{UserID: 1, Username: "alice", Role: "user"})
+//
+// Verify an access token:
+// claims, err := auth.GetTokenManager().Verify(token)
+//
+// Hash and check a password:
+// hash, err := auth.HashPassword("secret")
+// ok := auth.CheckPassword("secret", hash)
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+)
+
+// Supported signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+)
+
+var (
+	// ErrInvalidToken is returned when a token is malformed or its signature does not match.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired is returned when a token is past its expiration time.
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// Claims represents the payload of an access token.
+type Claims struct {
+	Subject   string `json:"sub"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	Issuer    string `json:"iss,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// header represents the JOSE header of a token.
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+}
+
+// TokenManager issues and verifies signed JSON Web Tokens.
+type TokenManager struct {
+	Algorithm       string
+	Issuer          string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	secret          []byte
+	privateKey      *rsa.PrivateKey
+	publicKey       *rsa.PublicKey
+}
+
+// NewHS256TokenManager creates a TokenManager signing tokens with HMAC-SHA256.
+func NewHS256TokenManager(secret []byte, issuer string, accessTTL time.Duration, refreshTTL time.Duration) (*TokenManager, error) {
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("HS256 secret must be at least 32 bytes long")
+	}
+	return &TokenManager{
+		Algorithm:       HS256,
+		Issuer:          issuer,
+		AccessTokenTTL:  accessTTL,
+		RefreshTokenTTL: refreshTTL,
+		secret:          secret,
+	}, nil
+}
+
+// NewRS256TokenManager creates a TokenManager signing tokens with RSASSA-PKCS1-v1_5 SHA-256.
+// The private key may be nil, in which case the manager can only verify tokens.
+func NewRS256TokenManager(privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey, issuer string, accessTTL time.Duration, refreshTTL time.Duration) (*TokenManager, error) {
+	if publicKey == nil && privateKey != nil {
+		publicKey = &privateKey.PublicKey
+	}
+	if publicKey == nil {
+		return nil, fmt.Errorf("RS256 requires a public key")
+	}
+	return &TokenManager{
+		Algorithm:       RS256,
+		Issuer:          issuer,
+		AccessTokenTTL:  accessTTL,
+		RefreshTokenTTL: refreshTTL,
+		privateKey:      privateKey,
+		publicKey:       publicKey,
+	}, nil
+}
+
+// Sign encodes and signs the given claims.
+func (tm *TokenManager) Sign(claims Claims) (string, error) {
+	headerJSON, err := json.Marshal(header{Algorithm: tm.Algorithm, Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encodeSegment(headerJSON) + "." + encodeSegment(claimsJSON)
+	signature, err := tm.signature(signingInput)
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + encodeSegment(signature), nil
+}
+
+// Issue creates a signed access token for the given identity, valid for AccessTokenTTL.
+func (tm *TokenManager) Issue(identity *Identity) (string, error) {
+	now := time.Now()
+	return tm.Sign(Claims{
+		Subject:   strconv.FormatUint(uint64(identity.UserID), 10),
+		Username:  identity.Username,
+		Role:      identity.Role,
+		Issuer:    tm.Issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(tm.AccessTokenTTL).Unix(),
+	})
+}
+
+// Verify checks the signature, algorithm, issuer and expiration of a token and returns its claims.
+func (tm *TokenManager) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	headerJSON, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var h header
+	if err := json.Unmarshal(headerJSON, &h); err != nil || h.Algorithm != tm.Algorithm {
+		// Never let the token pick the algorithm, see "alg: none" attacks
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !tm.verifySignature(parts[0]+"."+parts[1], signature) {
+		return nil, ErrInvalidToken
+	}
+
+	claimsJSON, err := decodeSegment(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if tm.Issuer != "" && claims.Issuer != tm.Issuer {
+		return nil, ErrInvalidToken
+	}
+	if claims.ExpiresAt == 0 || time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	return &claims, nil
+}
+
+// signature computes the signature of the signing input.
+func (tm *TokenManager) signature(signingInput string) ([]byte, error) {
+	switch tm.Algorithm {
+	case HS256:
+		mac := hmac.New(sha256.New, tm.secret)
+		mac.Write([]byte(signingInput))
+		return mac.Sum(nil), nil
+	case RS256:
+		if tm.privateKey == nil {
+			return nil, fmt.Errorf("RS256 private key is not configured")
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		return rsa.SignPKCS1v15(nil, tm.privateKey, crypto.SHA256, digest[:])
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", tm.Algorithm)
+	}
+}
+
+// verifySignature reports whether the signature matches the signing input.
+func (tm *TokenManager) verifySignature(signingInput string, signature []byte) bool {
+	switch tm.Algorithm {
+	case HS256:
+		expected, _ := tm.signature(signingInput)
+		return hmac.Equal(signature, expected)
+	case RS256:
+		digest := sha256.Sum256([]byte(signingInput))
+		return rsa.VerifyPKCS1v15(tm.publicKey, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return false
+	}
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(segment)
+}
+
+// readRSAKeys reads PEM encoded RSA keys from the given files. Either path may be empty.
+func readRSAKeys(privateKeyPath string, publicKeyPath string) (*rsa.PrivateKey, *rsa.PublicKey, error) {
+	var privateKey *rsa.PrivateKey
+	var publicKey *rsa.PublicKey
+
+	if privateKeyPath != "" {
+		block, err := readPEM(privateKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s: %w", privateKeyPath, err)
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s is not an RSA private key", privateKeyPath)
+		}
+		privateKey = rsaKey
+	}
+
+	if publicKeyPath != "" {
+		block, err := readPEM(publicKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s: %w", publicKeyPath, err)
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s is not an RSA public key", publicKeyPath)
+		}
+		publicKey = rsaKey
+	}
+
+	return privateKey, publicKey, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s does not contain a PEM block", path)
+	}
+	return block, nil
+}
+
+// envSeconds reads a duration in seconds from the given environment variable.
+func envSeconds(name string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0, fmt.Errorf("%s must be a positive number of seconds", name)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// NewTokenManagerFromEnv creates a TokenManager from the AUTH_* environment variables.
+func NewTokenManagerFromEnv() (*TokenManager, error) {
+	accessTTL, err := envSeconds("AUTH_ACCESS_TOKEN_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	refreshTTL, err := envSeconds("AUTH_REFRESH_TOKEN_TTL", 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	issuer := os.Getenv("AUTH_ISSUER")
+
+	switch algorithm := os.Getenv("AUTH_ALGORITHM"); algorithm {
+	case "", HS256:
+		return NewHS256TokenManager([]byte(os.Getenv("AUTH_JWT_SECRET")), issuer, accessTTL, refreshTTL)
+	case RS256:
+		privateKey, publicKey, err := readRSAKeys(os.Getenv("AUTH_PRIVATE_KEY_FILE"), os.Getenv("AUTH_PUBLIC_KEY_FILE"))
+		if err != nil {
+			return nil, err
+		}
+		return NewRS256TokenManager(privateKey, publicKey, issuer, accessTTL, refreshTTL)
+	default:
+		return nil, fmt.Errorf("unsupported AUTH_ALGORITHM: %s", algorithm)
+	}
+}
+
+// tm is the shared singleton instance of the TokenManager.
+var tm *TokenManager
+
+// GetTokenManager returns the shared singleton instance of the TokenManager.
+// It is configured from the environment on first use.
+func GetTokenManager() *TokenManager {
+	if tm == nil {
+		manager, err := NewTokenManagerFromEnv()
+		if err != nil {
+			logger.GetLogger().Fatal("Error configuring token manager", "error", err)
+		}
+		tm = manager
+	}
+	return tm
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestHS256(t *testing.T) {
+	tm, err := NewHS256TokenManager([]byte(testSecret), "test", time.Minute, time.Hour)
+	assert.NoError(t, err)
+
+	token, err := tm.Issue(&Identity{UserID: 42, Username: "alice", Role: "admin"})
+	assert.NoError(t, err)
+
+	claims, err := tm.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", claims.Subject)
+	assert.Equal(t, "alice", claims.Username)
+
+	identity, err := IdentityFromClaims(claims)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), identity.UserID)
+	assert.Equal(t, "admin", identity.Role)
+
+	// Tampered payload
+	parts := strings.Split(token, ".")
+	forged, _ := tm.Sign(Claims{Subject: "1", ExpiresAt: time.Now().Add(time.Hour).Unix(), Issuer: "test"})
+	_, err = tm.Verify(parts[0] + "." + strings.Split(forged, ".")[1] + "." + parts[2])
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Other secret
+	other, _ := NewHS256TokenManager([]byte(strings.Repeat("x", 32)), "test", time.Minute, time.Hour)
+	_, err = other.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Unsigned token
+	_, err = tm.Verify(encodeSegment([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + ".")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Expired token
+	expired, _ := tm.Sign(Claims{Subject: "42", Issuer: "test", ExpiresAt: time.Now().Add(-time.Second).Unix()})
+	_, err = tm.Verify(expired)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	// Wrong issuer
+	wrongIssuer, _ := tm.Sign(Claims{Subject: "42", Issuer: "other", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	_, err = tm.Verify(wrongIssuer)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Short secrets are rejected
+	_, err = NewHS256TokenManager([]byte("short"), "test", time.Minute, time.Hour)
+	assert.Error(t, err)
+}
+
+func TestRS256(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write the keys to PEM files and configure the manager from the environment
+	dir := t.TempDir()
+	privateDER, _ := x509.MarshalPKCS8PrivateKey(privateKey)
+	publicDER, _ := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600)
+	os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644)
+
+	t.Setenv("AUTH_ALGORITHM", "RS256")
+	t.Setenv("AUTH_PRIVATE_KEY_FILE", privatePath)
+	t.Setenv("AUTH_PUBLIC_KEY_FILE", publicPath)
+	t.Setenv("AUTH_ACCESS_TOKEN_TTL", "60")
+
+	tm, err := NewTokenManagerFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, tm.AccessTokenTTL)
+
+	bob := &Identity{UserID: 7, Username: "bob", Role: "user"}
+	token, err := tm.Issue(bob)
+	assert.NoError(t, err)
+	claims, err := tm.Verify(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", claims.Username)
+
+	// A verify-only manager accepts the token but cannot sign
+	verifier, err := NewRS256TokenManager(nil, &privateKey.PublicKey, "", time.Minute, time.Hour)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(token)
+	assert.NoError(t, err)
+	_, err = verifier.Issue(bob)
+	assert.Error(t, err)
+
+	// An HS256 token signed with the public key must not be accepted
+	confused, _ := NewHS256TokenManager(publicDER, "", time.Minute, time.Hour)
+	forged, _ := confused.Issue(&Identity{UserID: 1, Username: "mallory", Role: "admin"})
+	_, err = tm.Verify(forged)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Password hashing parameters. The iteration count follows the OWASP recommendation for PBKDF2-HMAC-SHA256.
+const (
+	passwordIterations = 600000
+	passwordSaltLength = 16
+	passwordKeyLength  = 32
+	passwordScheme     = "pbkdf2-sha256"
+)
+
+// pbkdf2 derives a key from the password and salt as described in RFC 8018, using HMAC-SHA256.
+func pbkdf2(password []byte, salt []byte, iterations int, keyLength int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLength := prf.Size()
+	blocks := (keyLength + hashLength - 1) / hashLength
+
+	key := make([]byte, 0, blocks*hashLength)
+	counter := make([]byte, 4)
+	for block := 1; block <= blocks; block++ {
+		binary.BigEndian.PutUint32(counter, uint32(block))
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter)
+		u := prf.Sum(nil)
+		t := make([]byte, len(u))
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLength]
+}
+
+// HashPassword hashes a password with a random salt.
+// The result has the form `pbkdf2-sha256$<iterations>$<salt>$<hash>` and can be stored as is.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2([]byte(password), salt, passwordIterations, passwordKeyLength)
+	return fmt.Sprintf(
+		"%s$%d$%s$%s",
+		passwordScheme,
+		passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// DummyPasswordHash is a well-formed hash that no password matches. Check passwords against it when the user
+// is not found, so that unknown users take as long as known users and cannot be told apart by timing.
+var DummyPasswordHash = fmt.Sprintf(
+	"%s$%d$%s$%s",
+	passwordScheme,
+	passwordIterations,
+	base64.RawStdEncoding.EncodeToString(make([]byte, passwordSaltLength)),
+	base64.RawStdEncoding.EncodeToString(make([]byte, passwordKeyLength)),
+)
+
+// CheckPassword reports whether the password matches a hash created by HashPassword.
+func CheckPassword(password string, hash string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != passwordScheme {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	key := pbkdf2([]byte(password), salt, iterations, len(expected))
+	return hmac.Equal(key, expected)
+}
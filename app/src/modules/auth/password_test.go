@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// RFC 7914 section 11 test vector for PBKDF2-HMAC-SHA256
+	key := pbkdf2([]byte("passwd"), []byte("salt"), 1, 64)
+	assert.Equal(t,
+		"55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783",
+		hex.EncodeToString(key),
+	)
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	assert.NoError(t, err)
+	assert.True(t, CheckPassword("correct horse", hash))
+	assert.False(t, CheckPassword("wrong horse", hash))
+	assert.False(t, CheckPassword("correct horse", "plaintext"))
+
+	// Hashes are salted
+	other, _ := HashPassword("correct horse")
+	assert.NotEqual(t, hash, other)
+}
+
+func TestDummyPasswordHash(t *testing.T) {
+	// The dummy hash costs as much to check as a real one, and matches no password
+	hash, err := HashPassword("correct horse")
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Split(hash, "$")[1], strings.Split(DummyPasswordHash, "$")[1])
+	assert.Len(t, DummyPasswordHash, len(hash))
+	assert.False(t, CheckPassword("", DummyPasswordHash))
+	assert.False(t, CheckPassword("correct horse", DummyPasswordHash))
+}
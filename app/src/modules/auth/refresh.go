@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// refreshTokenLength is the number of random bytes in a refresh token.
+const refreshTokenLength = 32
+
+// NewRefreshToken generates an opaque refresh token.
+// Only the hash returned alongside it should be stored.
+func NewRefreshToken() (token string, hash string, err error) {
+	data := make([]byte, refreshTokenLength)
+	if _, err := rand.Read(data); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(data)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the value under which a refresh token is stored.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,218 @@
+// Package clientip resolves the IP address of the client that made a request.
+//
+// By default the client IP is the address of the peer of the connection, `r.RemoteAddr`, since any client can
+// set the `X-Forwarded-For` and `Forwarded` (RFC 7239) headers. When the peer is a trusted proxy, the addresses
+// it forwarded are walked from the nearest to the farthest, and the client IP is the first address that is not
+// a trusted proxy itself. Only the header set by the trusted proxies is read, `X-Forwarded-For` by default,
+// since proxies pass the other one through as the client sent it.
+//
+// Usage:
+// Set the trusted proxies by setting the environment variable `HTTP_TRUSTED_PROXIES` to a comma separated list
+// of IP addresses and CIDR ranges, e.g. the address of the reverse proxy in front of the application,
+// and the header they set by setting `HTTP_FORWARDED_HEADER` to `x-forwarded-for` or `forwarded`.
+// Then use the `Resolve` function to get the client IP of a request.
+//
+// The `Filter` type decides which client IP addresses can access the API, with the allowlist and the denylist
+// set by the environment variables `HTTP_ALLOWED_IPS` and `HTTP_DENIED_IPS`.
+//
+// Example:
+// Set the environment variable:
+// export HTTP_TRUSTED_PROXIES=10.0.0.0/8,127.0.0.1
+//
+// Resolve the client IP of a request:
+//
+//	ip := clientip.GetResolver().Resolve(r)
+//
+// The client IP can be stored in a context with `NewContext` and retrieved with `FromContext`. The logger returned by
+// `logger.FromContext` attaches the client IP of its context to every line as `client_ip`.
+package clientip
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+)
+
+// Header is the header in which the trusted proxies forward the address of the client.
+type Header string
+
+// Headers of a Resolver.
+const (
+	// HeaderXForwardedFor is the de facto standard `X-Forwarded-For` header.
+	HeaderXForwardedFor Header = "x-forwarded-for"
+	// HeaderForwarded is the `Forwarded` header of RFC 7239.
+	HeaderForwarded Header = "forwarded"
+)
+
+// ErrInvalidHeader is returned when parsing a header that is not x-forwarded-for or forwarded.
+var ErrInvalidHeader = errors.New("invalid forwarded header, expected x-forwarded-for or forwarded")
+
+// ParseHeader parses a header: `x-forwarded-for` or `forwarded`.
+func ParseHeader(value string) (Header, error) {
+	switch header := Header(strings.ToLower(strings.TrimSpace(value))); header {
+	case HeaderXForwardedFor, HeaderForwarded:
+		return header, nil
+	default:
+		return "", ErrInvalidHeader
+	}
+}
+
+// ParseNetworks parses IP addresses and CIDR ranges, e.g. `192.0.2.1` or `10.0.0.0/8`.
+// An IP address is parsed as a range of a single address, and empty values are skipped.
+func ParseNetworks(values ...string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Contains reports whether the given IP address, with or without a port, is in one of the networks.
+func Contains(networks []*net.IPNet, addr string) bool {
+	ip := parseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIP parses an IP address with or without a port, or an IPv6 address in brackets, and returns nil if it is not valid.
+func parseIP(addr string) net.IP {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"))
+}
+
+// forwardedFor returns the `for` parameters of the Forwarded headers of the request, from the farthest to the nearest hop.
+func forwardedFor(r *http.Request) []string {
+	var addrs []string
+	for _, header := range r.Header.Values("Forwarded") {
+		for _, element := range strings.Split(header, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				if strings.EqualFold(key, "for") {
+					addrs = append(addrs, strings.Trim(value, `"`))
+				}
+			}
+		}
+	}
+	return addrs
+}
+
+// xForwardedFor returns the addresses of the X-Forwarded-For headers of the request, from the farthest to the nearest hop.
+func xForwardedFor(r *http.Request) []string {
+	var addrs []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		addrs = append(addrs, strings.Split(header, ",")...)
+	}
+	return addrs
+}
+
+// Resolver resolves the client IP of requests, trusting the forwarding header set by trusted proxies only.
+type Resolver struct {
+	trustedProxies []*net.IPNet
+	header         Header
+}
+
+// NewResolver returns a new Resolver trusting the given header set by the proxies in the given networks.
+func NewResolver(trustedProxies []*net.IPNet, header Header) *Resolver {
+	return &Resolver{trustedProxies: trustedProxies, header: header}
+}
+
+// Resolve returns the client IP of the request, or an empty string if the address of the peer is not valid.
+func (res *Resolver) Resolve(r *http.Request) string {
+	ip := parseIP(r.RemoteAddr)
+	if ip == nil {
+		return ""
+	}
+	if !Contains(res.trustedProxies, ip.String()) {
+		return ip.String()
+	}
+
+	// The other header is passed through by the proxies as the client sent it
+	var hops []string
+	if res.header == HeaderForwarded {
+		hops = forwardedFor(r)
+	} else {
+		hops = xForwardedFor(r)
+	}
+	for i := len(hops) - 1; i >= 0 && Contains(res.trustedProxies, ip.String()); i-- {
+		// Stop at obfuscated or malformed addresses, the last trusted proxy is the best known client
+		hop := parseIP(hops[i])
+		if hop == nil {
+			break
+		}
+		ip = hop
+	}
+	return ip.String()
+}
+
+// contextKey is the context key of the client IP.
+type contextKey struct{}
+
+// NewContext returns a copy of the context carrying the given client IP.
+func NewContext(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, contextKey{}, ip)
+}
+
+// FromContext returns the client IP carried by the context, or an empty string.
+func FromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(contextKey{}).(string)
+	return ip
+}
+
+func init() {
+	// Attach the client IP of the request to every log line
+	logger.RegisterContextField("client_ip", FromContext)
+}
+
+// resolver is the shared singleton instance of the Resolver.
+var resolver *Resolver
+
+// GetResolver returns the shared singleton instance of the Resolver, trusting the proxies
+// listed in the HTTP_TRUSTED_PROXIES environment variable, and the header set by the HTTP_FORWARDED_HEADER
+// environment variable, x-forwarded-for by default.
+func GetResolver() *Resolver {
+	if resolver == nil {
+		value := os.Getenv("HTTP_TRUSTED_PROXIES")
+		trustedProxies, err := ParseNetworks(strings.Split(value, ",")...)
+		if err != nil {
+			logger.GetLogger().Fatal("Error parsing trusted proxies", "value", value, "error", err)
+		}
+		header := HeaderXForwardedFor
+		if value := os.Getenv("HTTP_FORWARDED_HEADER"); value != "" {
+			if header, err = ParseHeader(value); err != nil {
+				logger.GetLogger().Fatal("Error parsing forwarded header", "value", value, "error", err)
+			}
+		}
+		resolver = NewResolver(trustedProxies, header)
+	}
+	return resolver
+}
@@ -0,0 +1,121 @@
+package clientip
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks("10.0.0.0/8", " 192.0.2.1 ", "2001:db8::1", "")
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	assert.Equal(t, "192.0.2.1/32", networks[1].String())
+	assert.Equal(t, "2001:db8::1/128", networks[2].String())
+
+	_, err = ParseNetworks("10.0.0.0/8", "not an address")
+	assert.Error(t, err)
+
+	assert.True(t, Contains(networks, "10.1.2.3:443"))
+	assert.True(t, Contains(networks, "[2001:db8::1]:8080"))
+	assert.False(t, Contains(networks, "192.0.2.2"))
+	assert.False(t, Contains(networks, "localhost"))
+}
+
+func TestResolve(t *testing.T) {
+	trusted, err := ParseNetworks("10.0.0.0/8")
+	require.NoError(t, err)
+	resolver := NewResolver(trusted, HeaderXForwardedFor)
+	forwarded := NewResolver(trusted, HeaderForwarded)
+
+	tests := []struct {
+		name       string
+		resolver   *Resolver
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"direct client", resolver, "192.0.2.1:1234", nil, "192.0.2.1"},
+		{"untrusted peer cannot spoof", resolver, "192.0.2.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "192.0.2.1"},
+		{"trusted proxy", resolver, "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", resolver, "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"spoofed hop before the client", resolver, "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.9, 198.51.100.1"}, "198.51.100.1"},
+		{"trusted proxy without header", resolver, "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"malformed hop", resolver, "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1, garbage"}, "10.0.0.1"},
+		{"forwarded", forwarded, "10.0.0.1:1234", map[string]string{"Forwarded": `for=198.51.100.1;proto=https, for="[2001:db8:cafe::17]:4711"`}, "2001:db8:cafe::17"},
+		{"forwarded over x-forwarded-for", forwarded, "10.0.0.1:1234", map[string]string{"Forwarded": "For=198.51.100.1", "X-Forwarded-For": "203.0.113.9"}, "198.51.100.1"},
+		{"client forwarded ignored", resolver, "10.0.0.1:1234", map[string]string{"Forwarded": "for=1.2.3.4", "X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"client forwarded without x-forwarded-for", resolver, "10.0.0.1:1234", map[string]string{"Forwarded": "for=1.2.3.4"}, "10.0.0.1"},
+		{"client x-forwarded-for ignored", forwarded, "10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "10.0.0.1"},
+		{"obfuscated forwarded", forwarded, "10.0.0.1:1234", map[string]string{"Forwarded": "for=_hidden"}, "10.0.0.1"},
+		{"invalid peer", resolver, "", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			assert.Equal(t, tt.want, tt.resolver.Resolve(req))
+		})
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	header, err := ParseHeader(" Forwarded")
+	assert.NoError(t, err)
+	assert.Equal(t, HeaderForwarded, header)
+	_, err = ParseHeader("x-real-ip")
+	assert.ErrorIs(t, err, ErrInvalidHeader)
+}
+
+func TestGetResolver(t *testing.T) {
+	os.Setenv("HTTP_TRUSTED_PROXIES", "127.0.0.1,10.0.0.0/8")
+	defer os.Unsetenv("HTTP_TRUSTED_PROXIES")
+
+	r1 := GetResolver()
+	r2 := GetResolver()
+	assert.Same(t, r1, r2)
+	assert.Len(t, r1.trustedProxies, 2)
+	assert.Equal(t, HeaderXForwardedFor, r1.header)
+}
+
+func TestContext(t *testing.T) {
+	assert.Equal(t, "", FromContext(context.Background()))
+	ctx := NewContext(context.Background(), "192.0.2.1")
+	assert.Equal(t, "192.0.2.1", FromContext(ctx))
+}
+
+func TestFilter(t *testing.T) {
+	deny, err := ParseNetworks("10.0.0.0/8")
+	require.NoError(t, err)
+	allow, err := ParseNetworks("10.1.0.0/16")
+	require.NoError(t, err)
+
+	// Denied addresses are refused
+	filter := NewFilter(nil, deny)
+	assert.True(t, filter.Allowed("192.0.2.1"))
+	assert.False(t, filter.Allowed("10.1.2.3"))
+
+	// Only the addresses of a non-empty allowlist are accepted
+	filter = NewFilter(allow, deny)
+	assert.True(t, filter.Allowed("10.1.2.3:443"))
+	assert.False(t, filter.Allowed("10.2.0.1"))
+	assert.False(t, filter.Allowed("192.0.2.1"))
+}
+
+func TestGetFilter(t *testing.T) {
+	os.Setenv("HTTP_DENIED_IPS", "10.0.0.0/8, 192.0.2.1")
+	defer os.Unsetenv("HTTP_DENIED_IPS")
+
+	f1 := GetFilter()
+	f2 := GetFilter()
+	assert.Same(t, f1, f2)
+	assert.Empty(t, f1.allow)
+	assert.Len(t, f1.deny, 2)
+}
@@ -0,0 +1,52 @@
+package clientip
+
+import (
+	"net"
+	"os"
+	"strings"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+)
+
+// Filter decides which client IP addresses can access the API with an allowlist and a denylist.
+// Addresses of the denylist are refused unless they are also in the allowlist, so that a range can be denied
+// except for some of its addresses. When the allowlist is not empty, only its addresses are accepted.
+type Filter struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// NewFilter returns a new Filter with the given allowed and denied networks.
+func NewFilter(allow []*net.IPNet, deny []*net.IPNet) *Filter {
+	return &Filter{allow: allow, deny: deny}
+}
+
+// Allowed reports whether the given IP address, with or without a port, can access the API.
+func (f *Filter) Allowed(addr string) bool {
+	if Contains(f.allow, addr) {
+		return true
+	}
+	return len(f.allow) == 0 && !Contains(f.deny, addr)
+}
+
+// filter is the shared singleton instance of the Filter.
+var filter *Filter
+
+// GetFilter returns the shared singleton instance of the Filter, allowing the addresses listed in the
+// HTTP_ALLOWED_IPS environment variable and denying the addresses listed in the HTTP_DENIED_IPS
+// environment variable, as comma separated lists of IP addresses and CIDR ranges.
+func GetFilter() *Filter {
+	if filter == nil {
+		var networks [2][]*net.IPNet
+		for i, name := range []string{"HTTP_ALLOWED_IPS", "HTTP_DENIED_IPS"} {
+			value := os.Getenv(name)
+			var err error
+			networks[i], err = ParseNetworks(strings.Split(value, ",")...)
+			if err != nil {
+				logger.GetLogger().Fatal("Error parsing IP filter", "name", name, "value", value, "error", err)
+			}
+		}
+		filter = NewFilter(networks[0], networks[1])
+	}
+	return filter
+}
@@ -0,0 +1,225 @@
+// Package csrf provides signed double-submit tokens protecting against Cross-Site Request Forgery (CSRF) attacks.
+//
+// Every browser gets a random session ID in an HttpOnly cookie. A CSRF token is a random nonce signed with
+// HMAC-SHA256 over the session ID and the nonce, so that it is only valid for the session it was issued to and
+// cannot be forged without the secret. The token is sent in a cookie readable by JavaScript, and the client
+// submits it back in the `X-CSRF-Token` header of every unsafe request. Since a cross-site request can neither
+// read the cookie nor set the header, a request carrying a valid token was sent by the application itself.
+//
+// Usage:
+// Set the secret and the cookie attributes by setting the environment variables `CSRF_SECRET` (at least
+// 32 bytes), `CSRF_SECURE`, `CSRF_SAME_SITE` (`strict`, `lax` or `none`) and `CSRF_MAX_AGE` (seconds).
+// Then use the `Token` function to get the token of a request, issuing one if needed, and the `Verify`
+// function to check the token submitted with a request.
+//
+// Example:
+//
+//	if !csrf.GetProtector().Verify(r) {
+//	    http.Error(w, "CSRF Token Invalid", http.StatusForbidden)
+//	    return
+//	}
+package csrf
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+)
+
+const (
+	// HeaderName is the request header carrying the CSRF token of unsafe requests, and the response header carrying the issued token.
+	HeaderName = "X-CSRF-Token"
+	// CookieName is the cookie carrying the CSRF token, readable by JavaScript.
+	CookieName = "csrf_token"
+	// SessionCookieName is the HttpOnly cookie carrying the session ID the tokens are tied to.
+	SessionCookieName = "csrf_session"
+
+	randomLength = 32
+)
+
+// ErrInvalidSameSite is returned when parsing a SameSite attribute that is not strict, lax or none.
+var ErrInvalidSameSite = errors.New("invalid SameSite attribute, expected strict, lax or none")
+
+// ParseSameSite parses a SameSite cookie attribute: `strict`, `lax` or `none`.
+func ParseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, ErrInvalidSameSite
+	}
+}
+
+// Protector issues and verifies the CSRF tokens of requests.
+type Protector struct {
+	secret   []byte
+	secure   bool
+	sameSite http.SameSite
+	maxAge   time.Duration
+}
+
+// NewProtector creates a new Protector signing the tokens with the given secret, at least 32 bytes long,
+// and setting cookies with the given Secure and SameSite attributes, valid for maxAge.
+func NewProtector(secret []byte, secure bool, sameSite http.SameSite, maxAge time.Duration) (*Protector, error) {
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("CSRF secret must be at least 32 bytes long")
+	}
+	// Browsers reject SameSite=None cookies that are not Secure
+	if sameSite == http.SameSiteNoneMode && !secure {
+		return nil, fmt.Errorf("CSRF cookies must be secure with SameSite=None")
+	}
+	return &Protector{secret: secret, secure: secure, sameSite: sameSite, maxAge: maxAge}, nil
+}
+
+// random returns a random URL-safe string.
+func random() (string, error) {
+	b := make([]byte, randomLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// sign returns the signature of the nonce for the session.
+func (p *Protector) sign(session string, nonce string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(session + "." + nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// valid reports whether the token was issued to the session.
+func (p *Protector) valid(session string, token string) bool {
+	nonce, signature, found := strings.Cut(token, ".")
+	if session == "" || !found || nonce == "" {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(p.sign(session, nonce)))
+}
+
+// cookie returns a cookie with the attributes of the Protector.
+func (p *Protector) cookie(name string, value string, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(p.maxAge.Seconds()),
+		HttpOnly: httpOnly,
+		Secure:   p.secure,
+		SameSite: p.sameSite,
+	}
+}
+
+// cookieValue returns the value of the named cookie of the request, or an empty string.
+func cookieValue(r *http.Request, name string) string {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// tokenKey is the context key of the CSRF token issued for a request.
+type tokenKey struct{}
+
+// NewContext returns a copy of the context carrying the given CSRF token.
+func NewContext(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// Token returns the CSRF token of the request: the token carried by its context, or by its cookie if it is valid
+// for its session. Otherwise it issues a new token, starting a new session if needed, and sets the cookies.
+func (p *Protector) Token(w http.ResponseWriter, r *http.Request) (string, error) {
+	if token, _ := r.Context().Value(tokenKey{}).(string); token != "" {
+		return token, nil
+	}
+	session := cookieValue(r, SessionCookieName)
+	if token := cookieValue(r, CookieName); p.valid(session, token) {
+		return token, nil
+	}
+
+	if session == "" {
+		var err error
+		if session, err = random(); err != nil {
+			return "", err
+		}
+		http.SetCookie(w, p.cookie(SessionCookieName, session, true))
+	}
+	nonce, err := random()
+	if err != nil {
+		return "", err
+	}
+	token := nonce + "." + p.sign(session, nonce)
+	// The token cookie is read by JavaScript to be submitted in the request header
+	http.SetCookie(w, p.cookie(CookieName, token, false))
+	return token, nil
+}
+
+// Verify reports whether the request submitted, in its header, the token of its cookie, and whether that token was
+// issued to its session.
+func (p *Protector) Verify(r *http.Request) bool {
+	token := r.Header.Get(HeaderName)
+	cookie := cookieValue(r, CookieName)
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(cookie)) != 1 {
+		return false
+	}
+	return p.valid(cookieValue(r, SessionCookieName), token)
+}
+
+// NewProtectorFromEnv creates a Protector from the CSRF_* environment variables.
+func NewProtectorFromEnv() (*Protector, error) {
+	secure := false
+	if value := os.Getenv("CSRF_SECURE"); value != "" {
+		var err error
+		if secure, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("CSRF_SECURE must be a boolean")
+		}
+	}
+	sameSite := http.SameSiteStrictMode
+	if value := os.Getenv("CSRF_SAME_SITE"); value != "" {
+		var err error
+		if sameSite, err = ParseSameSite(value); err != nil {
+			return nil, err
+		}
+	}
+	maxAge := time.Hour
+	if value := os.Getenv("CSRF_MAX_AGE"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("CSRF_MAX_AGE must be a positive number of seconds")
+		}
+		maxAge = time.Duration(seconds) * time.Second
+	}
+	return NewProtector([]byte(os.Getenv("CSRF_SECRET")), secure, sameSite, maxAge)
+}
+
+// protector is the shared singleton instance of the Protector.
+var protector *Protector
+
+// GetProtector returns the shared singleton instance of the Protector.
+// It is configured from the environment on first use.
+func GetProtector() *Protector {
+	if protector == nil {
+		p, err := NewProtectorFromEnv()
+		if err != nil {
+			logger.GetLogger().Fatal("Error configuring CSRF protection", "error", err)
+		}
+		protector = p
+	}
+	return protector
+}
@@ -0,0 +1,124 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+// issue issues a token for a new session and returns it with the cookies set.
+func issue(t *testing.T, p *Protector) (string, []*http.Cookie) {
+	rr := httptest.NewRecorder()
+	token, err := p.Token(rr, httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	return token, rr.Result().Cookies()
+}
+
+// unsafe returns a POST request with the given cookies and X-CSRF-Token header.
+func unsafe(cookies []*http.Cookie, token string) *http.Request {
+	req := httptest.NewRequest("POST", "/", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	if token != "" {
+		req.Header.Set(HeaderName, token)
+	}
+	return req
+}
+
+func TestNewProtector(t *testing.T) {
+	_, err := NewProtector([]byte("short"), true, http.SameSiteStrictMode, time.Hour)
+	assert.Error(t, err)
+	_, err = NewProtector(secret, false, http.SameSiteNoneMode, time.Hour)
+	assert.Error(t, err)
+	_, err = NewProtector(secret, true, http.SameSiteNoneMode, time.Hour)
+	assert.NoError(t, err)
+
+	for value, want := range map[string]http.SameSite{"strict": http.SameSiteStrictMode, "Lax": http.SameSiteLaxMode, "none": http.SameSiteNoneMode} {
+		sameSite, err := ParseSameSite(value)
+		assert.NoError(t, err)
+		assert.Equal(t, want, sameSite)
+	}
+	_, err = ParseSameSite("loose")
+	assert.ErrorIs(t, err, ErrInvalidSameSite)
+}
+
+func TestToken(t *testing.T) {
+	p, err := NewProtector(secret, true, http.SameSiteLaxMode, time.Hour)
+	require.NoError(t, err)
+
+	token, cookies := issue(t, p)
+	require.Len(t, cookies, 2)
+	session, tokenCookie := cookies[0], cookies[1]
+	assert.Equal(t, SessionCookieName, session.Name)
+	assert.True(t, session.HttpOnly)
+	assert.Equal(t, CookieName, tokenCookie.Name)
+	assert.Equal(t, token, tokenCookie.Value)
+	// The token cookie must be readable by JavaScript
+	assert.False(t, tokenCookie.HttpOnly)
+	for _, cookie := range cookies {
+		assert.True(t, cookie.Secure)
+		assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+		assert.Equal(t, 3600, cookie.MaxAge)
+	}
+
+	// A valid token is reused
+	req := unsafe(cookies, "")
+	rr := httptest.NewRecorder()
+	reused, err := p.Token(rr, req)
+	assert.NoError(t, err)
+	assert.Equal(t, token, reused)
+	assert.Empty(t, rr.Result().Cookies())
+
+	// A token is reissued for the same session if it is invalid
+	req = unsafe([]*http.Cookie{session, {Name: CookieName, Value: "forged.token"}}, "")
+	rr = httptest.NewRecorder()
+	reissued, err := p.Token(rr, req)
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, reissued)
+	require.Len(t, rr.Result().Cookies(), 1)
+	assert.True(t, p.Verify(unsafe([]*http.Cookie{session, rr.Result().Cookies()[0]}, reissued)))
+}
+
+func TestVerify(t *testing.T) {
+	p, err := NewProtector(secret, false, http.SameSiteStrictMode, time.Hour)
+	require.NoError(t, err)
+	token, cookies := issue(t, p)
+	other, otherCookies := issue(t, p)
+
+	assert.True(t, p.Verify(unsafe(cookies, token)))
+	// The header must be set
+	assert.False(t, p.Verify(unsafe(cookies, "")))
+	// The header must match the cookie
+	assert.False(t, p.Verify(unsafe(cookies, other)))
+	// The token must be issued to the session
+	assert.False(t, p.Verify(unsafe([]*http.Cookie{cookies[0], otherCookies[1]}, other)))
+	// The token must be signed with the secret
+	forger, err := NewProtector([]byte("fedcba9876543210fedcba9876543210"), false, http.SameSiteStrictMode, time.Hour)
+	require.NoError(t, err)
+	forged, forgedCookies := issue(t, forger)
+	assert.False(t, p.Verify(unsafe(forgedCookies, forged)))
+}
+
+func TestGetProtector(t *testing.T) {
+	os.Setenv("CSRF_SECRET", string(secret))
+	os.Setenv("CSRF_SAME_SITE", "lax")
+	os.Setenv("CSRF_MAX_AGE", "60")
+	defer os.Unsetenv("CSRF_SECRET")
+	defer os.Unsetenv("CSRF_SAME_SITE")
+	defer os.Unsetenv("CSRF_MAX_AGE")
+
+	p1 := GetProtector()
+	p2 := GetProtector()
+	assert.Same(t, p1, p2)
+	assert.False(t, p1.secure)
+	assert.Equal(t, http.SameSiteLaxMode, p1.sameSite)
+	assert.Equal(t, time.Minute, p1.maxAge)
+}
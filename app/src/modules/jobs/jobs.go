@@ -0,0 +1,368 @@
+// Package jobs provides persistent background jobs run by the worker pool.
+//
+// Jobs are stored in a repositories.JobRepository, the jobs table in production, so that they survive restarts
+// and can be shared by several instances of the application. A Runner claims due jobs one at a time and runs
+// them on the worker manager with the handler registered for their type. A job that fails is retried with
+// an exponential backoff until it runs out of attempts, then it is moved to the dead state, where it stays
+// until it is retried manually, e.g. with the POST /api/jobs/{id}/retry endpoint.
+//
+// Usage:
+// Declare a job type with the type of its payload, register its handler and enqueue jobs with typed payloads.
+// Payloads are stored as JSON, so they should only hold exported fields.
+//
+// Example:
+//
+//	type WelcomeEmail struct{ UserId uint }
+//
+//	var SendWelcomeEmail = jobs.NewType[WelcomeEmail]("email.welcome")
+//
+//	SendWelcomeEmail.Handle(jobs.GetRunner(), func(ctx context.Context, payload WelcomeEmail) error {
+//		return mailer.SendWelcome(ctx, payload.UserId)
+//	})
+//	job, err := SendWelcomeEmail.Enqueue(ctx, jobs.GetRunner(), WelcomeEmail{UserId: user.Id})
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/database"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/metrics"
+	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
+)
+
+// ErrUnknownJobType is recorded on jobs whose type has no registered handler. Such jobs are not retried.
+var ErrUnknownJobType = errors.New("unknown job type")
+
+// Handler runs a job with its JSON encoded payload. A returned error fails the attempt.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Handle adapts a function taking a typed payload into a Handler. The payload is decoded from JSON,
+// a payload that cannot be decoded fails the attempt.
+func Handle[T any](fn func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return fmt.Errorf("decoding payload: %w", err)
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// Type is a named job type whose payload is a T.
+type Type[T any] struct {
+	Name string
+}
+
+// NewType declares a job type with the given name, which must be unique across the application.
+func NewType[T any](name string) Type[T] {
+	return Type[T]{Name: name}
+}
+
+// Handle registers the handler of the job type on the runner.
+func (t Type[T]) Handle(r *Runner, fn func(ctx context.Context, payload T) error) {
+	r.Register(t.Name, Handle(fn))
+}
+
+// Enqueue stores a job of this type to be run as soon as possible.
+func (t Type[T]) Enqueue(ctx context.Context, r *Runner, payload T) (models.Job, error) {
+	return r.Enqueue(ctx, t.Name, payload, time.Time{})
+}
+
+// EnqueueAt stores a job of this type to be run at the given time.
+func (t Type[T]) EnqueueAt(ctx context.Context, r *Runner, payload T, runAt time.Time) (models.Job, error) {
+	return r.Enqueue(ctx, t.Name, payload, runAt)
+}
+
+// Options configures a Runner. Zero durations disable the corresponding feature.
+type Options struct {
+	// PollInterval is how long the runner waits before looking for due jobs again when there are none.
+	PollInterval time.Duration
+	// Concurrency is the maximum number of jobs run at the same time by the runner.
+	Concurrency int
+	// MaxAttempts is the number of attempts of the jobs enqueued without one.
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry, doubled for every further retry up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Timeout bounds the time spent on a single attempt.
+	Timeout time.Duration
+	// StaleAfter requeues the jobs that have been running for this long, e.g. because their process crashed.
+	// It must be longer than Timeout.
+	StaleAfter time.Duration
+}
+
+// Backoff returns the delay before retrying a job that failed the given number of attempts:
+// base doubled for every attempt after the first one, capped to max if it is not 0.
+func Backoff(base time.Duration, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		if max > 0 && delay >= max {
+			break
+		}
+		delay *= 2
+	}
+	if max > 0 && delay > max {
+		return max
+	}
+	return delay
+}
+
+// Runner runs the jobs of a JobRepository on a worker manager.
+type Runner struct {
+	repo     repositories.JobRepository
+	wm       *worker_manager.WorkerManager
+	options  Options
+	mu       sync.RWMutex
+	handlers map[string]Handler
+	slots    chan struct{} // one element per running job, bounded by Options.Concurrency
+}
+
+// NewRunner creates a runner taking its jobs from the repository and running them on the worker manager.
+func NewRunner(repo repositories.JobRepository, wm *worker_manager.WorkerManager, options Options) *Runner {
+	if options.Concurrency < 1 {
+		options.Concurrency = 1
+	}
+	if options.MaxAttempts < 1 {
+		options.MaxAttempts = 1
+	}
+	return &Runner{
+		repo:     repo,
+		wm:       wm,
+		options:  options,
+		handlers: make(map[string]Handler),
+		slots:    make(chan struct{}, options.Concurrency),
+	}
+}
+
+// Register sets the handler of a job type, replacing any previous one.
+func (r *Runner) Register(jobType string, handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[jobType] = handler
+}
+
+// handler returns the handler of a job type, false if it has none.
+func (r *Runner) handler(jobType string) (Handler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.handlers[jobType]
+	return handler, ok
+}
+
+// Enqueue stores a job of the given type with the JSON encoding of the payload, to be run at runAt,
+// or as soon as possible if runAt is zero. Prefer the Enqueue method of Type, which checks the payload type.
+func (r *Runner) Enqueue(ctx context.Context, jobType string, payload interface{}, runAt time.Time) (models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return models.Job{}, fmt.Errorf("encoding payload of %s job: %w", jobType, err)
+	}
+	return r.repo.Enqueue(ctx, models.Job{
+		Type:        jobType,
+		Payload:     data,
+		MaxAttempts: r.options.MaxAttempts,
+		RunAt:       runAt,
+	})
+}
+
+// Run claims due jobs and adds them to the worker manager until the context is done, and returns once it
+// stopped adding jobs. Jobs already added keep running after that, and are drained with the worker manager,
+// which must only be drained after Run returned, see api.Server.Go.
+func (r *Runner) Run(ctx context.Context) {
+	logger.GetLogger().Info("Starting job runner", "concurrency", r.options.Concurrency)
+	lastRequeue := time.Time{}
+	for {
+		if r.options.StaleAfter > 0 && time.Since(lastRequeue) >= r.options.StaleAfter {
+			lastRequeue = time.Now()
+			r.requeueStale(ctx)
+		}
+
+		select {
+		case r.slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		claimed, err := r.claim(ctx)
+		if claimed {
+			continue
+		}
+		<-r.slots
+		if err != nil && ctx.Err() == nil {
+			logger.GetLogger().Error("Error claiming job", "error", err)
+		}
+
+		select {
+		case <-time.After(r.options.PollInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// claim claims a due job and adds it to the worker manager. It reports whether a job was added,
+// in which case the job releases the slot taken by the caller once it is done.
+func (r *Runner) claim(ctx context.Context) (bool, error) {
+	job, err := r.repo.Claim(ctx)
+	if errors.Is(err, repositories.ErrJobNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = r.wm.AddJobContext(ctx, func() {
+		defer func() { <-r.slots }()
+		r.process(job)
+	})
+	if err != nil {
+		// The runner is stopping, give the job back without waiting for the backoff
+		r.fail(job, errors.New("job runner stopped before the job started"), time.Now())
+		return false, nil
+	}
+	return true, nil
+}
+
+// requeueStale moves the jobs running for longer than Options.StaleAfter back to pending.
+func (r *Runner) requeueStale(ctx context.Context) {
+	count, err := r.repo.RequeueStale(ctx, time.Now().Add(-r.options.StaleAfter))
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.GetLogger().Error("Error requeuing stale jobs", "error", err)
+		}
+		return
+	}
+	if count > 0 {
+		logger.GetLogger().Warn("Requeued stale jobs", "count", count)
+	}
+}
+
+// process runs a claimed job and records its outcome.
+func (r *Runner) process(job models.Job) {
+	logger := logger.GetLogger().With("job_id", job.Id, "job_type", job.Type, "attempt", job.Attempts)
+	handler, ok := r.handler(job.Type)
+	if !ok {
+		logger.Error("No handler registered for job type")
+		r.fail(job, ErrUnknownJobType, time.Time{})
+		return
+	}
+
+	ctx := context.Background()
+	if r.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.options.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	err := call(ctx, handler, job)
+	metrics.JobDuration.WithLabelValues(job.Type).Observe(time.Since(start).Seconds())
+	if err == nil {
+		if cErr := r.repo.Complete(context.Background(), job.Id); cErr != nil {
+			logger.Error("Error completing job", "error", cErr)
+			return
+		}
+		metrics.JobsProcessed.WithLabelValues(job.Type, models.JobCompleted).Inc()
+		logger.Info("Job completed", "duration", time.Since(start))
+		return
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		logger.Error("Job failed, no attempts left", "error", err)
+		r.fail(job, err, time.Time{})
+		return
+	}
+	retryAt := time.Now().Add(Backoff(r.options.BaseBackoff, r.options.MaxBackoff, job.Attempts))
+	logger.Warn("Job failed, retrying", "error", err, "retry_at", retryAt)
+	r.fail(job, err, retryAt)
+}
+
+// call runs the handler of a job, turning its panic into an error so that the attempt fails like any other.
+func call(ctx context.Context, handler Handler, job models.Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			metrics.PanicsRecovered.WithLabelValues("job").Inc()
+			logger.GetLogger().Error("Recovered from panic in job", "job_id", job.Id, "job_type", job.Type, "panic", p, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return handler(ctx, job.Payload)
+}
+
+// fail records the failure of a job, which is retried at retryAt, or dead if retryAt is zero.
+func (r *Runner) fail(job models.Job, jobErr error, retryAt time.Time) {
+	if err := r.repo.Fail(context.Background(), job.Id, jobErr.Error(), retryAt); err != nil {
+		logger.GetLogger().Error("Error recording job failure", "job_id", job.Id, "error", err)
+		return
+	}
+	outcome := "retried"
+	if retryAt.IsZero() {
+		outcome = models.JobDead
+	}
+	metrics.JobsProcessed.WithLabelValues(job.Type, outcome).Inc()
+}
+
+var runner *Runner = nil
+
+// envInt reads a non-negative integer from the given environment variable, or returns the fallback if it is not set.
+func envInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		logger.GetLogger().Fatal("Error parsing jobs configuration", "name", name, "value", value)
+	}
+	return n
+}
+
+// envSeconds reads a duration in seconds, possibly fractional, from the given environment variable,
+// or returns the fallback if it is not set.
+func envSeconds(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		logger.GetLogger().Fatal("Error parsing jobs configuration", "name", name, "value", value)
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// GetRunner returns a singleton runner of the jobs stored in the database, run on the shared worker manager.
+// It is configured with the following environment variables, durations are in seconds:
+//
+//   - JOBS_POLL_INTERVAL: how long to wait before looking for due jobs again when there are none
+//   - JOBS_CONCURRENCY: the maximum number of jobs run at the same time
+//   - JOBS_MAX_ATTEMPTS: the number of attempts before a job is dead
+//   - JOBS_BASE_BACKOFF, JOBS_MAX_BACKOFF: the delay before the first retry, and the maximum delay
+//   - JOBS_TIMEOUT: the maximum duration of an attempt, 0 disables it
+//   - JOBS_STALE_AFTER: the running time after which a job is considered lost and requeued, 0 disables it
+func GetRunner() *Runner {
+	if runner == nil {
+		runner = NewRunner(
+			repositories.NewPostgresJobRepository(database.GetDatabase()),
+			worker_manager.GetWorkerManager(),
+			Options{
+				PollInterval: envSeconds("JOBS_POLL_INTERVAL", time.Second),
+				Concurrency:  envInt("JOBS_CONCURRENCY", 1),
+				MaxAttempts:  envInt("JOBS_MAX_ATTEMPTS", 5),
+				BaseBackoff:  envSeconds("JOBS_BASE_BACKOFF", time.Second),
+				MaxBackoff:   envSeconds("JOBS_MAX_BACKOFF", time.Hour),
+				Timeout:      envSeconds("JOBS_TIMEOUT", 0),
+				StaleAfter:   envSeconds("JOBS_STALE_AFTER", 0),
+			},
+		)
+	}
+	return runner
+}
@@ -0,0 +1,131 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/worker_manager"
+	"github.com/emso-c/konzek-go-assignment/src/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+type greeting struct {
+	Name string
+}
+
+func setup() (*repositories.MemoryJobRepository, *Runner) {
+	os.Setenv("LOGGER_DISABLED", "true")
+	repo := repositories.NewMemoryJobRepository()
+	runner := NewRunner(repo, worker_manager.NewWorkerManager(2, 8), Options{
+		PollInterval: 10 * time.Millisecond,
+		Concurrency:  2,
+		MaxAttempts:  3,
+		BaseBackoff:  time.Millisecond,
+		MaxBackoff:   time.Millisecond,
+	})
+	return repo, runner
+}
+
+// waitFor waits until the job has the given status, or fails the test after a second.
+func waitFor(t *testing.T, repo repositories.JobRepository, id uint, status string) models.Job {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		job, err := repo.Get(context.Background(), id)
+		assert.NoError(t, err)
+		if job.Status == status || time.Now().After(deadline) {
+			assert.Equal(t, status, job.Status)
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, Backoff(time.Second, time.Minute, 1))
+	assert.Equal(t, 4*time.Second, Backoff(time.Second, time.Minute, 3))
+	assert.Equal(t, time.Minute, Backoff(time.Second, time.Minute, 10))
+	assert.Equal(t, time.Minute, Backoff(time.Second, time.Minute, 1000))
+}
+
+func TestRunnerCompletesTypedJobs(t *testing.T) {
+	repo, runner := setup()
+	greet := NewType[greeting]("greet")
+	received := make(chan string, 1)
+	greet.Handle(runner, func(ctx context.Context, payload greeting) error {
+		received <- payload.Name
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	job, err := greet.Enqueue(context.Background(), runner, greeting{Name: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, job.MaxAttempts)
+	assert.Equal(t, "alice", <-received)
+	waitFor(t, repo, job.Id, models.JobCompleted)
+}
+
+func TestRunnerRetriesThenKillsFailingJobs(t *testing.T) {
+	repo, runner := setup()
+	var attempts atomic.Int32
+	runner.Register("flaky", func(ctx context.Context, payload json.RawMessage) error {
+		attempts.Add(1)
+		return errors.New("boom")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	job, err := runner.Enqueue(context.Background(), "flaky", nil, time.Time{})
+	assert.NoError(t, err)
+	job = waitFor(t, repo, job.Id, models.JobDead)
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.Equal(t, 3, job.Attempts)
+	assert.Equal(t, "boom", job.LastError)
+
+	// Unknown job types are dead after a single attempt
+	job, _ = runner.Enqueue(context.Background(), "unknown", nil, time.Time{})
+	job = waitFor(t, repo, job.Id, models.JobDead)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, ErrUnknownJobType.Error(), job.LastError)
+}
+
+func TestRunnerRejectsUndecodablePayloads(t *testing.T) {
+	repo, runner := setup()
+	NewType[greeting]("greet").Handle(runner, func(ctx context.Context, payload greeting) error {
+		return nil
+	})
+	job, _ := repo.Enqueue(context.Background(), models.Job{Type: "greet", Payload: []byte(`"alice"`), MaxAttempts: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	job = waitFor(t, repo, job.Id, models.JobDead)
+	assert.Contains(t, job.LastError, "decoding payload")
+}
+
+func TestRunnerRecoversPanics(t *testing.T) {
+	repo, runner := setup()
+	runner.Register("panicky", func(ctx context.Context, payload json.RawMessage) error {
+		panic("boom")
+	})
+	job, _ := repo.Enqueue(context.Background(), models.Job{Type: "panicky", MaxAttempts: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runner.Run(ctx)
+
+	job = waitFor(t, repo, job.Id, models.JobDead)
+	assert.Equal(t, "panic: boom", job.LastError)
+}
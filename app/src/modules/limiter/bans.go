@@ -0,0 +1,145 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+)
+
+var (
+	// ErrBanNotFound is returned when lifting the ban of an address that is not banned.
+	ErrBanNotFound = errors.New("ban not found")
+	// ErrInvalidAddress is returned when banning an address that is not a valid IP address.
+	ErrInvalidAddress = errors.New("invalid IP address")
+)
+
+// Bans manages temporary bans of client IP addresses, kept in the store of a Limiter.
+//
+// Offenses of a client, e.g. requests refused by the rate limiter, are counted in fixed windows,
+// and the client is banned for a cooldown period once it committed threshold offenses in a window.
+type Bans struct {
+	store     Store
+	threshold int
+	window    time.Duration
+	duration  time.Duration
+	now       func() time.Time
+}
+
+// NewBans returns new Bans kept in the given store, banning a client for duration once it committed threshold
+// offenses within window. A threshold of 0 disables automatic bans.
+func NewBans(store Store, threshold int, window time.Duration, duration time.Duration) *Bans {
+	return &Bans{
+		store:     store,
+		threshold: threshold,
+		window:    window,
+		duration:  duration,
+		now:       time.Now,
+	}
+}
+
+// Duration returns the duration of automatic bans.
+func (b *Bans) Duration() time.Duration {
+	return b.duration
+}
+
+// Ban bans the IP address for the given duration and returns the ban.
+// It returns ErrInvalidAddress if the address is not a valid IP address.
+func (b *Bans) Ban(ctx context.Context, address string, reason string, duration time.Duration) (models.Ban, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return models.Ban{}, ErrInvalidAddress
+	}
+	now := b.now()
+	ban := models.Ban{
+		Address:   ip.String(),
+		Reason:    reason,
+		CreatedAt: now,
+		ExpiresAt: now.Add(duration),
+	}
+	return ban, b.store.AddBan(ctx, ban)
+}
+
+// Unban lifts the ban of the IP address. It returns ErrBanNotFound if the address is not banned.
+func (b *Bans) Unban(ctx context.Context, address string) error {
+	if ip := net.ParseIP(address); ip != nil {
+		address = ip.String()
+	}
+	removed, err := b.store.RemoveBan(ctx, address)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrBanNotFound
+	}
+	return nil
+}
+
+// Get returns the ban of the IP address, and false if it is not banned.
+func (b *Bans) Get(ctx context.Context, address string) (models.Ban, bool, error) {
+	if ip := net.ParseIP(address); ip != nil {
+		address = ip.String()
+	}
+	return b.store.GetBan(ctx, address, b.now())
+}
+
+// List returns the current bans, the latest expiring first.
+func (b *Bans) List(ctx context.Context) ([]models.Ban, error) {
+	return b.store.ListBans(ctx, b.now())
+}
+
+// Offend records an offense of the IP address for the given reason, and bans it once it committed
+// threshold offenses within the window. It returns the ban and true if the address was banned.
+func (b *Bans) Offend(ctx context.Context, address string, reason string) (models.Ban, bool, error) {
+	if b.threshold < 1 {
+		return models.Ban{}, false, nil
+	}
+	start := b.now().Truncate(b.window)
+	count, err := b.store.Increment(ctx, "offense:"+address, start, start.Add(b.window))
+	if err != nil || count < b.threshold {
+		return models.Ban{}, false, err
+	}
+	ban, err := b.Ban(ctx, address, reason, b.duration)
+	if err != nil {
+		return models.Ban{}, false, err
+	}
+	return ban, true, nil
+}
+
+// bans is the shared singleton instance of the Bans.
+var bans *Bans
+
+// envNonNegativeInt reads a non-negative integer from the given environment variable, 0 if it is not set.
+func envNonNegativeInt(name string) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		logger.GetLogger().Fatal("Error parsing rate limiter configuration", "name", name, "value", value)
+	}
+	return n
+}
+
+// GetBans returns the shared singleton instance of the Bans, kept in the store of GetLimiter.
+// A client is banned for HTTP_BAN_DURATION seconds once it committed HTTP_BAN_THRESHOLD offenses
+// within HTTP_BAN_WINDOW seconds, 900 and 60 by default. A threshold of 0, the default, disables automatic bans.
+func GetBans() *Bans {
+	if bans == nil {
+		window, duration := time.Minute, 15*time.Minute
+		if os.Getenv("HTTP_BAN_WINDOW") != "" {
+			window = time.Duration(envPositiveInt("HTTP_BAN_WINDOW")) * time.Second
+		}
+		if os.Getenv("HTTP_BAN_DURATION") != "" {
+			duration = time.Duration(envPositiveInt("HTTP_BAN_DURATION")) * time.Second
+		}
+		bans = NewBans(GetLimiter().store, envNonNegativeInt("HTTP_BAN_THRESHOLD"), window, duration)
+	}
+	return bans
+}
@@ -0,0 +1,108 @@
+package limiter
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// newTestBans returns bans after 2 offenses within a minute, for an hour, with a clock set by the test.
+func newTestBans() (*Bans, *time.Time) {
+	b := NewBans(NewMemoryStore(), 2, time.Minute, time.Hour)
+	clock := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	b.now = func() time.Time { return clock }
+	return b, &clock
+}
+
+func TestBansOffend(t *testing.T) {
+	b, clock := newTestBans()
+	ctx := context.Background()
+
+	if _, banned, _ := b.Offend(ctx, "192.0.2.1", "rate_limit"); banned {
+		t.Errorf("Offend banned the client after its first offense, want a ban after 2")
+	}
+	// Offenses are counted in fixed windows
+	*clock = clock.Add(time.Minute)
+	if _, banned, _ := b.Offend(ctx, "192.0.2.1", "rate_limit"); banned {
+		t.Errorf("Offend banned the client after one offense in the window, want a ban after 2")
+	}
+	ban, banned, err := b.Offend(ctx, "192.0.2.1", "sql_injection")
+	if err != nil || !banned {
+		t.Fatalf("Offend returned %v, %v after 2 offenses in the window, want a ban", banned, err)
+	}
+	if ban.Reason != "sql_injection" || !ban.ExpiresAt.Equal(clock.Add(time.Hour)) {
+		t.Errorf("Offend returned %+v, want a ban for sql_injection expiring in an hour", ban)
+	}
+
+	if _, banned, _ := b.Get(ctx, "192.0.2.1"); !banned {
+		t.Errorf("Get returned false for a banned client, want true")
+	}
+	if _, banned, _ := b.Get(ctx, "192.0.2.2"); banned {
+		t.Errorf("Get returned true for another client, want false")
+	}
+	// Bans expire after their duration
+	*clock = clock.Add(time.Hour + time.Second)
+	if _, banned, _ := b.Get(ctx, "192.0.2.1"); banned {
+		t.Errorf("Get returned true for an expired ban, want false")
+	}
+
+	// A threshold of 0 disables automatic bans
+	disabled := NewBans(NewMemoryStore(), 0, time.Minute, time.Hour)
+	for i := 0; i < 5; i++ {
+		if _, banned, _ := disabled.Offend(ctx, "192.0.2.1", "rate_limit"); banned {
+			t.Errorf("Offend banned a client with automatic bans disabled")
+		}
+	}
+}
+
+func TestBansManage(t *testing.T) {
+	b, _ := newTestBans()
+	ctx := context.Background()
+
+	if _, err := b.Ban(ctx, "not an address", "manual", time.Hour); err != ErrInvalidAddress {
+		t.Errorf("Ban returned %v for an invalid address, want ErrInvalidAddress", err)
+	}
+	ban, err := b.Ban(ctx, "2001:db8:0::1", "manual", time.Minute)
+	if err != nil || ban.Address != "2001:db8::1" {
+		t.Errorf("Ban returned %+v, %v, want a ban of the normalized address", ban, err)
+	}
+	if _, err := b.Ban(ctx, "192.0.2.1", "manual", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	bans, err := b.List(ctx)
+	if err != nil || len(bans) != 2 || bans[0].Address != "192.0.2.1" {
+		t.Errorf("List returned %+v, %v, want 2 bans, the latest expiring first", bans, err)
+	}
+
+	if err := b.Unban(ctx, "2001:db8::0:1"); err != nil {
+		t.Errorf("Unban returned %v for a banned address, want nil", err)
+	}
+	if err := b.Unban(ctx, "2001:db8::1"); err != ErrBanNotFound {
+		t.Errorf("Unban returned %v for an address that is not banned, want ErrBanNotFound", err)
+	}
+}
+
+func TestGetBans(t *testing.T) {
+	setup()
+	defer teardown()
+	os.Setenv("HTTP_BAN_THRESHOLD", "10")
+	os.Setenv("HTTP_BAN_DURATION", "300")
+	defer os.Unsetenv("HTTP_BAN_THRESHOLD")
+	defer os.Unsetenv("HTTP_BAN_DURATION")
+
+	b1 := GetBans()
+	b2 := GetBans()
+
+	if b1 != b2 {
+		t.Errorf("GetBans did not return the shared singleton instance of the Bans")
+	}
+	if b1.store != GetLimiter().store {
+		t.Errorf("GetBans did not keep the bans in the store of the shared Limiter")
+	}
+	if b1.threshold != 10 || b1.window != time.Minute || b1.duration != 5*time.Minute {
+		t.Errorf("GetBans returned bans with threshold %d, window %s and duration %s, want 10, 1m and 5m",
+			b1.threshold, b1.window, b1.duration)
+	}
+}
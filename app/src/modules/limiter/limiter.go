@@ -1,90 +1,162 @@
-// Package limiter provides a *token bucket algorithm* based rate limiter.
-// It limits the rate of incoming requests from clients by keeping track of the
-// requests made during a certain time window, and allowing a maximum number of
-// requests per that window.
+// Package limiter provides a rate limiter with a choice of algorithms.
+// It limits the rate of incoming requests from clients to a maximum number of requests per time window:
+//
+//   - `fixed_window` counts the requests of each client in consecutive windows. It is the cheapest,
+//     but a client can make twice the limit around the boundary of two windows.
+//   - `sliding_window` keeps a log of the requests of each client in the last window, so that the limit
+//     holds over any window. It stores one entry per request.
+//   - `token_bucket` gives each client a bucket of `limit` tokens, refilled at `limit` tokens per window.
+//     A request takes a token, so bursts up to the limit are allowed, then requests are spread evenly.
+//
+// The state of the clients is kept in a Store: a MemoryStore keeps it in process memory, and a PostgresStore
+// keeps it in the database, so that several instances of the application behind a load balancer
+// share the same limits instead of each one granting the whole quota. The state of a client is removed
+// once it expired, e.g. when its bucket is full again, instead of resetting every client at once.
 //
 // Usage:
-// Before using the limiter, set the rate limit and the window size by setting the
-// environment variables `HTTP_RATE_LIMIT` and `HTTP_RATE_LIMIT_WINDOW` respectively.
-// Then initialize the limiter by calling the `Initialize` function once at the start
-// of your application. Finally, use the `ExceedsLimit` function to check if a client
-// has exceeded the rate limit, and `Increment` function to record a request made by
-// a client.
+// Before using the limiter, set the rate limit, the window size in seconds, the algorithm and the store by setting the
+// environment variables `HTTP_RATE_LIMIT`, `HTTP_RATE_LIMIT_WINDOW`, `HTTP_RATE_LIMIT_ALGORITHM` and
+// `HTTP_RATE_LIMIT_STORE` (`memory` or `postgres`) respectively. Then call the `Initialize` function once at the start
+// of your application to remove the expired state periodically. Finally, use the `Allow` function
+// to record a request made by a client and check whether it is within the rate limit.
 //
 // Example:
 // Set the environment variables:
 // export HTTP_RATE_LIMIT=10
 // export HTTP_RATE_LIMIT_WINDOW=60
+// export HTTP_RATE_LIMIT_ALGORITHM=token_bucket
+// export HTTP_RATE_LIMIT_STORE=postgres
 //
 // Initialize the limiter:
 // limiter.GetLimiter().Initialize()
 //
-// Check if a client has exceeded the rate limit:
+// Record a request and check if the client has exceeded the rate limit:
 //
-//	if limiter.GetLimiter().ExceedsLimit(clientID) {
+//	result, err := limiter.GetLimiter().Allow(r.Context(), clientID)
+//	if err == nil && !result.Allowed {
 //	    http.Error(w, "Too many requests", http.StatusTooManyRequests)
 //	    return
 //	}
-//
-// Increment the request count for a client:
-// limiter.GetLimiter().Increment(clientID)
 package limiter
 
 import (
-	"log"
+	"context"
+	"errors"
+	"math"
 	"os"
 	"strconv"
 	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/database"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
 )
 
-// Limiter represents a token bucket algorithm based rate limiter.
+// Rate limiting algorithms.
+const (
+	FixedWindow   = "fixed_window"
+	SlidingWindow = "sliding_window"
+	TokenBucket   = "token_bucket"
+)
+
+// ErrUnknownAlgorithm is returned when creating a limiter with an algorithm that is not one of
+// FixedWindow, SlidingWindow and TokenBucket.
+var ErrUnknownAlgorithm = errors.New("unknown rate limiting algorithm, expected fixed_window, sliding_window or token_bucket")
+
+// Result is the outcome of a request checked by a Limiter.
+type Result struct {
+	Allowed bool
+	// Limit is the number of requests allowed per window.
+	Limit int
+	// Remaining is the number of requests the client can still make right away.
+	Remaining int
+	// ResetAt is when the client can make one more request: the end of the window for a fixed window,
+	// when the oldest request leaves the window for a sliding window, and when the next token is added for a token bucket.
+	ResetAt time.Time
+}
+
+// Limiter represents a rate limiter.
 type Limiter struct {
-	UsageMap map[string]int
+	store     Store
+	algorithm string
+	limit     int
+	window    time.Duration
+	now       func() time.Time
 }
 
-// NewLimiter returns a new Limiter instance.
-func NewLimiter() *Limiter {
-	return &Limiter{
-		UsageMap: make(map[string]int),
+// NewLimiter returns a new Limiter allowing limit requests per window for each client with the given algorithm,
+// keeping the state of the clients in the given store. It returns ErrUnknownAlgorithm for an unknown algorithm.
+func NewLimiter(store Store, algorithm string, limit int, window time.Duration) (*Limiter, error) {
+	switch algorithm {
+	case FixedWindow, SlidingWindow, TokenBucket:
+	default:
+		return nil, ErrUnknownAlgorithm
 	}
+	return &Limiter{
+		store:     store,
+		algorithm: algorithm,
+		limit:     limit,
+		window:    window,
+		now:       time.Now,
+	}, nil
 }
 
-// ExceedsLimit checks if the number of requests made by a client exceeds the rate limit.
-// It returns true if the limit is exceeded, false otherwise.
+// Allow records a request made by a client and reports whether it is within the rate limit.
 // The identifier parameter represents the client identifier (e.g. IP address, user ID, etc.).
-func (l *Limiter) ExceedsLimit(identifier string) bool {
-	limitStr := os.Getenv("HTTP_RATE_LIMIT")
-	if limitStr == "" {
-		log.Panic("HTTP_RATE_LIMIT is not set")
+// Make sure the identifier is unique for each client.
+func (l *Limiter) Allow(ctx context.Context, identifier string) (Result, error) {
+	now := l.now()
+	result := Result{Limit: l.limit}
+	switch l.algorithm {
+	case FixedWindow:
+		// Windows are aligned to the Unix epoch, so that every instance sharing a store counts in the same window
+		start := now.Truncate(l.window)
+		count, err := l.store.Increment(ctx, identifier, start, start.Add(l.window))
+		if err != nil {
+			return result, err
+		}
+		result.Allowed = count <= l.limit
+		result.Remaining = l.limit - count
+		result.ResetAt = start.Add(l.window)
+	case SlidingWindow:
+		count, oldest, recorded, err := l.store.AppendLog(ctx, identifier, l.limit, l.window, now)
+		if err != nil {
+			return result, err
+		}
+		result.Allowed = recorded
+		result.Remaining = l.limit - count
+		result.ResetAt = oldest.Add(l.window)
+	case TokenBucket:
+		rate := float64(l.limit) / l.window.Seconds()
+		tokens, taken, err := l.store.TakeToken(ctx, identifier, l.limit, rate, now)
+		if err != nil {
+			return result, err
+		}
+		result.Allowed = taken
+		result.Remaining = int(math.Floor(tokens))
+		next := (math.Floor(tokens) + 1 - tokens) / rate
+		result.ResetAt = now.Add(time.Duration(next * float64(time.Second)))
+	}
+	if result.Remaining < 0 {
+		result.Remaining = 0
 	}
-	limit, _ := strconv.Atoi(limitStr)
-	return l.UsageMap[identifier] > limit
+	return result, nil
 }
 
-// Increment increments the request count for a client.
-// The identifier parameter represents the client identifier (e.g. IP address, user ID, etc.).
-// Make sure the identifier is unique for each client.
-func (l *Limiter) Increment(identifier string) {
-	l.UsageMap[identifier]++
+// Size returns the number of clients tracked by the limiter.
+func (l *Limiter) Size(ctx context.Context) (int, error) {
+	return l.store.Size(ctx, l.now())
 }
 
-// Initialize initializes the limiter by setting a periodic task to reset
-// the usage map after a certain time window.
-// The time window and rate limit are read from the environment variables
-// `HTTP_RATE_LIMIT_WINDOW` and `HTTP_RATE_LIMIT` respectively.
+// Initialize initializes the limiter by setting a periodic task to remove
+// the expired state of the clients from the store, every window.
 func (l *Limiter) Initialize() {
-	windowStr := os.Getenv("HTTP_RATE_LIMIT_WINDOW")
-	if windowStr == "" {
-		log.Fatal("HTTP_RATE_LIMIT_WINDOW is not set")
-	}
-	window, err := strconv.Atoi(windowStr)
-	if err != nil {
-		log.Fatal(err)
-	}
 	go func() {
-		for {
-			time.Sleep(time.Duration(window) * time.Second)
-			l.UsageMap = make(map[string]int)
+		ticker := time.NewTicker(l.window)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if err := l.store.Cleanup(context.Background(), now); err != nil {
+				logger.GetLogger().Error("Error removing expired rate limit state", "error", err)
+			}
 		}
 	}()
 }
@@ -92,10 +164,44 @@ func (l *Limiter) Initialize() {
 // l is the shared singleton instance of the Limiter.
 var l *Limiter
 
+// envPositiveInt reads a positive integer from the given environment variable.
+func envPositiveInt(name string) int {
+	value := os.Getenv(name)
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		logger.GetLogger().Fatal("Error parsing rate limiter configuration", "name", name, "value", value)
+	}
+	return n
+}
+
 // GetLimiter returns the shared singleton instance of the Limiter.
+// It is configured with the HTTP_RATE_LIMIT, HTTP_RATE_LIMIT_WINDOW (seconds),
+// HTTP_RATE_LIMIT_ALGORITHM (`fixed_window`, the default, `sliding_window` or `token_bucket`)
+// and HTTP_RATE_LIMIT_STORE (`memory`, the default, or `postgres`) environment variables.
 func GetLimiter() *Limiter {
 	if l == nil {
-		l = NewLimiter()
+		limit := envPositiveInt("HTTP_RATE_LIMIT")
+		window := time.Duration(envPositiveInt("HTTP_RATE_LIMIT_WINDOW")) * time.Second
+
+		var store Store
+		switch backend := os.Getenv("HTTP_RATE_LIMIT_STORE"); backend {
+		case "", "memory":
+			store = NewMemoryStore()
+		case "postgres":
+			store = NewPostgresStore(database.GetDatabase())
+		default:
+			logger.GetLogger().Fatal("Unknown rate limiter store, expected memory or postgres", "store", backend)
+		}
+
+		algorithm := os.Getenv("HTTP_RATE_LIMIT_ALGORITHM")
+		if algorithm == "" {
+			algorithm = FixedWindow
+		}
+		limiter, err := NewLimiter(store, algorithm, limit, window)
+		if err != nil {
+			logger.GetLogger().Fatal("Error configuring rate limiter", "algorithm", algorithm, "error", err)
+		}
+		l = limiter
 	}
 	return l
 }
@@ -1,7 +1,9 @@
 package limiter
 
 import (
+	"context"
 	"os"
+	"sync"
 	"testing"
 	"time"
 )
@@ -9,6 +11,7 @@ import (
 func setup() {
 	os.Setenv("HTTP_RATE_LIMIT", "2")
 	os.Setenv("HTTP_RATE_LIMIT_WINDOW", "1")
+	os.Setenv("LOGGER_DISABLED", "true")
 }
 
 func teardown() {
@@ -16,77 +19,157 @@ func teardown() {
 	os.Unsetenv("HTTP_RATE_LIMIT_WINDOW")
 }
 
-func TestExceedsLimit(t *testing.T) {
-	setup()
-	defer teardown()
-
-	os.Setenv("HTTP_RATE_LIMIT", "2")
-	l := NewLimiter()
-	l.Initialize()
+// newTestLimiter returns a limiter of 2 requests per minute with a clock set by the test.
+func newTestLimiter(t *testing.T, algorithm string) (*Limiter, *time.Time) {
+	l, err := NewLimiter(NewMemoryStore(), algorithm, 2, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	l.now = func() time.Time { return clock }
+	return l, &clock
+}
 
-	// Test with a client that has not exceeded the limit
-	l.Increment("client1")
-	if l.ExceedsLimit("client1") {
-		t.Errorf("ExceedsLimit returned true for client1, want false")
+// allowed returns whether the limiter allows a request of the client.
+func allowed(t *testing.T, l *Limiter, client string) bool {
+	result, err := l.Allow(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
 	}
+	return result.Allowed
+}
 
-	// Test with a client that has exceeded the limit
-	l.Increment("client2")
-	l.Increment("client2")
-	l.Increment("client2")
-	if !l.ExceedsLimit("client2") {
-		t.Errorf("ExceedsLimit returned false for client2, want true")
+func TestNewLimiter(t *testing.T) {
+	if _, err := NewLimiter(NewMemoryStore(), "leaky_bucket", 2, time.Minute); err != ErrUnknownAlgorithm {
+		t.Errorf("NewLimiter returned %v for an unknown algorithm, want ErrUnknownAlgorithm", err)
 	}
+}
 
-	// Test with a client that has not made any requests
-	if l.ExceedsLimit("client3") {
-		t.Errorf("ExceedsLimit returned true for client3, want false")
+func TestAllow(t *testing.T) {
+	for _, algorithm := range []string{FixedWindow, SlidingWindow, TokenBucket} {
+		t.Run(algorithm, func(t *testing.T) {
+			l, _ := newTestLimiter(t, algorithm)
+
+			// Test with a client that has not exceeded the limit
+			result, _ := l.Allow(context.Background(), "client1")
+			if !result.Allowed || result.Limit != 2 || result.Remaining != 1 {
+				t.Errorf("Allow returned %+v for the first request of client1, want allowed with 1 remaining", result)
+			}
+			if !allowed(t, l, "client1") {
+				t.Errorf("Allow returned false for the second request of client1, want true")
+			}
+
+			// Test with a client that has exceeded the limit
+			result, _ = l.Allow(context.Background(), "client1")
+			if result.Allowed || result.Remaining != 0 || !result.ResetAt.After(l.now()) {
+				t.Errorf("Allow returned %+v for the third request of client1, want refused with a reset time", result)
+			}
+
+			// Test with another client
+			if !allowed(t, l, "client2") {
+				t.Errorf("Allow returned false for client2, want true")
+			}
+			if size, _ := l.Size(context.Background()); size != 2 {
+				t.Errorf("Size returned %d, want 2", size)
+			}
+		})
 	}
+}
 
-	// Should throw Fatal log message if HTTP_RATE_LIMIT is not set
-	os.Unsetenv("HTTP_RATE_LIMIT")
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("ExceedsLimit did not panic when HTTP_RATE_LIMIT is not set")
+func TestAllowAcrossWindows(t *testing.T) {
+	// Two requests at the end of a window and two at the start of the next one
+	burst := func(l *Limiter, clock *time.Time) int {
+		*clock = time.Date(2024, 1, 1, 12, 0, 59, 0, time.UTC)
+		count := 0
+		for i := 0; i < 2; i++ {
+			if allowed(t, l, "client1") {
+				count++
+			}
 		}
-	}()
-
-	l.ExceedsLimit("client1")
-}
+		*clock = clock.Add(2 * time.Second)
+		for i := 0; i < 2; i++ {
+			if allowed(t, l, "client1") {
+				count++
+			}
+		}
+		return count
+	}
 
-func TestIncrement(t *testing.T) {
-	setup()
-	defer teardown()
+	// The fixed window allows twice the limit around the boundary, the other algorithms do not
+	for algorithm, want := range map[string]int{FixedWindow: 4, SlidingWindow: 2, TokenBucket: 2} {
+		l, clock := newTestLimiter(t, algorithm)
+		if got := burst(l, clock); got != want {
+			t.Errorf("%s allowed %d requests around a window boundary, want %d", algorithm, got, want)
+		}
+	}
 
-	l := NewLimiter()
-	l.Initialize()
+	// The sliding window frees a request once the oldest one leaves the window
+	l, clock := newTestLimiter(t, SlidingWindow)
+	allowed(t, l, "client1")
+	*clock = clock.Add(30 * time.Second)
+	allowed(t, l, "client1")
+	*clock = clock.Add(31 * time.Second)
+	if !allowed(t, l, "client1") {
+		t.Errorf("sliding window refused a request after the oldest one left the window")
+	}
+	if allowed(t, l, "client1") {
+		t.Errorf("sliding window allowed more than the limit within a window")
+	}
 
-	// Test incrementing the request count for a client
-	l.Increment("client1")
-	if l.UsageMap["client1"] != 1 {
-		t.Errorf("Increment did not increase the request count for client1")
+	// The token bucket adds a token every window / limit
+	l, clock = newTestLimiter(t, TokenBucket)
+	allowed(t, l, "client1")
+	allowed(t, l, "client1")
+	*clock = clock.Add(29 * time.Second)
+	if allowed(t, l, "client1") {
+		t.Errorf("token bucket allowed a request before a token was added")
+	}
+	*clock = clock.Add(time.Second)
+	if !allowed(t, l, "client1") {
+		t.Errorf("token bucket refused a request after a token was added")
 	}
+}
+
+func TestAllowConcurrently(t *testing.T) {
+	for _, algorithm := range []string{FixedWindow, SlidingWindow, TokenBucket} {
+		l, err := NewLimiter(NewMemoryStore(), algorithm, 50, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	// Test incrementing the request count for a different client
-	l.Increment("client2")
-	if l.UsageMap["client2"] != 1 {
-		t.Errorf("Increment did not increase the request count for client2")
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		count := 0
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if result, _ := l.Allow(context.Background(), "client1"); result.Allowed {
+					mu.Lock()
+					count++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if count != 50 {
+			t.Errorf("%s allowed %d concurrent requests, want 50", algorithm, count)
+		}
 	}
 }
 
 func TestInitialize(t *testing.T) {
-	setup()
-	defer teardown()
-
-	os.Setenv("HTTP_RATE_LIMIT_WINDOW", "1")
-	l := NewLimiter()
+	store := NewMemoryStore()
+	l, _ := NewLimiter(store, TokenBucket, 2, 100*time.Millisecond)
 	l.Initialize()
 
-	// Test that the usage map is reset after the time window
-	l.Increment("client1")
-	time.Sleep(2 * time.Second)
-	if l.UsageMap["client1"] != 0 {
-		t.Errorf("Initialize did not reset the usage map after the time window")
+	// Test that the state of a client is removed once its bucket is full again
+	l.Allow(context.Background(), "client1")
+	time.Sleep(300 * time.Millisecond)
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.buckets) != 0 {
+		t.Errorf("Initialize did not remove the expired state of the clients")
 	}
 }
 
@@ -100,4 +183,8 @@ func TestGetLimiter(t *testing.T) {
 	if l1 != l2 {
 		t.Errorf("GetLimiter did not return the shared singleton instance of the Limiter")
 	}
+	if l1.limit != 2 || l1.window != time.Second || l1.algorithm != FixedWindow {
+		t.Errorf("GetLimiter returned a limiter with limit %d, window %s and algorithm %s, want 2, 1s and fixed_window",
+			l1.limit, l1.window, l1.algorithm)
+	}
 }
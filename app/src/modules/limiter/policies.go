@@ -0,0 +1,179 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/clientip"
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+)
+
+// ErrInvalidPolicy is returned when parsing a rate limit policy that is not of the form `<requests>/<seconds>`.
+var ErrInvalidPolicy = errors.New("invalid rate limit policy, expected <requests>/<seconds>")
+
+// Policy is a rate limit of Limit requests per Window.
+type Policy struct {
+	Limit  int
+	Window time.Duration
+}
+
+// ParsePolicy parses a rate limit policy of the form `<requests>/<seconds>`, e.g. `10/60` for 10 requests per minute.
+func ParsePolicy(value string) (Policy, error) {
+	requests, seconds, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return Policy{}, ErrInvalidPolicy
+	}
+	limit, err := strconv.Atoi(requests)
+	if err != nil || limit < 1 {
+		return Policy{}, ErrInvalidPolicy
+	}
+	window, err := strconv.Atoi(seconds)
+	if err != nil || window < 1 {
+		return Policy{}, ErrInvalidPolicy
+	}
+	return Policy{Limit: limit, Window: time.Duration(window) * time.Second}, nil
+}
+
+// Policies applies rate limits by route and by tier on top of a default Limiter.
+//
+// A route policy limits the requests of each client to a named route, e.g. `tasks.create`, in addition to its
+// tier or default limit. A tier policy replaces the default limit for the clients of a tier,
+// e.g. the users with the `admin` role or the clients authenticated with an API key.
+// Clients from an allowlisted address are not limited.
+type Policies struct {
+	fallback  *Limiter
+	routes    map[string]*Limiter
+	tiers     map[string]*Limiter
+	allowlist []*net.IPNet
+}
+
+// NewPolicies returns new Policies limiting every client with the given default limiter.
+// The limiters of the route and tier policies share its store and algorithm.
+func NewPolicies(fallback *Limiter) *Policies {
+	return &Policies{
+		fallback: fallback,
+		routes:   map[string]*Limiter{},
+		tiers:    map[string]*Limiter{},
+	}
+}
+
+// routeKey normalizes a route name the way it is written in the configuration, e.g. `tasks.create` to `TASKS_CREATE`.
+func routeKey(route string) string {
+	return strings.ToUpper(strings.ReplaceAll(route, ".", "_"))
+}
+
+// limiter returns a limiter for the given policy sharing the store, algorithm and clock of the default limiter.
+func (p *Policies) limiter(policy Policy) (*Limiter, error) {
+	l, err := NewLimiter(p.fallback.store, p.fallback.algorithm, policy.Limit, policy.Window)
+	if err != nil {
+		return nil, err
+	}
+	l.now = p.fallback.now
+	return l, nil
+}
+
+// SetRoute sets the policy of the named route.
+func (p *Policies) SetRoute(route string, policy Policy) error {
+	l, err := p.limiter(policy)
+	if err != nil {
+		return err
+	}
+	p.routes[routeKey(route)] = l
+	return nil
+}
+
+// SetTier sets the policy of the given tier.
+func (p *Policies) SetTier(tier string, policy Policy) error {
+	l, err := p.limiter(policy)
+	if err != nil {
+		return err
+	}
+	p.tiers[strings.ToLower(tier)] = l
+	return nil
+}
+
+// Allowlist adds IP addresses or CIDR ranges, e.g. `10.0.0.0/8`, to the addresses that are not limited.
+func (p *Policies) Allowlist(addrs ...string) error {
+	networks, err := clientip.ParseNetworks(addrs...)
+	if err != nil {
+		return err
+	}
+	p.allowlist = append(p.allowlist, networks...)
+	return nil
+}
+
+// IsAllowlisted reports whether the given address, with or without a port, is allowlisted.
+func (p *Policies) IsAllowlisted(addr string) bool {
+	return clientip.Contains(p.allowlist, addr)
+}
+
+// Allow records a request made by a client to the named route and reports whether it is within the rate limits.
+// The request is checked against the policy of the route, if any, then against the policy of the first
+// of the given tiers that has one, or the default limiter. The most restrictive result is returned,
+// and a request refused by the route policy does not count against the other limits.
+func (p *Policies) Allow(ctx context.Context, client, route string, tiers ...string) (Result, error) {
+	var result Result
+	var limited bool
+	if l, ok := p.routes[routeKey(route)]; ok && route != "" {
+		r, err := l.Allow(ctx, "route:"+routeKey(route)+":"+client)
+		if err != nil || !r.Allowed {
+			return r, err
+		}
+		result, limited = r, true
+	}
+
+	l, identifier := p.fallback, client
+	for _, tier := range tiers {
+		if tl, ok := p.tiers[strings.ToLower(tier)]; ok {
+			l, identifier = tl, "tier:"+strings.ToLower(tier)+":"+client
+			break
+		}
+	}
+	r, err := l.Allow(ctx, identifier)
+	if err != nil || !r.Allowed || !limited || r.Remaining < result.Remaining {
+		return r, err
+	}
+	return result, nil
+}
+
+// ps is the shared singleton instance of the Policies.
+var ps *Policies
+
+// GetPolicies returns the shared singleton instance of the Policies, built on top of GetLimiter.
+// Route policies are read from the RATE_LIMITS_<ROUTE> environment variables, where <ROUTE> is the route
+// name in upper case with dots replaced by underscores, and tier policies from the RATE_LIMIT_TIERS_<TIER>
+// environment variables, both of the form `<requests>/<seconds>`. The HTTP_RATE_LIMIT_ALLOWLIST environment
+// variable is a comma separated list of IP addresses and CIDR ranges that are not limited.
+func GetPolicies() *Policies {
+	if ps == nil {
+		policies := NewPolicies(GetLimiter())
+		for _, env := range os.Environ() {
+			name, value, _ := strings.Cut(env, "=")
+			var err error
+			switch {
+			case strings.HasPrefix(name, "RATE_LIMITS_"):
+				var policy Policy
+				if policy, err = ParsePolicy(value); err == nil {
+					err = policies.SetRoute(strings.TrimPrefix(name, "RATE_LIMITS_"), policy)
+				}
+			case strings.HasPrefix(name, "RATE_LIMIT_TIERS_"):
+				var policy Policy
+				if policy, err = ParsePolicy(value); err == nil {
+					err = policies.SetTier(strings.TrimPrefix(name, "RATE_LIMIT_TIERS_"), policy)
+				}
+			case name == "HTTP_RATE_LIMIT_ALLOWLIST":
+				err = policies.Allowlist(strings.Split(value, ",")...)
+			}
+			if err != nil {
+				logger.GetLogger().Fatal("Error parsing rate limit policy", "name", name, "value", value, "error", err)
+			}
+		}
+		ps = policies
+	}
+	return ps
+}
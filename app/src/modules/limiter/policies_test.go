@@ -0,0 +1,127 @@
+package limiter
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy(" 10/60 ")
+	if err != nil || policy.Limit != 10 || policy.Window != time.Minute {
+		t.Errorf("ParsePolicy returned %+v, %v for 10/60, want 10 requests per minute", policy, err)
+	}
+	for _, value := range []string{"", "10", "10/", "/60", "0/60", "10/0", "ten/60", "10/-1"} {
+		if _, err := ParsePolicy(value); err != ErrInvalidPolicy {
+			t.Errorf("ParsePolicy returned %v for %q, want ErrInvalidPolicy", err, value)
+		}
+	}
+}
+
+// newTestPolicies returns policies with a default limit of 2 requests per minute, a limit of 1 request per minute
+// on the tasks.create route and a limit of 4 requests per minute for the admin tier.
+func newTestPolicies(t *testing.T) *Policies {
+	fallback, _ := newTestLimiter(t, FixedWindow)
+	p := NewPolicies(fallback)
+	if err := p.SetRoute("tasks.create", Policy{Limit: 1, Window: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetTier("admin", Policy{Limit: 4, Window: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// allowedBy returns whether the policies allow a request of the client to the route.
+func allowedBy(t *testing.T, p *Policies, client, route string, tiers ...string) Result {
+	result, err := p.Allow(context.Background(), client, route, tiers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestPoliciesAllow(t *testing.T) {
+	p := newTestPolicies(t)
+
+	// The route policy applies in addition to the default limit and is reported when more restrictive
+	if result := allowedBy(t, p, "client1", "tasks.create"); !result.Allowed || result.Limit != 1 || result.Remaining != 0 {
+		t.Errorf("Allow returned %+v for the first request to tasks.create, want allowed by the route policy", result)
+	}
+	if result := allowedBy(t, p, "client1", "tasks.create"); result.Allowed || result.Limit != 1 {
+		t.Errorf("Allow returned %+v for the second request to tasks.create, want refused by the route policy", result)
+	}
+	// The refused request did not count against the default limit
+	if result := allowedBy(t, p, "client1", "tasks.list"); !result.Allowed || result.Limit != 2 || result.Remaining != 0 {
+		t.Errorf("Allow returned %+v for a request to tasks.list, want allowed by the default limit with 0 remaining", result)
+	}
+	if result := allowedBy(t, p, "client1", ""); result.Allowed {
+		t.Errorf("Allow returned %+v once the default limit was exhausted, want refused", result)
+	}
+
+	// The first tier with a policy replaces the default limit
+	for i := 0; i < 4; i++ {
+		if result := allowedBy(t, p, "client2", "tasks.list", "api_key", "admin"); !result.Allowed || result.Limit != 4 {
+			t.Errorf("Allow returned %+v for request %d of an admin, want allowed by the admin tier", result, i+1)
+		}
+	}
+	if result := allowedBy(t, p, "client2", "tasks.list", "admin"); result.Allowed {
+		t.Errorf("Allow returned %+v once the admin tier limit was exhausted, want refused", result)
+	}
+	// Tiers without a policy fall back to the default limit
+	if result := allowedBy(t, p, "client2", "tasks.list", "user"); !result.Allowed || result.Limit != 2 {
+		t.Errorf("Allow returned %+v for a tier without a policy, want allowed by the default limit", result)
+	}
+}
+
+func TestPoliciesAllowlist(t *testing.T) {
+	p := newTestPolicies(t)
+	if err := p.Allowlist("10.0.0.0/8", " 192.0.2.1", "::1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Allowlist("not an address"); err == nil {
+		t.Errorf("Allowlist returned no error for an invalid address")
+	}
+
+	for _, addr := range []string{"10.1.2.3", "10.1.2.3:443", "192.0.2.1", "[::1]:8080"} {
+		if !p.IsAllowlisted(addr) {
+			t.Errorf("IsAllowlisted returned false for %s, want true", addr)
+		}
+	}
+	for _, addr := range []string{"192.0.2.2", "11.0.0.1:443", "", "localhost"} {
+		if p.IsAllowlisted(addr) {
+			t.Errorf("IsAllowlisted returned true for %s, want false", addr)
+		}
+	}
+}
+
+func TestGetPolicies(t *testing.T) {
+	setup()
+	defer teardown()
+	os.Setenv("RATE_LIMITS_TASKS_CREATE", "1/60")
+	os.Setenv("RATE_LIMIT_TIERS_API_KEY", "5/1")
+	os.Setenv("HTTP_RATE_LIMIT_ALLOWLIST", "127.0.0.1,10.0.0.0/8")
+	defer os.Unsetenv("RATE_LIMITS_TASKS_CREATE")
+	defer os.Unsetenv("RATE_LIMIT_TIERS_API_KEY")
+	defer os.Unsetenv("HTTP_RATE_LIMIT_ALLOWLIST")
+
+	p1 := GetPolicies()
+	p2 := GetPolicies()
+
+	if p1 != p2 {
+		t.Errorf("GetPolicies did not return the shared singleton instance of the Policies")
+	}
+	if p1.fallback != GetLimiter() {
+		t.Errorf("GetPolicies did not use the shared Limiter as the default limit")
+	}
+	if l, ok := p1.routes["TASKS_CREATE"]; !ok || l.limit != 1 || l.window != time.Minute {
+		t.Errorf("GetPolicies did not read the policy of the tasks.create route")
+	}
+	if l, ok := p1.tiers["api_key"]; !ok || l.limit != 5 || l.window != time.Second {
+		t.Errorf("GetPolicies did not read the policy of the api_key tier")
+	}
+	if !p1.IsAllowlisted("127.0.0.1") || !p1.IsAllowlisted("10.0.0.1") {
+		t.Errorf("GetPolicies did not read the allowlist")
+	}
+}
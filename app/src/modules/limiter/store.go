@@ -0,0 +1,242 @@
+package limiter
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+)
+
+// Store keeps the state of the rate limited clients of a Limiter, with one operation per algorithm.
+// Each operation must be atomic, so that a store can be shared by concurrent requests and by several
+// instances of the application. State is kept per key and removed by Cleanup once it expired.
+type Store interface {
+	// Increment adds a request to the counter of the key in the fixed window starting at windowStart,
+	// and returns the new count. The counter expires at expiresAt.
+	Increment(ctx context.Context, key string, windowStart time.Time, expiresAt time.Time) (int, error)
+	// TakeToken takes a token from the bucket of the key, which holds up to capacity tokens and is refilled
+	// with rate tokens per second, starting full. It returns the tokens left and true, or false if the bucket
+	// holds less than a token, in which case no token is taken.
+	TakeToken(ctx context.Context, key string, capacity int, rate float64, now time.Time) (float64, bool, error)
+	// AppendLog records a request of the key at now, unless limit requests are already recorded within
+	// the window before now. It returns the number of requests recorded within the window, the time
+	// of the oldest one, and whether the request was recorded.
+	AppendLog(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (int, time.Time, bool, error)
+	// Size returns the number of keys with a state that has not expired at now.
+	Size(ctx context.Context, now time.Time) (int, error)
+	// Cleanup removes the state and the bans that expired before now.
+	Cleanup(ctx context.Context, now time.Time) error
+
+	// AddBan bans an address, replacing its current ban if any.
+	AddBan(ctx context.Context, ban models.Ban) error
+	// GetBan returns the ban of the address that has not expired at now, and false if it is not banned.
+	GetBan(ctx context.Context, address string, now time.Time) (models.Ban, bool, error)
+	// RemoveBan lifts the ban of the address, and returns false if it was not banned.
+	RemoveBan(ctx context.Context, address string) (bool, error)
+	// ListBans returns the bans that have not expired at now, the latest expiring first.
+	ListBans(ctx context.Context, now time.Time) ([]models.Ban, error)
+}
+
+// windowCounter is a fixed window counter of a MemoryStore.
+type windowCounter struct {
+	windowStart time.Time
+	count       int
+	expiresAt   time.Time
+}
+
+// bucket is a token bucket of a MemoryStore. Tokens are refilled lazily, when one is taken.
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time // the bucket is full again, and equivalent to no bucket, past this time
+}
+
+// requestLog is a sliding window log of a MemoryStore, oldest request first.
+type requestLog struct {
+	times     []time.Time
+	expiresAt time.Time
+}
+
+// MemoryStore is a Store that keeps the state in process memory.
+// Every instance of the application counts on its own, so it is only suited to a single instance.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*windowCounter
+	buckets  map[string]*bucket
+	logs     map[string]*requestLog
+	bans     map[string]models.Ban
+}
+
+// NewMemoryStore creates a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]*windowCounter),
+		buckets:  make(map[string]*bucket),
+		logs:     make(map[string]*requestLog),
+		bans:     make(map[string]models.Ban),
+	}
+}
+
+// Increment adds a request to the counter of the key in the window and returns the new count.
+func (s *MemoryStore) Increment(ctx context.Context, key string, windowStart time.Time, expiresAt time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counter, ok := s.counters[key]
+	if !ok || !counter.windowStart.Equal(windowStart) {
+		counter = &windowCounter{windowStart: windowStart, expiresAt: expiresAt}
+		s.counters[key] = counter
+	}
+	counter.count++
+	return counter.count, nil
+}
+
+// TakeToken takes a token from the bucket of the key and returns the tokens left, or false if it is empty.
+func (s *MemoryStore) TakeToken(ctx context.Context, key string, capacity int, rate float64, now time.Time) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(capacity), updatedAt: now}
+		s.buckets[key] = b
+	}
+	tokens := math.Min(float64(capacity), b.tokens+now.Sub(b.updatedAt).Seconds()*rate)
+	if tokens < 1 {
+		return tokens, false, nil
+	}
+	b.tokens = tokens - 1
+	b.updatedAt = now
+	b.expiresAt = now.Add(time.Duration((float64(capacity) - b.tokens) / rate * float64(time.Second)))
+	return b.tokens, true, nil
+}
+
+// AppendLog records a request of the key at now unless the window is full.
+func (s *MemoryStore) AppendLog(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (int, time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log, ok := s.logs[key]
+	if !ok {
+		log = &requestLog{}
+		s.logs[key] = log
+	}
+	// Forget the requests that left the window
+	start := now.Add(-window)
+	i := 0
+	for i < len(log.times) && !log.times[i].After(start) {
+		i++
+	}
+	log.times = log.times[i:]
+
+	recorded := len(log.times) < limit
+	if recorded {
+		log.times = append(log.times, now)
+		log.expiresAt = now.Add(window)
+	}
+	if len(log.times) == 0 {
+		return 0, now, recorded, nil
+	}
+	return len(log.times), log.times[0], recorded, nil
+}
+
+// Size returns the number of keys with a state that has not expired at now.
+func (s *MemoryStore) Size(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := 0
+	for _, counter := range s.counters {
+		if !counter.expiresAt.Before(now) {
+			size++
+		}
+	}
+	for _, b := range s.buckets {
+		if !b.expiresAt.Before(now) {
+			size++
+		}
+	}
+	for _, log := range s.logs {
+		if !log.expiresAt.Before(now) {
+			size++
+		}
+	}
+	return size, nil
+}
+
+// Cleanup removes the state that expired before now.
+func (s *MemoryStore) Cleanup(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, counter := range s.counters {
+		if counter.expiresAt.Before(now) {
+			delete(s.counters, key)
+		}
+	}
+	for key, b := range s.buckets {
+		if b.expiresAt.Before(now) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, log := range s.logs {
+		if log.expiresAt.Before(now) {
+			delete(s.logs, key)
+		}
+	}
+	for address, ban := range s.bans {
+		if ban.ExpiresAt.Before(now) {
+			delete(s.bans, address)
+		}
+	}
+	return nil
+}
+
+// AddBan bans an address, replacing its current ban if any.
+func (s *MemoryStore) AddBan(ctx context.Context, ban models.Ban) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bans[ban.Address] = ban
+	return nil
+}
+
+// GetBan returns the ban of the address that has not expired at now, and false if it is not banned.
+func (s *MemoryStore) GetBan(ctx context.Context, address string, now time.Time) (models.Ban, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ban, ok := s.bans[address]
+	if !ok || ban.ExpiresAt.Before(now) {
+		return models.Ban{}, false, nil
+	}
+	return ban, true, nil
+}
+
+// RemoveBan lifts the ban of the address, and returns false if it was not banned.
+func (s *MemoryStore) RemoveBan(ctx context.Context, address string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.bans[address]
+	delete(s.bans, address)
+	return ok, nil
+}
+
+// ListBans returns the bans that have not expired at now, the latest expiring first.
+func (s *MemoryStore) ListBans(ctx context.Context, now time.Time) ([]models.Ban, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bans := []models.Ban{}
+	for _, ban := range s.bans {
+		if !ban.ExpiresAt.Before(now) {
+			bans = append(bans, ban)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].ExpiresAt.After(bans[j].ExpiresAt) })
+	return bans, nil
+}
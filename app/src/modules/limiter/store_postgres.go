@@ -0,0 +1,181 @@
+package limiter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+)
+
+// PostgresStore is a Store that keeps the state in the rate_limits, rate_limit_buckets and rate_limit_log
+// tables of a PostgreSQL database, and the bans in the rate_limit_bans table, so that every instance of the application connected to it shares the same limits.
+// Counters and buckets are updated with a single upsert, which is atomic across concurrent requests.
+// Sliding window logs are updated in a transaction holding an advisory lock on the key.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore creates a new PostgresStore using the given database connection.
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Increment adds a request to the counter of the key in the window and returns the new count.
+func (s *PostgresStore) Increment(ctx context.Context, key string, windowStart time.Time, expiresAt time.Time) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx,
+		"INSERT INTO rate_limits (key, window_start, count, expires_at) VALUES ($1, $2, 1, $3) "+
+			"ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limits.count + 1 RETURNING count",
+		key, windowStart, expiresAt,
+	).Scan(&count)
+	return count, err
+}
+
+// TakeToken takes a token from the bucket of the key and returns the tokens left, or false if it is empty.
+// An empty bucket is left untouched, so no row is returned, and the tokens it holds are not known: 0 is returned.
+func (s *PostgresStore) TakeToken(ctx context.Context, key string, capacity int, rate float64, now time.Time) (float64, bool, error) {
+	// The bucket is refilled lazily with the tokens earned since it was last updated
+	const refilled = "LEAST($2::double precision, b.tokens + EXTRACT(EPOCH FROM $3::timestamptz - b.updated_at) * $4::double precision)"
+	var tokens float64
+	err := s.db.QueryRowContext(ctx,
+		"INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at, expires_at) VALUES ($1, $2::double precision - 1, $3, $5) "+
+			"ON CONFLICT (key) DO UPDATE SET tokens = "+refilled+" - 1, updated_at = $3, expires_at = $5 "+
+			"WHERE "+refilled+" >= 1 RETURNING tokens",
+		key, capacity, now, rate, now.Add(time.Duration(float64(capacity)/rate*float64(time.Second))),
+	).Scan(&tokens)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return tokens, true, nil
+}
+
+// AppendLog records a request of the key at now unless the window is full.
+func (s *PostgresStore) AppendLog(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (int, time.Time, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, now, false, err
+	}
+	defer tx.Rollback()
+
+	// Serialize the requests of the key, counting and inserting is not atomic otherwise
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", key); err != nil {
+		return 0, now, false, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM rate_limit_log WHERE key = $1 AND requested_at <= $2", key, now.Add(-window)); err != nil {
+		return 0, now, false, err
+	}
+	var count int
+	var oldest sql.NullTime
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*), MIN(requested_at) FROM rate_limit_log WHERE key = $1", key).Scan(&count, &oldest)
+	if err != nil {
+		return 0, now, false, err
+	}
+
+	recorded := count < limit
+	if recorded {
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO rate_limit_log (key, requested_at, expires_at) VALUES ($1, $2, $3)",
+			key, now, now.Add(window),
+		)
+		if err != nil {
+			return 0, now, false, err
+		}
+		count++
+		if !oldest.Valid {
+			oldest = sql.NullTime{Time: now, Valid: true}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, now, false, err
+	}
+	if !oldest.Valid {
+		oldest.Time = now
+	}
+	return count, oldest.Time, recorded, nil
+}
+
+// Size returns the number of keys with a state that has not expired at now.
+func (s *PostgresStore) Size(ctx context.Context, now time.Time) (int, error) {
+	var size int
+	err := s.db.QueryRowContext(ctx,
+		"SELECT (SELECT COUNT(DISTINCT key) FROM rate_limits WHERE expires_at >= $1) + "+
+			"(SELECT COUNT(*) FROM rate_limit_buckets WHERE expires_at >= $1) + "+
+			"(SELECT COUNT(DISTINCT key) FROM rate_limit_log WHERE expires_at >= $1)",
+		now,
+	).Scan(&size)
+	return size, err
+}
+
+// Cleanup removes the state and the bans that expired before now.
+func (s *PostgresStore) Cleanup(ctx context.Context, now time.Time) error {
+	var errs []error
+	for _, table := range []string{"rate_limits", "rate_limit_buckets", "rate_limit_log", "rate_limit_bans"} {
+		if _, err := s.db.ExecContext(ctx, "DELETE FROM "+table+" WHERE expires_at < $1", now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AddBan bans an address, replacing its current ban if any.
+func (s *PostgresStore) AddBan(ctx context.Context, ban models.Ban) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO rate_limit_bans (address, reason, created_at, expires_at) VALUES ($1, $2, $3, $4) "+
+			"ON CONFLICT (address) DO UPDATE SET reason = $2, created_at = $3, expires_at = $4",
+		ban.Address, ban.Reason, ban.CreatedAt, ban.ExpiresAt,
+	)
+	return err
+}
+
+// GetBan returns the ban of the address that has not expired at now, and false if it is not banned.
+func (s *PostgresStore) GetBan(ctx context.Context, address string, now time.Time) (models.Ban, bool, error) {
+	var ban models.Ban
+	err := s.db.QueryRowContext(ctx,
+		"SELECT address, reason, created_at, expires_at FROM rate_limit_bans WHERE address = $1 AND expires_at >= $2",
+		address, now,
+	).Scan(&ban.Address, &ban.Reason, &ban.CreatedAt, &ban.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Ban{}, false, nil
+	}
+	if err != nil {
+		return models.Ban{}, false, err
+	}
+	return ban, true, nil
+}
+
+// RemoveBan lifts the ban of the address, and returns false if it was not banned.
+func (s *PostgresStore) RemoveBan(ctx context.Context, address string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM rate_limit_bans WHERE address = $1", address)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ListBans returns the bans that have not expired at now, the latest expiring first.
+func (s *PostgresStore) ListBans(ctx context.Context, now time.Time) ([]models.Ban, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT address, reason, created_at, expires_at FROM rate_limit_bans WHERE expires_at >= $1 ORDER BY expires_at DESC",
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bans := []models.Ban{}
+	for rows.Next() {
+		var ban models.Ban
+		if err := rows.Scan(&ban.Address, &ban.Reason, &ban.CreatedAt, &ban.ExpiresAt); err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+	return bans, rows.Err()
+}
@@ -0,0 +1,187 @@
+package limiter
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	next := start.Add(time.Minute)
+
+	// Fixed window counters start over in a new window
+	count, _ := store.Increment(ctx, "client1", start, next)
+	assert.Equal(t, 1, count)
+	count, _ = store.Increment(ctx, "client1", start, next)
+	assert.Equal(t, 2, count)
+	count, _ = store.Increment(ctx, "client1", next, next.Add(time.Minute))
+	assert.Equal(t, 1, count)
+
+	// Buckets start full and are refilled over time
+	tokens, taken, _ := store.TakeToken(ctx, "client2", 2, 1, start)
+	assert.True(t, taken)
+	assert.Equal(t, 1.0, tokens)
+	store.TakeToken(ctx, "client2", 2, 1, start)
+	tokens, taken, _ = store.TakeToken(ctx, "client2", 2, 1, start.Add(500*time.Millisecond))
+	assert.False(t, taken)
+	assert.Equal(t, 0.5, tokens)
+	tokens, taken, _ = store.TakeToken(ctx, "client2", 2, 1, start.Add(time.Second))
+	assert.True(t, taken)
+	assert.Equal(t, 0.0, tokens)
+
+	// Logs only keep the requests within the window
+	count, oldest, recorded, _ := store.AppendLog(ctx, "client3", 2, time.Minute, start)
+	assert.True(t, recorded)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, start, oldest)
+	store.AppendLog(ctx, "client3", 2, time.Minute, start.Add(time.Second))
+	_, _, recorded, _ = store.AppendLog(ctx, "client3", 2, time.Minute, start.Add(2*time.Second))
+	assert.False(t, recorded)
+	count, oldest, recorded, _ = store.AppendLog(ctx, "client3", 2, time.Minute, next)
+	assert.True(t, recorded)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, start.Add(time.Second), oldest)
+
+	size, _ := store.Size(ctx, start.Add(2*time.Second))
+	assert.Equal(t, 3, size)
+
+	// Bans are replaced, listed until they expire, and removed
+	assert.NoError(t, store.AddBan(ctx, models.Ban{Address: "192.0.2.1", Reason: "rate_limit", CreatedAt: start, ExpiresAt: next}))
+	assert.NoError(t, store.AddBan(ctx, models.Ban{Address: "192.0.2.2", Reason: "rate_limit", CreatedAt: start, ExpiresAt: start.Add(time.Second)}))
+	assert.NoError(t, store.AddBan(ctx, models.Ban{Address: "192.0.2.2", Reason: "manual", CreatedAt: start, ExpiresAt: next.Add(time.Hour)}))
+	ban, banned, _ := store.GetBan(ctx, "192.0.2.2", next)
+	assert.True(t, banned)
+	assert.Equal(t, "manual", ban.Reason)
+	_, banned, _ = store.GetBan(ctx, "192.0.2.1", next.Add(time.Second))
+	assert.False(t, banned)
+	bans, _ := store.ListBans(ctx, start)
+	assert.Equal(t, []string{"192.0.2.2", "192.0.2.1"}, []string{bans[0].Address, bans[1].Address})
+	removed, _ := store.RemoveBan(ctx, "192.0.2.2")
+	assert.True(t, removed)
+	removed, _ = store.RemoveBan(ctx, "192.0.2.2")
+	assert.False(t, removed)
+
+	// Only the expired state is removed
+	assert.NoError(t, store.Cleanup(ctx, next.Add(5*time.Second)))
+	assert.Len(t, store.counters, 1)
+	assert.Len(t, store.buckets, 0)
+	assert.Len(t, store.logs, 1)
+	assert.Len(t, store.bans, 0)
+}
+
+func TestPostgresStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewPostgresStore(db)
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	next := start.Add(time.Minute)
+
+	// Increment
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO rate_limits (key, window_start, count, expires_at) VALUES ($1, $2, 1, $3) ON CONFLICT (key, window_start) DO UPDATE")).
+		WithArgs("client1", start, next).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	count, err := store.Increment(ctx, "client1", start, next)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// TakeToken, the bucket is not updated when it is empty
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at, expires_at)")).
+		WithArgs("client1", 2, start, 2.0/60, next).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens"}).AddRow(1.5))
+	tokens, taken, err := store.TakeToken(ctx, "client1", 2, 2.0/60, start)
+	assert.NoError(t, err)
+	assert.True(t, taken)
+	assert.Equal(t, 1.5, tokens)
+
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at, expires_at)")).
+		WithArgs("client1", 2, start, 2.0/60, next).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens"}))
+	_, taken, err = store.TakeToken(ctx, "client1", 2, 2.0/60, start)
+	assert.NoError(t, err)
+	assert.False(t, taken)
+
+	// AppendLog
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock(hashtext($1))")).WithArgs("client1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM rate_limit_log WHERE key = $1 AND requested_at <= $2")).
+		WithArgs("client1", start).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*), MIN(requested_at) FROM rate_limit_log WHERE key = $1")).
+		WithArgs("client1").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "min"}).AddRow(1, start.Add(time.Second)))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO rate_limit_log (key, requested_at, expires_at) VALUES ($1, $2, $3)")).
+		WithArgs("client1", next, next.Add(time.Minute)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	count, oldest, recorded, err := store.AppendLog(ctx, "client1", 2, time.Minute, next)
+	assert.NoError(t, err)
+	assert.True(t, recorded)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, start.Add(time.Second), oldest)
+
+	// Size and Cleanup
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT (SELECT COUNT(DISTINCT key) FROM rate_limits WHERE expires_at >= $1)")).
+		WithArgs(start).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	size, err := store.Size(ctx, start)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, size)
+
+	// Bans
+	ban := models.Ban{Address: "192.0.2.1", Reason: "rate_limit", CreatedAt: start, ExpiresAt: next}
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO rate_limit_bans (address, reason, created_at, expires_at) VALUES ($1, $2, $3, $4) ON CONFLICT (address) DO UPDATE")).
+		WithArgs(ban.Address, ban.Reason, ban.CreatedAt, ban.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, store.AddBan(ctx, ban))
+
+	banColumns := []string{"address", "reason", "created_at", "expires_at"}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT address, reason, created_at, expires_at FROM rate_limit_bans WHERE address = $1 AND expires_at >= $2")).
+		WithArgs("192.0.2.1", start).
+		WillReturnRows(sqlmock.NewRows(banColumns).AddRow(ban.Address, ban.Reason, ban.CreatedAt, ban.ExpiresAt))
+	got, banned, err := store.GetBan(ctx, "192.0.2.1", start)
+	assert.NoError(t, err)
+	assert.True(t, banned)
+	assert.Equal(t, ban, got)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT address, reason, created_at, expires_at FROM rate_limit_bans WHERE address = $1")).
+		WithArgs("192.0.2.2", start).
+		WillReturnRows(sqlmock.NewRows(banColumns))
+	_, banned, err = store.GetBan(ctx, "192.0.2.2", start)
+	assert.NoError(t, err)
+	assert.False(t, banned)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT address, reason, created_at, expires_at FROM rate_limit_bans WHERE expires_at >= $1 ORDER BY expires_at DESC")).
+		WithArgs(start).
+		WillReturnRows(sqlmock.NewRows(banColumns).AddRow(ban.Address, ban.Reason, ban.CreatedAt, ban.ExpiresAt))
+	bans, err := store.ListBans(ctx, start)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Ban{ban}, bans)
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM rate_limit_bans WHERE address = $1")).
+		WithArgs("192.0.2.1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	removed, err := store.RemoveBan(ctx, "192.0.2.1")
+	assert.NoError(t, err)
+	assert.False(t, removed)
+
+	for _, table := range []string{"rate_limits", "rate_limit_buckets", "rate_limit_log", "rate_limit_bans"} {
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM " + table + " WHERE expires_at < $1")).
+			WithArgs(next).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+	}
+	assert.NoError(t, store.Cleanup(ctx, next))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"context"
+)
+
+// requestIDKey is the context key of the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of the context carrying the given request ID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by the context, or an empty string.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextField is a field attached to the lines of the loggers returned by FromContext.
+type contextField struct {
+	key   string
+	value func(ctx context.Context) string
+}
+
+// contextFields are the fields registered with RegisterContextField, in registration order.
+var contextFields []contextField
+
+// RegisterContextField attaches the field with the given key to the lines of the loggers returned by FromContext,
+// with the value returned by the given function for their context, unless it is empty. This lets the packages
+// that own a request value, e.g. the client IP, log it without the logger depending on them.
+// It must be called during initialization, before any logger is returned by FromContext.
+func RegisterContextField(key string, value func(ctx context.Context) string) {
+	contextFields = append(contextFields, contextField{key: key, value: value})
+}
+
+// FromContext returns the singleton logger with the request ID and the registered fields of the context, if any,
+// attached to every line. Request handlers should use it instead of GetLogger.
+func FromContext(ctx context.Context) *_Logger {
+	var fields []interface{}
+	if requestID := RequestID(ctx); requestID != "" {
+		fields = append(fields, "request_id", requestID)
+	}
+	for _, field := range contextFields {
+		if value := field.value(ctx); value != "" {
+			fields = append(fields, field.key, value)
+		}
+	}
+	if len(fields) == 0 {
+		return GetLogger()
+	}
+	return GetLogger().With(fields...)
+}
@@ -1,18 +1,29 @@
-// Package logger provides a leveled logging solution.
-// It supports three levels of logging: INFO, ERROR, and FATAL.
-// Each level has its own logger, and each logger writes to its own output.
+// Package logger provides a leveled, structured logging solution.
+// It supports five levels of logging: DEBUG, INFO, WARN, ERROR, and FATAL.
 // All logs are written to LOG_FILE and console,
 // and error logs are also written to ERROR_LOG_FILE.
 //
+// Log files are rotated when they grow past LOGGER_MAX_SIZE megabytes or every LOGGER_ROTATE_INTERVAL
+// seconds. Rotated files are renamed with a timestamp, gzipped if LOGGER_COMPRESS is true,
+// and removed beyond LOGGER_MAX_BACKUPS files or after LOGGER_MAX_AGE days. `Reopen` reopens the files
+// after an external tool such as logrotate moved them.
+//
+// Every log line has a message and optional key/value fields. Lines are written
+// as text (`2024/01/01 12:00:00 INFO: message key=value`) or, with the `json`
+// format, as one JSON object per line.
+//
 // Usage:
 // Before using the logger, create a new logger by calling the `NewLogger` function,
 // passing in the paths to the log file and error log file, and the desired log level.
-// Then, use the `Info`, `Error`, and `Fatal` methods to log messages at the respective levels.
+// Then, use the `Debug`, `Info`, `Warn`, `Error`, and `Fatal` methods to log messages at the respective levels.
 // Finally, call the `Close` method to close the log files when you're done with the logger.
 //
+// The logger is configured with the LOGGER_LEVEL, LOGGER_FORMAT (text or json), LOGGER_LOG_FILE,
+// LOGGER_ERROR_LOG_FILE and LOGGER_DISABLED environment variables.
+//
 // Example:
 // Create a new logger:
-// logger, err := logger.NewLogger("log.txt", "error_log.txt", logger.INFO)
+// logger, err := logger.NewLogger("log.txt", "error_log.txt", logger.INFO, false)
 //
 //	if err != nil {
 //	    log.Fatal(err)
@@ -21,8 +32,11 @@
 // Log an info message:
 // logger.Info("This is an info message")
 //
-// Log an error message: (This will also be written to the error log file)
-// logger.Error("This is an error message")
+// Log an error message with fields: (This will also be written to the error log file)
+// logger.Error("Error getting task", "id", id, "error", err)
+//
+// Bind fields to every line of a logger:
+// taskLogger := logger.With("task_id", id)
 //
 // Log a fatal message: (Same as error, but keep in mind that this will exit the program)
 // logger.Fatal("This is a fatal message")
@@ -32,10 +46,17 @@
 package logger
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
-	"log"
 	"os"
-	"syscall"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // _LogLevel represents the level of logging.
@@ -43,16 +64,39 @@ type _LogLevel int
 
 // Initialize the constants for the log levels.
 const (
-	INFO _LogLevel = iota
+	DEBUG _LogLevel = iota
+	INFO
+	WARN
 	ERROR
 	FATAL
 )
 
+// String returns the name of the log level.
+func (l _LogLevel) String() string {
+	switch l {
+	case DEBUG:
+		return "DEBUG"
+	case INFO:
+		return "INFO"
+	case WARN:
+		return "WARN"
+	case ERROR:
+		return "ERROR"
+	default:
+		return "FATAL"
+	}
+}
+
 // _GetLogLevel returns the LogLevel based on the given string.
+// Unknown levels fall back to INFO.
 func _GetLogLevel(level string) _LogLevel {
-	switch level {
+	switch strings.ToUpper(level) {
+	case "DEBUG":
+		return DEBUG
 	case "INFO":
 		return INFO
+	case "WARN", "WARNING":
+		return WARN
 	case "ERROR":
 		return ERROR
 	case "FATAL":
@@ -62,69 +106,199 @@ func _GetLogLevel(level string) _LogLevel {
 	}
 }
 
+// _output holds the destinations shared by a logger and the loggers derived from it with With.
+type _output struct {
+	mu      sync.Mutex
+	out     io.Writer // every line
+	errOut  io.Writer // ERROR and FATAL lines
+	console io.Writer // every line
+	closers []io.Closer
+}
+
 // _Logger represents a leveled logger.
 type _Logger struct {
-	infoLogger    *log.Logger
-	errorLogger   *log.Logger
-	consoleLogger *log.Logger
-	logLevel      _LogLevel
+	output   *_output
+	logLevel _LogLevel
+	json     bool
+	fields   []interface{}
 }
 
-// _NewLogger creates a new Logger instance.
-func _NewLogger(logFilePath string, errorLogFilePath string, level _LogLevel) (*_Logger, error) {
+// _NewLogger creates a new Logger instance. Both log files are rotated according to the given policy.
+func _NewLogger(logFilePath string, errorLogFilePath string, level _LogLevel, jsonFormat bool, policy _RotationPolicy) (*_Logger, error) {
 	// Open log file
-	logFile, err := os.OpenFile(logFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	logFile, err := _NewRotatingFile(logFilePath, policy)
 	if err != nil {
 		return nil, err
 	}
 
 	// Open error log file
-	errorLogFile, err := os.OpenFile(errorLogFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	errorLogFile, err := _NewRotatingFile(errorLogFilePath, policy)
 	if err != nil {
+		logFile.Close()
 		return nil, err
 	}
 
-	// Create loggers
-	infoLogger := log.New(logFile, "INFO: ", log.Ldate|log.Ltime)
-	errorLogger := log.New(errorLogFile, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
-	consoleLogger := log.New(os.Stdout, "", log.Ldate|log.Ltime)
-
 	return &_Logger{
-		infoLogger:    infoLogger,
-		errorLogger:   errorLogger,
-		consoleLogger: consoleLogger,
-		logLevel:      level,
+		output: &_output{
+			out:     logFile,
+			errOut:  errorLogFile,
+			console: os.Stdout,
+			closers: []io.Closer{logFile, errorLogFile},
+		},
+		logLevel: level,
+		json:     jsonFormat,
 	}, nil
 }
 
-func (l *_Logger) Info(message string) {
-	if l.logLevel <= INFO {
-		l.infoLogger.Println(message)
-		l.consoleLogger.Println(message)
+// With returns a logger that adds the given key/value pairs to every line.
+// It shares the outputs of the original logger.
+func (l *_Logger) With(keyvals ...interface{}) *_Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals))
+	fields = append(fields, l.fields...)
+	fields = append(fields, keyvals...)
+	return &_Logger{output: l.output, logLevel: l.logLevel, json: l.json, fields: fields}
+}
+
+// Enabled reports whether lines of the given level are written.
+func (l *_Logger) Enabled(level _LogLevel) bool {
+	return level >= l.logLevel
+}
+
+func (l *_Logger) Debug(message string, keyvals ...interface{}) {
+	l.log(DEBUG, message, keyvals)
+}
+
+func (l *_Logger) Info(message string, keyvals ...interface{}) {
+	l.log(INFO, message, keyvals)
+}
+
+func (l *_Logger) Warn(message string, keyvals ...interface{}) {
+	l.log(WARN, message, keyvals)
+}
+
+func (l *_Logger) Error(message string, keyvals ...interface{}) {
+	l.log(ERROR, message, keyvals)
+}
+
+func (l *_Logger) Fatal(message string, keyvals ...interface{}) {
+	l.log(FATAL, message, keyvals)
+	os.Exit(1)
+}
+
+func (l *_Logger) Panic(message string, keyvals ...interface{}) {
+	l.log(FATAL, message, keyvals)
+	panic(message)
+}
+
+// log formats a line and writes it to the outputs of its level.
+func (l *_Logger) log(level _LogLevel, message string, keyvals []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	fields := append(append([]interface{}{}, l.fields...), keyvals...)
+	if level >= ERROR {
+		// Skip log and the level method to report the caller of the logger
+		if _, file, line, ok := runtime.Caller(2); ok {
+			fields = append(fields, "caller", filepath.Base(file)+":"+strconv.Itoa(line))
+		}
+	}
+
+	var line string
+	if l.json {
+		line = formatJSON(time.Now(), level, message, fields)
+	} else {
+		line = formatText(time.Now(), level, message, fields)
+	}
+
+	l.output.mu.Lock()
+	defer l.output.mu.Unlock()
+	io.WriteString(l.output.out, line)
+	io.WriteString(l.output.console, line)
+	if level >= ERROR {
+		io.WriteString(l.output.errOut, line)
+	}
+}
+
+// fieldValue converts a field value to a value suitable for the output.
+func fieldValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
 	}
+	return value
 }
 
-func (l *_Logger) Error(message string) {
-	if l.logLevel <= ERROR {
-		l.errorLogger.Println(message)
-		l.infoLogger.Println("ERROR:", message)
-		l.consoleLogger.Println("ERROR: ", message)
+// forEachField calls fn for every key/value pair. A key without value gets a nil value,
+// and non-string keys are formatted with fmt.
+func forEachField(keyvals []interface{}, fn func(key string, value interface{})) {
+	for i := 0; i < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+		var value interface{}
+		if i+1 < len(keyvals) {
+			value = fieldValue(keyvals[i+1])
+		}
+		fn(key, value)
 	}
 }
 
-func (l *_Logger) Fatal(message string) {
-	if l.logLevel <= FATAL {
-		l.errorLogger.Println(message)
-		l.infoLogger.Println("FATAL:", message)
-		l.consoleLogger.Fatalf("FATAL: %s", message)
+// formatText formats a line as `date time LEVEL: message key=value ...`.
+// Values containing spaces, quotes or equal signs are quoted.
+func formatText(t time.Time, level _LogLevel, message string, keyvals []interface{}) string {
+	var b strings.Builder
+	b.WriteString(t.Format("2006/01/02 15:04:05 "))
+	b.WriteString(level.String())
+	b.WriteString(": ")
+	b.WriteString(message)
+	forEachField(keyvals, func(key string, value interface{}) {
+		s := fmt.Sprint(value)
+		if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+			s = strconv.Quote(s)
+		}
+		b.WriteString(" " + key + "=" + s)
+	})
+	b.WriteString("\n")
+	return b.String()
+}
+
+// formatJSON formats a line as a JSON object with the time, level, message and fields.
+func formatJSON(t time.Time, level _LogLevel, message string, keyvals []interface{}) string {
+	entry := map[string]interface{}{}
+	forEachField(keyvals, func(key string, value interface{}) {
+		entry[key] = value
+	})
+	entry["time"] = t.Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = message
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		data, _ = json.Marshal(map[string]interface{}{
+			"time":  entry["time"],
+			"level": entry["level"],
+			"msg":   message,
+			"error": "unsupported log field: " + err.Error(),
+		})
 	}
+	return string(data) + "\n"
 }
 
-func (l *_Logger) Panic(message string) {
-	l.errorLogger.Println(message)
-	l.infoLogger.Println("PANIC:", message)
-	l.consoleLogger.Panic(message)
-	syscall.Exit(1)
+// Reopen closes and reopens the log files at their paths.
+// Call it after an external tool rotated the files, usually on SIGHUP.
+func (l *_Logger) Reopen() error {
+	var errs []error
+	for _, closer := range l.output.closers {
+		if file, ok := closer.(*_RotatingFile); ok {
+			if err := file.Reopen(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // Close the log files.
@@ -132,21 +306,19 @@ func (l *_Logger) Panic(message string) {
 // be called only once to not cause other modules to lose the connection.
 // Preferably use defer to call this method in the main function.
 func (l *_Logger) Close() {
-	if l.infoLogger != nil {
-		_ = l.infoLogger.Writer().(*os.File).Close()
-	}
-	if l.errorLogger != nil {
-		_ = l.errorLogger.Writer().(*os.File).Close()
+	for _, closer := range l.output.closers {
+		_ = closer.Close()
 	}
 }
 
 func MockLogger() *_Logger {
 	return &_Logger{
-		infoLogger:  log.New(io.Discard, "", 0),
-		errorLogger: log.New(io.Discard, "", 0),
-		// consoleLogger: log.New(os.Stdout, "", log.Ldate|log.Ltime),
-		consoleLogger: log.New(io.Discard, "", 0),
-		logLevel:      INFO,
+		output: &_output{
+			out:     io.Discard,
+			errOut:  io.Discard,
+			console: io.Discard,
+		},
+		logLevel: INFO,
 	}
 }
 
@@ -163,13 +335,27 @@ func InitLogger() (*_Logger, error) {
 		logger = MockLogger()
 		return logger, nil
 	}
+	level := os.Getenv("LOGGER_LEVEL")
+	if level == "" {
+		// Older configurations used the log_level key
+		level = os.Getenv("LOGGER_LOG_LEVEL")
+	}
+	policy, err := _RotationPolicyFromEnv()
+	if err != nil {
+		return nil, err
+	}
 	_logger, err := _NewLogger(
 		os.Getenv("LOGGER_LOG_FILE"),
 		os.Getenv("LOGGER_ERROR_LOG_FILE"),
-		_GetLogLevel(os.Getenv("LOGGER_LOG_LEVEL")),
+		_GetLogLevel(level),
+		strings.EqualFold(os.Getenv("LOGGER_FORMAT"), "json"),
+		policy,
 	)
+	if err != nil {
+		return nil, err
+	}
 	logger = _logger
-	return logger, err
+	return logger, nil
 }
 
 // GetLogger returns the singleton instance of the logger.
@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// bufferLogger returns a logger writing every output to buffers.
+func bufferLogger(level _LogLevel, jsonFormat bool) (*_Logger, *bytes.Buffer, *bytes.Buffer) {
+	var out, errOut bytes.Buffer
+	return &_Logger{
+		output:   &_output{out: &out, errOut: &errOut, console: &bytes.Buffer{}},
+		logLevel: level,
+		json:     jsonFormat,
+	}, &out, &errOut
+}
+
+func TestGetLogLevel(t *testing.T) {
+	assert.Equal(t, DEBUG, _GetLogLevel("DEBUG"))
+	assert.Equal(t, WARN, _GetLogLevel("warn"))
+	assert.Equal(t, WARN, _GetLogLevel("WARNING"))
+	assert.Equal(t, ERROR, _GetLogLevel("ERROR"))
+	assert.Equal(t, INFO, _GetLogLevel("verbose"))
+}
+
+func TestTextFormat(t *testing.T) {
+	l, out, errOut := bufferLogger(INFO, false)
+
+	l.Debug("hidden")
+	l.With("request_id", "abc").Info("Task created", "id", 1, "title", "two words", "error", errors.New("boom"))
+	l.Error("Failed")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[0], ` INFO: Task created request_id=abc id=1 title="two words" error=boom`), lines[0])
+	assert.Contains(t, lines[1], "ERROR: Failed caller=logger_test.go:")
+	assert.Equal(t, lines[1]+"\n", errOut.String())
+}
+
+func TestJSONFormat(t *testing.T) {
+	l, out, _ := bufferLogger(DEBUG, true)
+
+	l.Debug("Listing tasks", "page", 2, "elapsed", time.Second, "dangling")
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "DEBUG", entry["level"])
+	assert.Equal(t, "Listing tasks", entry["msg"])
+	assert.Equal(t, float64(2), entry["page"])
+	assert.Equal(t, "1s", entry["elapsed"])
+	assert.Contains(t, entry, "dangling")
+	assert.Contains(t, entry, "time")
+}
+
+func TestFromContext(t *testing.T) {
+	l, out, _ := bufferLogger(INFO, false)
+	previous := logger
+	logger = l
+	defer func() { logger = previous }()
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	assert.Equal(t, "req-1", RequestID(ctx))
+	FromContext(ctx).Info("Handled")
+	FromContext(context.Background()).Info("Background")
+
+	type clientIPKey struct{}
+	previousFields := contextFields
+	defer func() { contextFields = previousFields }()
+	RegisterContextField("client_ip", func(ctx context.Context) string {
+		clientIP, _ := ctx.Value(clientIPKey{}).(string)
+		return clientIP
+	})
+	FromContext(ctx).Info("Unresolved")
+	ctx = context.WithValue(ctx, clientIPKey{}, "192.0.2.1")
+	FromContext(ctx).Info("Resolved")
+
+	assert.Contains(t, out.String(), "Handled request_id=req-1\n")
+	assert.Contains(t, out.String(), "Unresolved request_id=req-1\n")
+	assert.Contains(t, out.String(), "Resolved request_id=req-1 client_ip=192.0.2.1\n")
+	assert.Contains(t, out.String(), "Background\n")
+}
@@ -0,0 +1,312 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp appended to the name of rotated log files,
+// e.g. `app-2024-01-02T15-04-05.000.log`. It sorts chronologically and is safe in file names.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// _RotationPolicy configures when a log file is rotated and how long its backups are kept.
+// Zero values disable the corresponding rule.
+type _RotationPolicy struct {
+	MaxSize    int64         // rotate before a write would make the file larger than this many bytes
+	Interval   time.Duration // rotate when the current file was started in a previous interval, aligned to UTC
+	Compress   bool          // gzip rotated files
+	MaxBackups int           // number of rotated files to keep
+	MaxAge     time.Duration // remove rotated files older than this
+}
+
+// _RotationPolicyFromEnv reads the rotation policy from the LOGGER_MAX_SIZE (megabytes),
+// LOGGER_ROTATE_INTERVAL (seconds), LOGGER_COMPRESS, LOGGER_MAX_BACKUPS and LOGGER_MAX_AGE (days)
+// environment variables. Unset variables disable the corresponding rule.
+func _RotationPolicyFromEnv() (_RotationPolicy, error) {
+	var policy _RotationPolicy
+	maxSize, err := envInt("LOGGER_MAX_SIZE")
+	if err != nil {
+		return policy, err
+	}
+	interval, err := envInt("LOGGER_ROTATE_INTERVAL")
+	if err != nil {
+		return policy, err
+	}
+	maxBackups, err := envInt("LOGGER_MAX_BACKUPS")
+	if err != nil {
+		return policy, err
+	}
+	maxAge, err := envInt("LOGGER_MAX_AGE")
+	if err != nil {
+		return policy, err
+	}
+	policy.MaxSize = int64(maxSize) << 20
+	policy.Interval = time.Duration(interval) * time.Second
+	policy.Compress = os.Getenv("LOGGER_COMPRESS") == "true"
+	policy.MaxBackups = maxBackups
+	policy.MaxAge = time.Duration(maxAge) * 24 * time.Hour
+	return policy, nil
+}
+
+// envInt reads a non-negative integer from the given environment variable, 0 if it is not set.
+func envInt(name string) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return n, nil
+}
+
+// _RotatingFile is a log file that rotates itself according to a _RotationPolicy.
+// Rotated files are renamed with a timestamp, then compressed and pruned in the background.
+// It is safe for concurrent use.
+type _RotatingFile struct {
+	mu       sync.Mutex
+	path     string
+	policy   _RotationPolicy
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+
+	millMu sync.Mutex     // serializes compression and pruning
+	mills  sync.WaitGroup // pending compression and pruning runs
+}
+
+// _NewRotatingFile opens, or creates, the log file at path.
+func _NewRotatingFile(path string, policy _RotationPolicy) (*_RotatingFile, error) {
+	f := &_RotatingFile{path: path, policy: policy, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// open opens the log file in append mode. The start of an existing file is approximated
+// with its modification time, so that a restart does not postpone time based rotation.
+func (f *_RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+	if f.size > 0 {
+		f.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+// Write writes p to the log file, rotating it first if the policy requires so.
+func (f *_RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// shouldRotate reports whether the file must be rotated before writing the given number of bytes.
+func (f *_RotatingFile) shouldRotate(length int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.policy.MaxSize > 0 && f.size+length > f.policy.MaxSize {
+		return true
+	}
+	interval := f.policy.Interval
+	return interval > 0 && !f.now().Truncate(interval).Equal(f.openedAt.Truncate(interval))
+}
+
+// rotate renames the current file to a timestamped backup, opens a new file,
+// and starts compressing and pruning the backups in the background.
+func (f *_RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	if err := os.Rename(f.path, f.backupName(f.now())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.mills.Add(1)
+	go func() {
+		defer f.mills.Done()
+		f.mill()
+	}()
+	return nil
+}
+
+// Rotate rotates the file immediately.
+func (f *_RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	return f.rotate()
+}
+
+// Reopen closes and reopens the file at its path. It is meant to be called after an
+// external tool such as logrotate moved the file, so that new lines go to a new file.
+func (f *_RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+	return f.open()
+}
+
+// Close closes the file and waits for the pending compression and pruning runs.
+func (f *_RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.mills.Wait()
+	return err
+}
+
+// backupName returns the name of a backup of the file rotated at the given time.
+func (f *_RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.path)
+	return strings.TrimSuffix(f.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// _backup is a rotated log file.
+type _backup struct {
+	path      string
+	rotatedAt time.Time
+}
+
+// backups returns the rotated files of the log file, most recent first.
+func (f *_RotatingFile) backups() ([]_backup, error) {
+	dir := filepath.Dir(f.path)
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(filepath.Base(f.path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []_backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		timestamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		timestamp = strings.TrimPrefix(timestamp, prefix)
+		rotatedAt, err := time.ParseInLocation(backupTimeFormat, timestamp, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, _backup{path: filepath.Join(dir, name), rotatedAt: rotatedAt})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].rotatedAt.After(backups[j].rotatedAt) })
+	return backups, nil
+}
+
+// mill compresses the uncompressed backups and removes the backups exceeding
+// the retention policy. Errors are reported on stderr, as the log file itself may be the problem.
+func (f *_RotatingFile) mill() {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	backups, err := f.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logger: listing backups of %s: %v\n", f.path, err)
+		return
+	}
+	for i, backup := range backups {
+		expired := f.policy.MaxAge > 0 && f.now().Sub(backup.rotatedAt) > f.policy.MaxAge
+		if (f.policy.MaxBackups > 0 && i >= f.policy.MaxBackups) || expired {
+			if err := os.Remove(backup.path); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "logger: removing %s: %v\n", backup.path, err)
+			}
+			continue
+		}
+		if f.policy.Compress && !strings.HasSuffix(backup.path, ".gz") {
+			if err := compressFile(backup.path); err != nil {
+				fmt.Fprintf(os.Stderr, "logger: compressing %s: %v\n", backup.path, err)
+			}
+		}
+	}
+}
+
+// compressFile replaces the file at path with a gzip compressed copy named `<path>.gz`.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
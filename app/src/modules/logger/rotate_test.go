@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// readFile returns the content of a log file, decompressing gzipped backups.
+func readFile(t *testing.T, path string) string {
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		assert.NoError(t, err)
+		reader = gz
+	}
+	data, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestRotatingFileSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := _NewRotatingFile(path, _RotationPolicy{MaxSize: 10, Compress: true, MaxBackups: 2})
+	assert.NoError(t, err)
+
+	clock := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	f.now = func() time.Time { return clock }
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		clock = clock.Add(time.Second)
+		_, err := f.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, f.Close())
+
+	// Every line exceeds the size of the previous one, the oldest backup is pruned
+	assert.Equal(t, "fourth\n", readFile(t, path))
+	backups, err := f.backups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 2)
+	assert.Equal(t, filepath.Join(dir, "app-2024-01-01T12-00-04.000.log.gz"), backups[0].path)
+	assert.Equal(t, "third\n", readFile(t, backups[0].path))
+	assert.Equal(t, "second\n", readFile(t, backups[1].path))
+}
+
+func TestRotatingFileInterval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := _NewRotatingFile(path, _RotationPolicy{Interval: time.Hour, MaxAge: 24 * time.Hour})
+	assert.NoError(t, err)
+
+	// A stale backup is removed on the next rotation
+	stale := filepath.Join(dir, "app-2023-12-01T00-00-00.000.log")
+	assert.NoError(t, os.WriteFile(stale, []byte("stale\n"), 0644))
+
+	clock := time.Now().Truncate(time.Hour)
+	f.now = func() time.Time { return clock }
+	f.openedAt = clock
+	f.Write([]byte("first\n"))
+	clock = clock.Add(30 * time.Minute)
+	f.Write([]byte("second\n"))
+	clock = clock.Add(30 * time.Minute)
+	f.Write([]byte("third\n"))
+	assert.NoError(t, f.Close())
+
+	assert.Equal(t, "third\n", readFile(t, path))
+	backups, err := f.backups()
+	assert.NoError(t, err)
+	assert.Len(t, backups, 1)
+	assert.Equal(t, "first\nsecond\n", readFile(t, backups[0].path))
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	f, err := _NewRotatingFile(path, _RotationPolicy{})
+	assert.NoError(t, err)
+
+	// Simulate logrotate moving the file away
+	f.Write([]byte("before\n"))
+	assert.NoError(t, os.Rename(path, path+".1"))
+	f.Write([]byte("moved\n"))
+	assert.NoError(t, f.Reopen())
+	f.Write([]byte("after\n"))
+	assert.NoError(t, f.Close())
+
+	assert.Equal(t, "before\nmoved\n", readFile(t, path+".1"))
+	assert.Equal(t, "after\n", readFile(t, path))
+}
+
+func TestRotationPolicyFromEnv(t *testing.T) {
+	t.Setenv("LOGGER_MAX_SIZE", "100")
+	t.Setenv("LOGGER_ROTATE_INTERVAL", "86400")
+	t.Setenv("LOGGER_COMPRESS", "true")
+	t.Setenv("LOGGER_MAX_BACKUPS", "7")
+	t.Setenv("LOGGER_MAX_AGE", "30")
+	policy, err := _RotationPolicyFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, _RotationPolicy{
+		MaxSize:    100 << 20,
+		Interval:   24 * time.Hour,
+		Compress:   true,
+		MaxBackups: 7,
+		MaxAge:     30 * 24 * time.Hour,
+	}, policy)
+
+	t.Setenv("LOGGER_MAX_BACKUPS", "-1")
+	_, err = _RotationPolicyFromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package metrics
+
+// Metrics of the application, registered on the shared registry.
+// Gauges that read the state of other modules, such as the worker pool and the
+// database connection pool, are registered by the code that owns that state.
+var (
+	// HTTPRequests counts the HTTP requests by route template, method and status code.
+	HTTPRequests = registry.NewCounterVec(
+		"http_requests_total",
+		"Number of HTTP requests by route, method and status code.",
+		"route", "method", "status",
+	)
+	// HTTPRequestDuration observes the time spent serving HTTP requests by route template and method.
+	HTTPRequestDuration = registry.NewHistogramVec(
+		"http_request_duration_seconds",
+		"Time spent serving HTTP requests by route and method.",
+		DefaultBuckets,
+		"route", "method",
+	)
+	// WorkerQueueWait observes the time jobs wait before a worker starts them.
+	WorkerQueueWait = registry.NewHistogramVec(
+		"worker_queue_wait_seconds",
+		"Time jobs wait in the worker pool queue before a worker starts them.",
+		DefaultBuckets,
+	).WithLabelValues()
+	// WorkerQueueRejections counts the jobs rejected because the worker pool queue was full.
+	WorkerQueueRejections = registry.NewCounterVec(
+		"worker_queue_rejections_total",
+		"Number of jobs rejected because the worker pool queue was full.",
+	).WithLabelValues()
+	// WorkerJobsDropped counts the jobs dropped without running because their request was already done.
+	WorkerJobsDropped = registry.NewCounterVec(
+		"worker_jobs_dropped_total",
+		"Number of jobs dropped without running because their request was already cancelled or timed out.",
+	).WithLabelValues()
+	// JobsProcessed counts the background job attempts by job type and outcome: completed, retried or dead.
+	JobsProcessed = registry.NewCounterVec(
+		"jobs_processed_total",
+		"Number of background job attempts by job type and outcome.",
+		"type", "outcome",
+	)
+	// JobDuration observes the time spent running background job attempts by job type.
+	JobDuration = registry.NewHistogramVec(
+		"job_duration_seconds",
+		"Time spent running background job attempts by job type.",
+		DefaultBuckets,
+		"type",
+	)
+	// PanicsRecovered counts the recovered panics by where they were recovered: http, worker or job.
+	PanicsRecovered = registry.NewCounterVec(
+		"panics_recovered_total",
+		"Number of recovered panics by source.",
+		"source",
+	)
+	// IPFilterRejections counts the requests rejected by the IP filter by reason: denied or banned.
+	IPFilterRejections = registry.NewCounterVec(
+		"ip_filter_rejections_total",
+		"Number of requests rejected by the IP filter by reason.",
+		"reason",
+	)
+	// BansIssued counts the clients banned automatically by the offense that triggered the ban: rate_limit or sql_injection.
+	BansIssued = registry.NewCounterVec(
+		"bans_issued_total",
+		"Number of clients banned automatically by offense.",
+		"offense",
+	)
+	// RateLimitRejections counts the requests rejected by the rate limiter.
+	RateLimitRejections = registry.NewCounterVec(
+		"rate_limit_rejections_total",
+		"Number of requests rejected by the rate limiter.",
+	).WithLabelValues()
+	// SQLInjectionDetections counts the requests in which the SQL injection detector matched a rule, by the part
+	// of the request it was found in (query, body or form), the rule, and the action taken: blocked or logged.
+	SQLInjectionDetections = registry.NewCounterVec(
+		"sql_injection_detections_total",
+		"Number of requests in which a SQL injection was detected by request part, rule and action.",
+		"source", "rule", "action",
+	)
+)
@@ -0,0 +1,378 @@
+// Package metrics provides counters, gauges and histograms exposed in the
+// Prometheus text exposition format (version 0.0.4).
+//
+// Usage:
+// Create metrics on a Registry, usually the shared one returned by `GetRegistry`,
+// then update them from your code. Metrics with labels return one series per
+// combination of label values. Serve the registry with its `Handler` method.
+//
+// Example:
+// Create a counter with a label:
+// requests := metrics.GetRegistry().NewCounterVec("requests_total", "Number of requests.", "method")
+//
+// Increment it:
+// requests.WithLabelValues("GET").Inc()
+//
+// Expose the metrics:
+// http.Handle("/metrics", metrics.GetRegistry().Handler())
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the default histogram buckets, in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector is a metric family that can write itself in the text exposition format.
+type collector interface {
+	write(w io.Writer)
+}
+
+// Registry holds a set of metric families, written in registration order.
+type Registry struct {
+	mu         sync.Mutex
+	names      []string
+	collectors map[string]collector
+}
+
+// NewRegistry creates a new, empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// register adds a metric family. Registering a name again replaces the previous family.
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.collectors[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.collectors[name] = c
+}
+
+// Write writes every metric family of the registry in the text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	collectors := make([]collector, len(r.names))
+	for i, name := range r.names {
+		collectors[i] = r.collectors[name]
+	}
+	r.mu.Unlock()
+
+	buffered := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(buffered)
+	}
+	return buffered.Flush()
+}
+
+// Handler returns an HTTP handler serving the metrics of the registry.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// NewCounterVec creates and registers a counter with the given label names.
+func (r *Registry) NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	c := &CounterVec{family: newFamily(name, help, "counter", labels)}
+	r.register(name, c)
+	return c
+}
+
+// NewGaugeVec creates and registers a gauge with the given label names.
+func (r *Registry) NewGaugeVec(name string, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{family: newFamily(name, help, "gauge", labels)}
+	r.register(name, g)
+	return g
+}
+
+// NewHistogramVec creates and registers a histogram with the given upper bounds and label names.
+// The buckets must be sorted in increasing order, the +Inf bucket is implicit.
+func (r *Registry) NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{family: newFamily(name, help, "histogram", labels), buckets: buckets}
+	r.register(name, h)
+	return h
+}
+
+// NewGaugeFunc creates and registers a gauge whose value is read from fn when the metrics are written.
+func (r *Registry) NewGaugeFunc(name string, help string, fn func() float64) {
+	r.register(name, &funcCollector{family: newFamily(name, help, "gauge", nil), fn: fn})
+}
+
+// NewCounterFunc creates and registers a counter whose value is read from fn when the metrics are written.
+// fn must never decrease.
+func (r *Registry) NewCounterFunc(name string, help string, fn func() float64) {
+	r.register(name, &funcCollector{family: newFamily(name, help, "counter", nil), fn: fn})
+}
+
+// family holds what all metric types share: metadata and a set of series indexed by label values.
+type family struct {
+	mu     sync.Mutex
+	name   string
+	help   string
+	kind   string
+	labels []string
+	series map[string]interface{}
+}
+
+func newFamily(name string, help string, kind string, labels []string) family {
+	return family{name: name, help: help, kind: kind, labels: labels, series: make(map[string]interface{})}
+}
+
+// get returns the series for the given label values, creating it with create if needed.
+// It panics if the number of values does not match the label names, as that is a programming error.
+func (f *family) get(values []string, create func() interface{}) interface{} {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := formatLabels(f.labels, values)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = create()
+		f.series[key] = s
+	}
+	return s
+}
+
+// sortedKeys returns the label sets of the series in a stable order.
+func (f *family) sortedKeys() []string {
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeHeader writes the HELP and TYPE lines of the family.
+func (f *family) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+}
+
+// value is a float64 safe for concurrent use.
+type value struct {
+	mu sync.Mutex
+	v  float64
+}
+
+func (v *value) add(delta float64) {
+	v.mu.Lock()
+	v.v += delta
+	v.mu.Unlock()
+}
+
+func (v *value) set(x float64) {
+	v.mu.Lock()
+	v.v = x
+	v.mu.Unlock()
+}
+
+func (v *value) get() float64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.v
+}
+
+// Counter is a single counter series. It can only increase.
+type Counter struct {
+	value
+}
+
+// Inc increments the counter by 1.
+func (c *Counter) Inc() {
+	c.add(1)
+}
+
+// Add increments the counter by the given non-negative amount.
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+	c.add(delta)
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	family
+}
+
+// WithLabelValues returns the counter for the given label values, in label name order.
+func (c *CounterVec) WithLabelValues(values ...string) *Counter {
+	return c.get(values, func() interface{} { return &Counter{} }).(*Counter)
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w)
+	for _, key := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, key, formatFloat(c.series[key].(*Counter).get()))
+	}
+}
+
+// Gauge is a single gauge series. It can go up and down.
+type Gauge struct {
+	value
+}
+
+// Set sets the gauge to the given value.
+func (g *Gauge) Set(x float64) {
+	g.set(x)
+}
+
+// Add adds the given, possibly negative, amount to the gauge.
+func (g *Gauge) Add(delta float64) {
+	g.add(delta)
+}
+
+// GaugeVec is a gauge partitioned by label values.
+type GaugeVec struct {
+	family
+}
+
+// WithLabelValues returns the gauge for the given label values, in label name order.
+func (g *GaugeVec) WithLabelValues(values ...string) *Gauge {
+	return g.get(values, func() interface{} { return &Gauge{} }).(*Gauge)
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writeHeader(w)
+	for _, key := range g.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, key, formatFloat(g.series[key].(*Gauge).get()))
+	}
+}
+
+// Histogram is a single histogram series, counting observations in cumulative buckets.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64 // per bucket, not cumulative
+	count   uint64
+	sum     float64
+}
+
+// Observe records a value in the histogram.
+func (h *Histogram) Observe(x float64) {
+	i := sort.SearchFloat64s(h.buckets, x)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += x
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	family
+	buckets []float64
+}
+
+// WithLabelValues returns the histogram for the given label values, in label name order.
+func (h *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return h.get(values, func() interface{} {
+		return &Histogram{buckets: h.buckets, counts: make([]uint64, len(h.buckets))}
+	}).(*Histogram)
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w)
+	for _, key := range h.sortedKeys() {
+		series := h.series[key].(*Histogram)
+		series.mu.Lock()
+		var cumulative uint64
+		for i, upperBound := range h.buckets {
+			cumulative += series.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLabel(key, "le", formatFloat(upperBound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLabel(key, "le", "+Inf"), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, key, formatFloat(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, key, series.count)
+		series.mu.Unlock()
+	}
+}
+
+// funcCollector is an unlabeled metric whose value is computed when it is written.
+type funcCollector struct {
+	family
+	fn func() float64
+}
+
+func (f *funcCollector) write(w io.Writer) {
+	f.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", f.name, formatFloat(f.fn()))
+}
+
+// formatLabels formats label pairs as `{name="value",...}`, or an empty string without labels.
+func formatLabels(names []string, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withLabel appends a label pair to a formatted label set.
+func withLabel(labels string, name string, value string) string {
+	pair := name + `="` + escapeLabel(value) + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return strings.TrimSuffix(labels, "}") + "," + pair + "}"
+}
+
+// escapeLabel escapes a label value as required by the text exposition format.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// escapeHelp escapes a help text as required by the text exposition format.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat formats a sample value.
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// registry is the shared singleton instance of the Registry.
+var registry = NewRegistry()
+
+// GetRegistry returns the shared singleton instance of the Registry.
+func GetRegistry() *Registry {
+	return registry
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	requests := r.NewCounterVec("requests_total", "Number of requests.", "method", "path")
+	requests.WithLabelValues("GET", `/a"b`).Inc()
+	requests.WithLabelValues("GET", `/a"b`).Add(2)
+	requests.WithLabelValues("DELETE", "/").Inc()
+	requests.WithLabelValues("DELETE", "/").Add(-1) // ignored
+
+	temperature := r.NewGaugeVec("temperature", "Current temperature.")
+	temperature.WithLabelValues().Set(20)
+	temperature.WithLabelValues().Add(-2.5)
+
+	latency := r.NewHistogramVec("latency_seconds", "Request latency.\nIn seconds.", []float64{0.1, 1}, "method")
+	latency.WithLabelValues("GET").Observe(0.05)
+	latency.WithLabelValues("GET").Observe(0.1)
+	latency.WithLabelValues("GET").Observe(0.5)
+	latency.WithLabelValues("GET").Observe(3)
+
+	r.NewGaugeFunc("answer", "The answer.", func() float64 { return 42 })
+
+	var out bytes.Buffer
+	assert.NoError(t, r.Write(&out))
+	assert.Equal(t, `# HELP requests_total Number of requests.
+# TYPE requests_total counter
+requests_total{method="DELETE",path="/"} 1
+requests_total{method="GET",path="/a\"b"} 3
+# HELP temperature Current temperature.
+# TYPE temperature gauge
+temperature 17.5
+# HELP latency_seconds Request latency.\nIn seconds.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{method="GET",le="0.1"} 2
+latency_seconds_bucket{method="GET",le="1"} 3
+latency_seconds_bucket{method="GET",le="+Inf"} 4
+latency_seconds_sum{method="GET"} 3.65
+latency_seconds_count{method="GET"} 4
+# HELP answer The answer.
+# TYPE answer gauge
+answer 42
+`, out.String())
+
+	// Registering a name again replaces the metric
+	r.NewGaugeFunc("answer", "The answer.", func() float64 { return 43 })
+	rr := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "version=0.0.4")
+	assert.Contains(t, rr.Body.String(), "answer 43\n")
+	assert.NotContains(t, rr.Body.String(), "answer 42")
+
+	assert.Panics(t, func() { requests.WithLabelValues("GET") })
+}
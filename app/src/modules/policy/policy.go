@@ -0,0 +1,132 @@
+// Package policy defines the roles and permissions of the API and the permission each route requires.
+//
+// Every authenticated identity has a role, and each role grants a set of permissions.
+// Routes are identified by their Gorilla Mux route name, and routes without a declared
+// permission are denied by default.
+//
+// Usage:
+// Use the `Allowed` function to check if an identity has a permission, and the
+// `RoutePermission` function to get the permission required by a named route.
+//
+// Example:
+//
+//	if !policy.Allowed(identity, policy.TasksAny) {
+//	    filter.OwnerID = identity.UserID
+//	}
+package policy
+
+import (
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
+)
+
+// Permission represents an action an identity can be allowed to perform.
+type Permission string
+
+// Permissions of the API.
+const (
+	// TasksRead allows listing and reading tasks.
+	TasksRead Permission = "tasks:read"
+	// TasksWrite allows creating, updating and deleting tasks.
+	TasksWrite Permission = "tasks:write"
+	// TasksAny extends TasksRead and TasksWrite to the tasks of every user.
+	TasksAny Permission = "tasks:any"
+	// KeysManage allows creating, listing and revoking one's own API keys.
+	KeysManage Permission = "keys:manage"
+	// WorkersManage allows inspecting and resizing the worker pool.
+	WorkersManage Permission = "workers:manage"
+	// JobsManage allows inspecting and retrying background jobs.
+	JobsManage Permission = "jobs:manage"
+	// BansManage allows listing, creating and lifting bans of client IP addresses.
+	BansManage Permission = "bans:manage"
+	// StatusRead allows reading the system status: database pool statistics, workers and migrations.
+	StatusRead Permission = "status:read"
+)
+
+// APIKeyScopes lists the permissions an API key can be scoped to.
+// API keys cannot manage other API keys.
+var APIKeyScopes = []Permission{TasksRead, TasksWrite, TasksAny}
+
+// Roles of the API.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// rolePermissions maps each role to the permissions it grants.
+var rolePermissions = map[string][]Permission{
+	RoleUser:  {TasksRead, TasksWrite, KeysManage},
+	RoleAdmin: {TasksRead, TasksWrite, TasksAny, KeysManage, WorkersManage, JobsManage, BansManage, StatusRead},
+}
+
+// routePermissions maps each named route to the permission it requires.
+var routePermissions = map[string]Permission{
+	"tasks.list":       TasksRead,
+	"tasks.get":        TasksRead,
+	"tasks.create":     TasksWrite,
+	"tasks.update":     TasksWrite,
+	"tasks.transition": TasksWrite,
+	"tasks.delete":     TasksWrite,
+	"keys.create":      KeysManage,
+	"keys.list":        KeysManage,
+	"keys.revoke":      KeysManage,
+	"workers.get":      WorkersManage,
+	"workers.resize":   WorkersManage,
+	"jobs.list":        JobsManage,
+	"jobs.get":         JobsManage,
+	"jobs.retry":       JobsManage,
+	"bans.list":        BansManage,
+	"bans.create":      BansManage,
+	"bans.delete":      BansManage,
+	"health.status":    StatusRead,
+}
+
+// IsValidRole reports whether the given role is defined.
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsAPIKeyScope reports whether an API key can be scoped to the given permission.
+func IsAPIKeyScope(scope string) bool {
+	for _, p := range APIKeyScopes {
+		if string(p) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed reports whether the identity has the given permission.
+// The permission must be granted by the identity's role and, for API keys, be one of its scopes.
+// Anonymous identities have no permissions.
+func Allowed(identity *auth.Identity, permission Permission) bool {
+	if identity == nil || !grants(rolePermissions[identity.Role], permission) {
+		return false
+	}
+	if identity.APIKeyID == 0 {
+		return true
+	}
+	for _, scope := range identity.Scopes {
+		if Permission(scope) == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// grants reports whether the permission is in the list.
+func grants(permissions []Permission, permission Permission) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RoutePermission returns the permission required by the named route,
+// and false if the route has no declared permission.
+func RoutePermission(route string) (Permission, bool) {
+	permission, ok := routePermissions[route]
+	return permission, ok
+}
@@ -0,0 +1,46 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowed(t *testing.T) {
+	user := &auth.Identity{UserID: 1, Role: RoleUser}
+	admin := &auth.Identity{UserID: 2, Role: RoleAdmin}
+	unknown := &auth.Identity{UserID: 3, Role: "superuser"}
+
+	assert.True(t, Allowed(user, TasksRead))
+	assert.True(t, Allowed(user, TasksWrite))
+	assert.False(t, Allowed(user, TasksAny))
+	assert.True(t, Allowed(admin, TasksAny))
+	assert.False(t, Allowed(unknown, TasksRead))
+	assert.False(t, Allowed(nil, TasksRead))
+
+	// API keys are limited to their scopes and their owner's role
+	key := &auth.Identity{UserID: 1, Role: RoleUser, APIKeyID: 1, Scopes: []string{"tasks:read", "tasks:any"}}
+	assert.True(t, Allowed(key, TasksRead))
+	assert.False(t, Allowed(key, TasksWrite))
+	assert.False(t, Allowed(key, TasksAny))
+	assert.False(t, Allowed(key, KeysManage))
+
+	assert.True(t, IsAPIKeyScope("tasks:write"))
+	assert.False(t, IsAPIKeyScope("keys:manage"))
+}
+
+func TestRoutePermission(t *testing.T) {
+	permission, ok := RoutePermission("tasks.delete")
+	assert.True(t, ok)
+	assert.Equal(t, TasksWrite, permission)
+
+	// The system status is only readable by admins
+	permission, ok = RoutePermission("health.status")
+	assert.True(t, ok)
+	assert.True(t, Allowed(&auth.Identity{UserID: 2, Role: RoleAdmin}, permission))
+	assert.False(t, Allowed(&auth.Identity{UserID: 1, Role: RoleUser}, permission))
+
+	_, ok = RoutePermission("")
+	assert.False(t, ok)
+}
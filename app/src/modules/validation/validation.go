@@ -0,0 +1,251 @@
+// Package validation validates request DTOs declaratively with `validate` struct tags, and reports every invalid field.
+//
+// A tag is a comma separated list of rules, checked in order until one fails:
+//   - `required`: strings must not be blank, slices and maps must not be empty, pointers must not be nil,
+//     and numbers must not be zero.
+//   - `min=N` and `max=N`: bounds of the number of characters of strings, the length of slices and maps,
+//     or the value of numbers.
+//   - `oneof=a b c`: strings must be one of the space separated values.
+//   - `format=NAME`: strings must match one of the Formats, e.g. `ip` or `email`.
+//   - `future`: times must be after the current time.
+//
+// Fields that are not required are only checked when they are set, i.e. not the zero value or nil,
+// so that optional fields can be omitted. Fields are reported by their JSON name: the name of the `json` tag
+// if any, or the field name with a lowercase first letter.
+//
+// Example:
+//
+//	type CreateTaskRequest struct {
+//		Title  string `validate:"required,max=255"`
+//		Status string `validate:"oneof=pending in_progress"`
+//	}
+//
+//	if errs := validation.Struct(req); errs != nil {
+//	    responses.ValidationError(w, errs)
+//	    return
+//	}
+//
+// Malformed tags are programming errors and cause a panic.
+package validation
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FieldError describes an invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors lists the invalid fields of a request, in the order of the struct fields.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Field + ": " + err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Formats are the formats of the `format` rule, by name.
+var Formats = map[string]*regexp.Regexp{
+	// identifier is a name made of letters, digits, dots, dashes and underscores
+	"identifier": regexp.MustCompile(`^[a-zA-Z0-9._-]+$`),
+	// line is a single line of printable text
+	"line": regexp.MustCompile(`^[^\p{C}]*$`),
+	// text is printable text, which may span lines
+	"text": regexp.MustCompile(`^(?:[^\p{C}]|[\t\r\n])*$`),
+}
+
+// matchFormat reports whether the value matches the named format, and panics if the format is unknown.
+func matchFormat(name string, value string) bool {
+	switch name {
+	case "ip":
+		return net.ParseIP(value) != nil
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	}
+	format, ok := Formats[name]
+	if !ok {
+		panic("validation: unknown format " + strconv.Quote(name))
+	}
+	return format.MatchString(value)
+}
+
+// Struct validates the struct, or pointer to a struct, and returns its invalid fields, or nil if it is valid.
+// Embedded structs are validated as part of the struct.
+func Struct(v interface{}) Errors {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: expected a struct, got %T", v))
+	}
+	errs := validateStruct(value)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func validateStruct(value reflect.Value) Errors {
+	var errs Errors
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, tagged := field.Tag.Lookup("validate")
+		if field.Anonymous && !tagged && reflect.Indirect(value.Field(i)).Kind() == reflect.Struct {
+			errs = append(errs, validateStruct(reflect.Indirect(value.Field(i)))...)
+			continue
+		}
+		if !tagged || tag == "" || tag == "-" {
+			continue
+		}
+		if err := validateField(value.Field(i), tag); err != nil {
+			err.Field = fieldName(field)
+			errs = append(errs, *err)
+		}
+	}
+	return errs
+}
+
+// fieldName returns the name of the field in JSON documents.
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	// Lowercase the leading acronym too, e.g. APIKey becomes apiKey
+	runes := []rune(field.Name)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// validateField checks the rules of the tag against the value, and returns the first rule that failed.
+func validateField(value reflect.Value, tag string) *FieldError {
+	rules := strings.Split(tag, ",")
+	if !isSet(value) {
+		if contains(rules, "required") {
+			return &FieldError{Rule: "required", Message: "is required"}
+		}
+		return nil
+	}
+	value = reflect.Indirect(value)
+
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		var message string
+		switch name {
+		case "required":
+		case "min", "max":
+			message = checkBound(value, name, param)
+		case "oneof":
+			if !contains(strings.Fields(param), stringOf(value, rule)) {
+				message = "must be one of: " + strings.Join(strings.Fields(param), ", ")
+			}
+		case "format":
+			if !matchFormat(param, stringOf(value, rule)) {
+				message = "must be a valid " + param
+			}
+		case "future":
+			t, ok := value.Interface().(time.Time)
+			if !ok {
+				panic("validation: rule future expects a time, got " + value.Type().String())
+			}
+			if !t.After(time.Now()) {
+				message = "must be in the future"
+			}
+		default:
+			panic("validation: unknown rule " + strconv.Quote(rule))
+		}
+		if message != "" {
+			return &FieldError{Rule: name, Message: message}
+		}
+	}
+	return nil
+}
+
+// isSet reports whether the value is set: not nil, not empty, not blank and not zero.
+func isSet(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return !value.IsNil()
+	case reflect.Slice, reflect.Map:
+		return value.Len() > 0
+	case reflect.String:
+		return strings.TrimSpace(value.String()) != ""
+	default:
+		return !value.IsZero()
+	}
+}
+
+// checkBound checks the min or max rule against the length of strings, slices and maps, or the value of numbers.
+func checkBound(value reflect.Value, rule string, param string) string {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic("validation: invalid " + rule + " parameter " + strconv.Quote(param))
+	}
+
+	var n float64
+	var unit string
+	switch value.Kind() {
+	case reflect.String:
+		n, unit = float64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Slice, reflect.Map:
+		n, unit = float64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = value.Float()
+	default:
+		panic("validation: rule " + rule + " does not apply to " + value.Type().String())
+	}
+
+	switch {
+	case rule == "min" && n < bound && unit == "":
+		return "must be at least " + param
+	case rule == "min" && n < bound:
+		return "must have at least " + param + unit
+	case rule == "max" && n > bound && unit == "":
+		return "must be at most " + param
+	case rule == "max" && n > bound:
+		return "must have at most " + param + unit
+	}
+	return ""
+}
+
+// stringOf returns the string value the rule applies to, and panics if the value is not a string.
+func stringOf(value reflect.Value, rule string) string {
+	if value.Kind() != reflect.String {
+		panic("validation: rule " + rule + " expects a string, got " + value.Type().String())
+	}
+	return value.String()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
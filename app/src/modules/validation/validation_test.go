@@ -0,0 +1,95 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Base struct {
+	Name string `validate:"required,max=5,format=identifier"`
+}
+
+type request struct {
+	Base
+	APIKey    string     `validate:"oneof=a b"`
+	Email     string     `json:"email_address" validate:"format=email"`
+	Address   string     `validate:"format=ip"`
+	Tags      []string   `validate:"required,max=2"`
+	Count     int        `validate:"min=0,max=10"`
+	ExpiresAt *time.Time `validate:"future"`
+	Note      string
+}
+
+func TestStruct(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	valid := request{
+		Base:      Base{Name: "bob"},
+		APIKey:    "a",
+		Email:     "bob@example.com",
+		Address:   "2001:db8::1",
+		Tags:      []string{"x"},
+		Count:     10,
+		ExpiresAt: &future,
+	}
+	assert.Nil(t, Struct(valid))
+	assert.Nil(t, Struct(&valid))
+
+	// Optional fields can be omitted
+	assert.Nil(t, Struct(request{Base: Base{Name: "bob"}, Tags: []string{"x"}}))
+
+	past := time.Now().Add(-time.Hour)
+	errs := Struct(request{
+		Base:      Base{Name: "  "},
+		APIKey:    "c",
+		Email:     "Bob <bob@example.com>",
+		Address:   "example.com",
+		Count:     -1,
+		ExpiresAt: &past,
+	})
+	assert.Equal(t, Errors{
+		{Field: "name", Rule: "required", Message: "is required"},
+		{Field: "apiKey", Rule: "oneof", Message: "must be one of: a, b"},
+		{Field: "email_address", Rule: "format", Message: "must be a valid email"},
+		{Field: "address", Rule: "format", Message: "must be a valid ip"},
+		{Field: "tags", Rule: "required", Message: "is required"},
+		{Field: "count", Rule: "min", Message: "must be at least 0"},
+		{Field: "expiresAt", Rule: "future", Message: "must be in the future"},
+	}, errs)
+	assert.EqualError(t, errs[:2], "name: is required; apiKey: must be one of: a, b")
+
+	// Rules are checked in order, lengths are counted in characters
+	errs = Struct(request{Base: Base{Name: "abcde"}, Tags: []string{"x", "y", "z"}, Count: 11})
+	assert.Equal(t, Errors{
+		{Field: "tags", Rule: "max", Message: "must have at most 2 items"},
+		{Field: "count", Rule: "max", Message: "must be at most 10"},
+	}, errs)
+	assert.Nil(t, Struct(struct {
+		Title string `validate:"max=3"`
+	}{Title: "ünï"}))
+	assert.Equal(t, "format", Struct(request{Base: Base{Name: "a b"}, Tags: []string{"x"}})[0].Rule)
+	assert.Equal(t, "max", Struct(request{Base: Base{Name: "a.b-c_d"}, Tags: []string{"x"}})[0].Rule)
+}
+
+func TestFormats(t *testing.T) {
+	assert.True(t, matchFormat("line", "UPDATE docs; it's done"))
+	assert.False(t, matchFormat("line", "two\nlines"))
+	assert.False(t, matchFormat("line", "null\x00byte"))
+	assert.True(t, matchFormat("text", "two\nlines\tand a tab"))
+	assert.False(t, matchFormat("text", "escape\x1b[31m"))
+}
+
+func TestStructPanicsOnMalformedTags(t *testing.T) {
+	assert.Panics(t, func() {
+		Struct(struct {
+			Name string `validate:"reqired"`
+		}{Name: "x"})
+	})
+	assert.Panics(t, func() {
+		Struct(struct {
+			Count int `validate:"format=ip"`
+		}{Count: 1})
+	})
+	assert.Panics(t, func() { Struct("not a struct") })
+}
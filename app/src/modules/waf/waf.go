@@ -0,0 +1,151 @@
+// Package waf detects SQL injection attempts in request values, as a defense in depth in front of the
+// parameterized queries of the repositories and the validation of the request bodies.
+//
+// Values are matched case-insensitively against rules describing the structure of injection payloads,
+// e.g. a quote followed by a tautology or a comment, rather than single SQL keywords, so that text such as
+// "UPDATE docs" or "select a plan" is not mistaken for an attack.
+//
+// Usage:
+// Set the mode by setting the environment variable `WAF_MODE` to `off`, `log` to only report the detections,
+// or `block` to refuse the requests, and optionally `WAF_RULES` to a comma separated list of the rules to apply,
+// every rule by default. Then use the `Detect` function to find the rule matched by a value.
+//
+// Example:
+//
+//	if rule, found := waf.GetDetector().Detect(value); found {
+//	    log.Println("Potential SQL injection detected", rule)
+//	}
+package waf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+)
+
+// Mode is what to do with the requests in which an injection is detected.
+type Mode string
+
+// Modes of a Detector.
+const (
+	// ModeOff disables the detection.
+	ModeOff Mode = "off"
+	// ModeLog reports the detections without refusing the requests.
+	ModeLog Mode = "log"
+	// ModeBlock refuses the requests.
+	ModeBlock Mode = "block"
+)
+
+// ErrInvalidMode is returned when parsing a mode that is not off, log or block.
+var ErrInvalidMode = errors.New("invalid WAF mode, expected off, log or block")
+
+// ParseMode parses a mode: `off`, `log` or `block`.
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(value)); mode {
+	case ModeOff, ModeLog, ModeBlock:
+		return mode, nil
+	default:
+		return "", ErrInvalidMode
+	}
+}
+
+// Rule is a named pattern of injection payloads.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Rules are the rules of a Detector by default.
+var Rules = []Rule{
+	// ' UNION SELECT password FROM users
+	{"union_select", regexp.MustCompile(`(?i)\bunion(\s+(all|distinct))?[\s(]+select\b`)},
+	// ' OR 1=1, " or 'a'='a
+	{"tautology", regexp.MustCompile(`(?i)\b(or|and)\s+('[^']*'|"[^"]*"|\d+)\s*(=|<>|!=|like)\s*('[^']*'?|"[^"]*"?|\d+)`)},
+	// admin'--, 1'#, x' /*, 1'); -- drop: a comment ending the input, or after a statement separator
+	{"quote_comment", regexp.MustCompile(`['"](\s*\))*\s*(;\s*(--|#|/\*)|(--|#|/\*)\s*$)`)},
+	// '; DROP TABLE tasks
+	{"stacked_query", regexp.MustCompile(`(?i);\s*(drop\s+(table|database)|delete\s+from|insert\s+into|update\s+\w+\s+set|truncate\s+table|alter\s+table|create\s+table|exec(ute)?\s)`)},
+	// 1 AND pg_sleep(10), BENCHMARK(1000000, MD5(1)), WAITFOR DELAY '0:0:10'
+	{"time_delay", regexp.MustCompile(`(?i)\b(pg_)?sleep\s*\(\s*\d+(\.\d+)?\s*\)|\bbenchmark\s*\(\s*\d+\s*,|\bwaitfor\s+delay\b`)},
+}
+
+// Detector matches values against rules of injection payloads.
+type Detector struct {
+	mode  Mode
+	rules []Rule
+}
+
+// NewDetector returns a new Detector in the given mode, matching values against the given rules.
+func NewDetector(mode Mode, rules []Rule) *Detector {
+	return &Detector{mode: mode, rules: rules}
+}
+
+// Mode returns the mode of the Detector.
+func (d *Detector) Mode() Mode {
+	return d.mode
+}
+
+// Detect returns the name of the first rule matched by the value, and false if none matched or the Detector is off.
+func (d *Detector) Detect(value string) (string, bool) {
+	if d.mode == ModeOff {
+		return "", false
+	}
+	for _, rule := range d.rules {
+		if rule.Pattern.MatchString(value) {
+			return rule.Name, true
+		}
+	}
+	return "", false
+}
+
+// SelectRules returns the named rules among Rules, or every rule if no name is given.
+func SelectRules(names ...string) ([]Rule, error) {
+	var rules []Rule
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, rule := range Rules {
+			if rule.Name == name {
+				rules = append(rules, rule)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown WAF rule %q", name)
+		}
+	}
+	if len(rules) == 0 {
+		return Rules, nil
+	}
+	return rules, nil
+}
+
+// detector is the shared singleton instance of the Detector.
+var detector *Detector
+
+// GetDetector returns the shared singleton instance of the Detector, in the mode set by WAF_MODE, log by default,
+// with the rules listed in WAF_RULES, every rule by default.
+func GetDetector() *Detector {
+	if detector == nil {
+		mode := ModeLog
+		if value := os.Getenv("WAF_MODE"); value != "" {
+			var err error
+			if mode, err = ParseMode(value); err != nil {
+				logger.GetLogger().Fatal("Error parsing WAF mode", "value", value, "error", err)
+			}
+		}
+		rules, err := SelectRules(strings.Split(os.Getenv("WAF_RULES"), ",")...)
+		if err != nil {
+			logger.GetLogger().Fatal("Error parsing WAF rules", "error", err)
+		}
+		detector = NewDetector(mode, rules)
+	}
+	return detector
+}
@@ -0,0 +1,82 @@
+package waf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	d := NewDetector(ModeBlock, Rules)
+
+	attacks := map[string]string{
+		"' UNION SELECT password FROM users --": "union_select",
+		"1 union all select null":               "union_select",
+		"x' or 1=1":                             "tautology",
+		`" OR "a"="a`:                           "tautology",
+		"admin'--":                              "quote_comment",
+		"1'#":                                   "quote_comment",
+		"x' /*":                                 "quote_comment",
+		"1'); -- drop":                          "quote_comment",
+		"1; drop table tasks":                   "stacked_query",
+		"x'; update users set role='admin'":     "stacked_query",
+		"1 and pg_sleep(10)":                    "time_delay",
+		"1 or sleep( 5 )":                       "time_delay",
+		"1 and benchmark(1000000, md5(1))":      "time_delay",
+		"1; WAITFOR DELAY '0:0:10'":             "time_delay",
+	}
+	for value, want := range attacks {
+		rule, found := d.Detect(value)
+		assert.True(t, found, value)
+		assert.Equal(t, want, rule, value)
+	}
+
+	// Text mentioning SQL keywords is not an injection
+	for _, value := range []string{
+		"UPDATE docs",
+		"Select a plan and delete the old one",
+		"Drop table reservations for Friday",
+		"Done; update the changelog",
+		"Ship it or not",
+		"It's 5 o'clock",
+		"Sleep well",
+		"Track sleep (hours)",
+		"Run benchmark (cpu)",
+		`Fix "#123"`,
+		`Fix "#123" and "#124"`,
+		`Rename "--verbose" flag`,
+		`Say "hi" -- then leave`,
+		"size=10",
+	} {
+		rule, found := d.Detect(value)
+		assert.False(t, found, "%s matched %s", value, rule)
+	}
+
+	// Nothing is detected when off
+	_, found := NewDetector(ModeOff, Rules).Detect("x' or 1=1")
+	assert.False(t, found)
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("LOG")
+	assert.NoError(t, err)
+	assert.Equal(t, ModeLog, mode)
+	_, err = ParseMode("audit")
+	assert.ErrorIs(t, err, ErrInvalidMode)
+}
+
+func TestSelectRules(t *testing.T) {
+	rules, err := SelectRules("")
+	require.NoError(t, err)
+	assert.Equal(t, Rules, rules)
+
+	rules, err = SelectRules("tautology", " time_delay")
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "tautology", rules[0].Name)
+	assert.Equal(t, "time_delay", rules[1].Name)
+
+	_, err = SelectRules("keywords")
+	assert.Error(t, err)
+}
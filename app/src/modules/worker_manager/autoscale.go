@@ -0,0 +1,65 @@
+package worker_manager
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+)
+
+// AutoscalePolicy configures the automatic scaling of a worker pool within its bounds.
+// Zero durations disable the corresponding rule.
+type AutoscalePolicy struct {
+	// Interval is how often the pool is checked. At most one worker is added or removed per check.
+	Interval time.Duration
+	// ScaleUpWait adds a worker when a job waited longer than this in the queue since the last check.
+	// A worker is also added when jobs are queued but none started since the last check.
+	ScaleUpWait time.Duration
+	// IdleTimeout removes a worker that has been waiting for a job for this long.
+	IdleTimeout time.Duration
+}
+
+// Autoscale resizes the pool within its bounds according to the policy every policy.Interval,
+// until the context is done. It returns immediately if the interval is 0.
+func (wm *WorkerManager) Autoscale(ctx context.Context, policy AutoscalePolicy) {
+	if policy.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(policy.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			wm.autoscale(policy, now)
+		}
+	}
+}
+
+// autoscale runs a single autoscaling check.
+func (wm *WorkerManager) autoscale(policy AutoscalePolicy, now time.Time) {
+	started := wm.started.Swap(0)
+	wait := time.Duration(wm.maxWait.Swap(0))
+	stalled := started == 0 && wm.QueueLength() > 0
+
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	size := len(wm.Workers)
+	if size < wm.maxWorkers && ((policy.ScaleUpWait > 0 && wait > policy.ScaleUpWait) || stalled) {
+		logger.GetLogger().Info("Scaling worker pool up", "size", size+1, "queue_wait", wait, "queue_length", wm.QueueLength())
+		wm.addWorker(NewWorker(strconv.Itoa(wm.nextID)))
+		return
+	}
+	if size > wm.minWorkers && policy.IdleTimeout > 0 {
+		for i := len(wm.Workers) - 1; i >= 0; i-- {
+			if wm.Workers[i].idleFor(now) >= policy.IdleTimeout {
+				logger.GetLogger().Info("Scaling worker pool down", "size", size-1, "worker_id", wm.Workers[i].ID)
+				wm.removeWorker(i)
+				return
+			}
+		}
+	}
+}
@@ -1,79 +1,249 @@
 // Package worker_manager provides functionality to manage a pool of workers
 // for executing asynchronous jobs.
+//
+// The pool size stays between a minimum and a maximum number of workers. Within these bounds
+// it can be resized at runtime with `Resize`, or automatically with `Autoscale`.
+// Removed workers finish the job they are running before they stop.
 package worker_manager
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"runtime/debug"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/emso-c/konzek-go-assignment/src/modules/logger"
+	"github.com/emso-c/konzek-go-assignment/src/modules/metrics"
+)
+
+var (
+	// ErrQueueFull is returned when a job is added to a worker manager whose queue is full.
+	ErrQueueFull = errors.New("worker queue is full")
+	// ErrInvalidPoolSize is returned when the worker pool is resized out of its bounds.
+	ErrInvalidPoolSize = errors.New("invalid worker pool size")
 )
 
 // Worker represents an individual worker that can execute jobs.
 type Worker struct {
-	ID       string
-	JobQueue chan func()
-	IsFree   bool
+	ID         string
+	JobQueue   chan func()
+	busy       atomic.Bool
+	lastActive atomic.Int64 // unix nanoseconds of the end of the last job, or of the start of the worker
+	quit       chan struct{}
+	done       chan struct{}
+	stopOnce   sync.Once
 }
 
 // NewWorker creates and initializes a new worker with the specified ID.
 func NewWorker(id string) *Worker {
-	logger.GetLogger().Info("Creating worker with ID: " + id)
+	logger.GetLogger().Info("Creating worker", "worker_id", id)
 	return &Worker{
 		ID:       id,
 		JobQueue: make(chan func()),
-		IsFree:   true,
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
 	}
 }
 
 // Start starts the worker, enabling it to execute jobs from its job queue.
+// The worker blocks while its queue is empty and runs until it is stopped or the queue is closed.
 func (w *Worker) Start() {
-	logger.GetLogger().Info("Starting worker with ID: " + w.ID)
+	logger.GetLogger().Info("Starting worker", "worker_id", w.ID)
+	w.lastActive.Store(time.Now().UnixNano())
 	go func() {
+		defer close(w.done)
 		for {
+			// Check for a stop first, select picks randomly among ready cases
 			select {
-			case job := <-w.JobQueue:
-				w.IsFree = false
-				job()
-				w.IsFree = true
+			case <-w.quit:
+				return
+			default:
 			}
+
+			select {
+			case <-w.quit:
+				return
+			case job, ok := <-w.JobQueue:
+				if !ok {
+					return
+				}
+				w.busy.Store(true)
+				w.run(job)
+				w.busy.Store(false)
+				w.lastActive.Store(time.Now().UnixNano())
+			}
+		}
+	}()
+}
+
+// run runs a job, recovering from its panic so that the worker stays alive.
+// Jobs serving a request should recover by themselves, to answer the request and log its ID.
+func (w *Worker) run(job func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			metrics.PanicsRecovered.WithLabelValues("worker").Inc()
+			logger.GetLogger().Error("Recovered from panic in worker job", "worker_id", w.ID, "panic", p, "stack", string(debug.Stack()))
 		}
 	}()
+	job()
+}
+
+// Stop stops the worker gracefully: it finishes the job it is running, if any, and takes no new job.
+// Use Done to wait for the worker to stop.
+func (w *Worker) Stop() {
+	w.stopOnce.Do(func() {
+		logger.GetLogger().Info("Stopping worker", "worker_id", w.ID)
+		close(w.quit)
+	})
+}
+
+// Done returns a channel that is closed once the worker has stopped.
+func (w *Worker) Done() <-chan struct{} {
+	return w.done
 }
 
 // AddJob adds a new job to the worker's job queue.
 func (w *Worker) AddJob(job func()) {
-	logger.GetLogger().Info("Adding job to worker with ID: " + w.ID)
+	logger.GetLogger().Debug("Adding job to worker", "worker_id", w.ID)
 	w.JobQueue <- job
 }
 
 // IsAvailable checks if the worker is available to accept new jobs.
 func (w *Worker) IsAvailable() bool {
-	return w.IsFree
+	return !w.busy.Load()
 }
 
-// WorkerManager manages a pool of workers.
+// idleFor returns for how long the worker has been waiting for a job, 0 if it is running one.
+func (w *Worker) idleFor(now time.Time) time.Duration {
+	if w.busy.Load() {
+		return 0
+	}
+	return now.Sub(time.Unix(0, w.lastActive.Load()))
+}
+
+// WorkerManager manages a pool of workers consuming a shared, bounded job queue.
+// Jobs are started in the order they were added by the first worker to become free.
 type WorkerManager struct {
-	Workers []*Worker
+	Workers    []*Worker
+	mu         sync.Mutex // guards Workers, the bounds and nextID
+	minWorkers int
+	maxWorkers int
+	nextID     int
+	queue      chan func()
+	pending    sync.WaitGroup // jobs queued or running
+
+	// Statistics since the last autoscaling check
+	started atomic.Int64 // number of started jobs
+	maxWait atomic.Int64 // longest queue wait of the started jobs, in nanoseconds
 }
 
-// NewWorkerManager creates and initializes a new worker manager with the specified number of initial workers.
-func NewWorkerManager(initialWorkers int) *WorkerManager {
-	wm := &WorkerManager{}
+// NewWorkerManager creates and initializes a new worker manager with the specified number of initial workers
+// and a queue holding up to queueSize jobs waiting for a worker.
+// The pool size is fixed to the initial number of workers until other bounds are set with SetBounds.
+func NewWorkerManager(initialWorkers int, queueSize int) *WorkerManager {
+	wm := &WorkerManager{
+		minWorkers: initialWorkers,
+		maxWorkers: initialWorkers,
+		queue:      make(chan func(), queueSize),
+	}
 	for i := 0; i < initialWorkers; i++ {
 		wm.AddWorker(NewWorker(strconv.Itoa(i)))
 	}
 	return wm
 }
 
-// AddWorker adds a new worker to the worker manager.
+// AddWorker adds a new worker to the worker manager. The worker consumes the queue of the manager.
 func (wm *WorkerManager) AddWorker(worker *Worker) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	wm.addWorker(worker)
+}
+
+// addWorker adds and starts a worker, wm.mu must be held.
+func (wm *WorkerManager) addWorker(worker *Worker) {
+	worker.JobQueue = wm.queue
 	wm.Workers = append(wm.Workers, worker)
+	wm.nextID++
 	worker.Start()
 }
 
+// removeWorker stops the worker at the given index and removes it from the pool, wm.mu must be held.
+func (wm *WorkerManager) removeWorker(i int) {
+	worker := wm.Workers[i]
+	wm.Workers = append(wm.Workers[:i:i], wm.Workers[i+1:]...)
+	worker.Stop()
+}
+
+// SetBounds sets the minimum and maximum number of workers, and resizes the pool into the new bounds.
+func (wm *WorkerManager) SetBounds(minWorkers int, maxWorkers int) error {
+	if minWorkers < 1 || maxWorkers < minWorkers {
+		return fmt.Errorf("%w: bounds must satisfy 1 <= min (%d) <= max (%d)", ErrInvalidPoolSize, minWorkers, maxWorkers)
+	}
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	wm.minWorkers, wm.maxWorkers = minWorkers, maxWorkers
+	if size := len(wm.Workers); size < minWorkers {
+		wm.resize(minWorkers)
+	} else if size > maxWorkers {
+		wm.resize(maxWorkers)
+	}
+	return nil
+}
+
+// Bounds returns the minimum and maximum number of workers.
+func (wm *WorkerManager) Bounds() (int, int) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	return wm.minWorkers, wm.maxWorkers
+}
+
+// Size returns the number of workers.
+func (wm *WorkerManager) Size() int {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	return len(wm.Workers)
+}
+
+// Resize adds or removes workers to reach the given pool size, which must be within the bounds of the pool.
+// Idle workers are removed first. Removed workers finish their running job before they stop.
+func (wm *WorkerManager) Resize(size int) error {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	if size < wm.minWorkers || size > wm.maxWorkers {
+		return fmt.Errorf("%w: %d is not between %d and %d", ErrInvalidPoolSize, size, wm.minWorkers, wm.maxWorkers)
+	}
+	wm.resize(size)
+	return nil
+}
+
+// resize adds or removes workers to reach the given pool size, wm.mu must be held.
+func (wm *WorkerManager) resize(size int) {
+	logger.GetLogger().Info("Resizing worker pool", "from", len(wm.Workers), "to", size)
+	for len(wm.Workers) < size {
+		wm.addWorker(NewWorker(strconv.Itoa(wm.nextID)))
+	}
+	for len(wm.Workers) > size {
+		// Prefer the most recently added idle worker, or the most recently added worker
+		i := len(wm.Workers) - 1
+		for j := i; j >= 0; j-- {
+			if wm.Workers[j].IsAvailable() {
+				i = j
+				break
+			}
+		}
+		wm.removeWorker(i)
+	}
+}
+
 // GetAvailableWorker retrieves an available worker from the worker manager.
 func (wm *WorkerManager) GetAvailableWorker() *Worker {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
 	for _, worker := range wm.Workers {
 		if worker.IsAvailable() {
 			return worker
@@ -82,29 +252,90 @@ func (wm *WorkerManager) GetAvailableWorker() *Worker {
 	return nil
 }
 
-// AddJob adds a new job to the worker manager.
-func (wm *WorkerManager) AddJob(job func()) {
-	go func() {
+// wrap tracks a job as pending and records its queue wait time.
+func (wm *WorkerManager) wrap(job func()) func() {
+	wm.pending.Add(1)
+	enqueuedAt := time.Now()
+	return func() {
+		defer wm.pending.Done()
+		wait := time.Since(enqueuedAt)
+		metrics.WorkerQueueWait.Observe(wait.Seconds())
+		wm.started.Add(1)
 		for {
-			for _, worker := range wm.Workers {
-				if worker.IsAvailable() {
-					worker.AddJob(job)
-					return
-				}
+			longest := wm.maxWait.Load()
+			if int64(wait) <= longest || wm.maxWait.CompareAndSwap(longest, int64(wait)) {
+				break
 			}
 		}
-	}()
+		job()
+	}
 }
 
-// Start starts all workers in the worker manager.
-func (wm *WorkerManager) Start() {
-	for _, worker := range wm.Workers {
-		worker.Start()
+// AddJob adds a new job to the worker manager, blocking while the queue is full.
+func (wm *WorkerManager) AddJob(job func()) {
+	wm.queue <- wm.wrap(job)
+}
+
+// TryAddJob adds a new job to the worker manager without blocking.
+// It returns ErrQueueFull if the queue is full.
+func (wm *WorkerManager) TryAddJob(job func()) error {
+	wrapped := wm.wrap(job)
+	select {
+	case wm.queue <- wrapped:
+		return nil
+	default:
+		wm.pending.Done()
+		metrics.WorkerQueueRejections.Inc()
+		return ErrQueueFull
+	}
+}
+
+// AddJobContext adds a new job to the worker manager, waiting for room in the queue until the context is done.
+// It returns ErrQueueFull if the queue is still full when the context is done.
+func (wm *WorkerManager) AddJobContext(ctx context.Context, job func()) error {
+	wrapped := wm.wrap(job)
+	select {
+	case wm.queue <- wrapped:
+		return nil
+	case <-ctx.Done():
+		wm.pending.Done()
+		metrics.WorkerQueueRejections.Inc()
+		return ErrQueueFull
+	}
+}
+
+// QueueLength returns the number of jobs waiting for a worker.
+func (wm *WorkerManager) QueueLength() int {
+	return len(wm.queue)
+}
+
+// QueueCapacity returns the maximum number of jobs that can wait for a worker.
+func (wm *WorkerManager) QueueCapacity() int {
+	return cap(wm.queue)
+}
+
+// Shutdown waits for the queued and running jobs to finish, or returns the context error if it is done first.
+// It should be called once no more jobs are added, e.g. after the HTTP server is shut down.
+func (wm *WorkerManager) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		wm.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logger.GetLogger().Info("All worker jobs finished")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
 // GetWorkerStatus returns the status of all workers in the worker manager.
 func (wm *WorkerManager) GetWorkerStatus() map[string]bool {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
 	status := make(map[string]bool)
 	for _, worker := range wm.Workers {
 		status[worker.ID] = worker.IsAvailable()
@@ -112,18 +343,92 @@ func (wm *WorkerManager) GetWorkerStatus() map[string]bool {
 	return status
 }
 
+// defaultQueueSize is the size of the job queue when HTTP_WORKER_QUEUE_SIZE is not set.
+const defaultQueueSize = 64
+
 var vm *WorkerManager = nil
 
+// envInt reads an integer from the given environment variable, or returns the fallback if it is not set.
+func envInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		logger.GetLogger().Fatal("Error parsing worker manager configuration", "name", name, "value", value)
+	}
+	return n
+}
+
+// envSeconds reads a duration in seconds, possibly fractional, from the given environment variable,
+// or returns the fallback if it is not set.
+func envSeconds(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		logger.GetLogger().Fatal("Error parsing worker manager configuration", "name", name, "value", value)
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
 // GetWorkerManager returns a singleton instance of the worker manager.
-// It initializes the worker pool size based on the environment variable HTTP_WORKER_POOL_SIZE.
+// It initializes the worker pool based on the following environment variables:
+//
+//   - HTTP_WORKER_POOL_SIZE: the initial number of workers
+//   - HTTP_WORKER_QUEUE_SIZE: the number of jobs that can wait for a worker
+//   - HTTP_WORKER_POOL_MIN, HTTP_WORKER_POOL_MAX: the bounds of the pool size, the initial size by default
+//
+// The pool is not autoscaled until Autoscale is called, see GetAutoscalePolicy.
 func GetWorkerManager() *WorkerManager {
 	if vm == nil {
 		var pool_size_str = os.Getenv("HTTP_WORKER_POOL_SIZE")
 		pool_size, err := strconv.Atoi(pool_size_str)
 		if err != nil {
-			logger.GetLogger().Fatal("Error parsing worker pool size: " + err.Error())
+			logger.GetLogger().Fatal("Error parsing worker pool size", "error", err)
 		}
-		vm = NewWorkerManager(pool_size)
+		wm := NewWorkerManager(pool_size, envInt("HTTP_WORKER_QUEUE_SIZE", defaultQueueSize))
+		err = wm.SetBounds(envInt("HTTP_WORKER_POOL_MIN", pool_size), envInt("HTTP_WORKER_POOL_MAX", pool_size))
+		if err != nil {
+			logger.GetLogger().Fatal("Error configuring worker pool", "error", err)
+		}
+		vm = wm
+		registerMetrics(vm)
 	}
 	return vm
 }
+
+// GetAutoscalePolicy returns the autoscaling policy of the worker pool based on the following environment variables:
+//
+//   - HTTP_WORKER_SCALE_INTERVAL: how often the pool is autoscaled, in seconds, 0 disables autoscaling
+//   - HTTP_WORKER_SCALE_UP_WAIT: queue wait, in seconds, above which a worker is added
+//   - HTTP_WORKER_IDLE_TIMEOUT: idle time, in seconds, after which a worker is removed
+func GetAutoscalePolicy() AutoscalePolicy {
+	return AutoscalePolicy{
+		Interval:    envSeconds("HTTP_WORKER_SCALE_INTERVAL", 0),
+		ScaleUpWait: envSeconds("HTTP_WORKER_SCALE_UP_WAIT", 0),
+		IdleTimeout: envSeconds("HTTP_WORKER_IDLE_TIMEOUT", 0),
+	}
+}
+
+// registerMetrics registers the busy and idle worker gauges of the worker manager.
+func registerMetrics(wm *WorkerManager) {
+	count := func(available bool) float64 {
+		n := 0
+		for _, isAvailable := range wm.GetWorkerStatus() {
+			if isAvailable == available {
+				n++
+			}
+		}
+		return float64(n)
+	}
+	registry := metrics.GetRegistry()
+	registry.NewGaugeFunc("worker_pool_size", "Number of workers.", func() float64 { return float64(wm.Size()) })
+	registry.NewGaugeFunc("worker_pool_busy_workers", "Number of workers running a job.", func() float64 { return count(false) })
+	registry.NewGaugeFunc("worker_pool_idle_workers", "Number of workers waiting for a job.", func() float64 { return count(true) })
+	registry.NewGaugeFunc("worker_queue_length", "Number of jobs waiting for a worker.", func() float64 { return float64(wm.QueueLength()) })
+	registry.NewGaugeFunc("worker_queue_capacity", "Maximum number of jobs that can wait for a worker.", func() float64 { return float64(wm.QueueCapacity()) })
+}
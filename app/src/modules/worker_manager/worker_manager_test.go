@@ -1,7 +1,11 @@
 package worker_manager
 
 import (
+	"context"
+	"errors"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -20,22 +24,22 @@ func TestWorker(t *testing.T) {
 	}
 
 	worker.Start()
-	jobExecuted := false
+	var jobExecuted atomic.Bool
 	worker.AddJob(func() {
-		jobExecuted = true
+		jobExecuted.Store(true)
 	})
 	// Wait for the job to be executed
 	time.Sleep(time.Millisecond * 100)
-	if !jobExecuted {
+	if !jobExecuted.Load() {
 		t.Error("Expected job to be executed by worker")
 	}
 
-	jobExecuted = false
+	jobExecuted.Store(false)
 	worker.AddJob(func() {
-		jobExecuted = true
+		jobExecuted.Store(true)
 	})
 	// Don't wait for the job to be executed
-	if jobExecuted {
+	if jobExecuted.Load() {
 		t.Error("Expected job to be not done immediately by worker")
 	}
 }
@@ -43,7 +47,7 @@ func TestWorker(t *testing.T) {
 func TestWorkerManager(t *testing.T) {
 	setup()
 
-	wm := NewWorkerManager(2)
+	wm := NewWorkerManager(2, 2)
 	if len(wm.Workers) != 2 {
 		t.Errorf("Expected number of workers to be 2, got %d", len(wm.Workers))
 	}
@@ -53,13 +57,13 @@ func TestWorkerManager(t *testing.T) {
 		t.Error("Expected an available worker, got nil")
 	}
 
-	jobExecuted := false
+	var jobExecuted atomic.Bool
 	wm.AddJob(func() {
-		jobExecuted = true
+		jobExecuted.Store(true)
 	})
 
 	time.Sleep(time.Millisecond * 100)
-	if !jobExecuted {
+	if !jobExecuted.Load() {
 		t.Error("Expected job to be executed by worker")
 	}
 
@@ -70,3 +74,236 @@ func TestWorkerManager(t *testing.T) {
 		}
 	}
 }
+
+func TestWorkerManagerShutdown(t *testing.T) {
+	setup()
+
+	wm := NewWorkerManager(1, 1)
+	release := make(chan struct{})
+	wm.AddJob(func() { <-release })
+
+	// Shutdown times out while a job is running
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := wm.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected shutdown to time out, got %v", err)
+	}
+
+	// Shutdown returns once the jobs are done
+	close(release)
+	if err := wm.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected shutdown to succeed, got %v", err)
+	}
+}
+
+func TestWorkerManagerQueue(t *testing.T) {
+	setup()
+
+	wm := NewWorkerManager(1, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	wm.AddJob(func() {
+		close(started)
+		<-release
+	})
+	<-started
+
+	// One job waits in the queue, the next one is rejected
+	if err := wm.TryAddJob(func() {}); err != nil {
+		t.Errorf("Expected job to be queued, got %v", err)
+	}
+	if wm.QueueLength() != 1 || wm.QueueCapacity() != 1 {
+		t.Errorf("Expected queue length and capacity to be 1, got %d and %d", wm.QueueLength(), wm.QueueCapacity())
+	}
+	if err := wm.TryAddJob(func() {}); err != ErrQueueFull {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := wm.AddJobContext(ctx, func() {}); err != ErrQueueFull {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+
+	// Rejected jobs are not waited for
+	close(release)
+	if err := wm.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected shutdown to succeed, got %v", err)
+	}
+}
+
+func TestWorkerManagerDispatch(t *testing.T) {
+	setup()
+
+	wm := NewWorkerManager(4, 100)
+	var done sync.WaitGroup
+	var count atomic.Int64
+	for i := 0; i < 100; i++ {
+		done.Add(1)
+		wm.AddJob(func() {
+			defer done.Done()
+			count.Add(1)
+		})
+	}
+	done.Wait()
+	if count.Load() != 100 {
+		t.Errorf("Expected 100 jobs to run, got %d", count.Load())
+	}
+}
+
+func TestWorkerStop(t *testing.T) {
+	setup()
+
+	worker := NewWorker("1")
+	worker.Start()
+	release := make(chan struct{})
+	var finished atomic.Bool
+	go worker.AddJob(func() {
+		<-release
+		finished.Store(true)
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	// The running job is finished before the worker stops
+	worker.Stop()
+	worker.Stop()
+	close(release)
+	select {
+	case <-worker.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected worker to stop")
+	}
+	if !finished.Load() {
+		t.Error("Expected the running job to finish")
+	}
+}
+
+func TestWorkerRecoversPanics(t *testing.T) {
+	setup()
+
+	worker := NewWorker("1")
+	worker.Start()
+	defer worker.Stop()
+	worker.AddJob(func() {
+		panic("boom")
+	})
+
+	// The worker survives the panic and runs the next job
+	done := make(chan struct{})
+	worker.AddJob(func() {
+		close(done)
+	})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the worker to run a job after a panic")
+	}
+}
+
+func TestWorkerManagerResize(t *testing.T) {
+	setup()
+
+	wm := NewWorkerManager(2, 10)
+	if err := wm.Resize(3); !errors.Is(err, ErrInvalidPoolSize) {
+		t.Errorf("Expected ErrInvalidPoolSize out of the default bounds, got %v", err)
+	}
+	if err := wm.SetBounds(1, 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := wm.SetBounds(3, 2); err == nil {
+		t.Error("Expected invalid bounds to be rejected")
+	}
+
+	if err := wm.Resize(4); err != nil || wm.Size() != 4 {
+		t.Errorf("Expected 4 workers, got %d (%v)", wm.Size(), err)
+	}
+	removed := wm.Workers[3]
+	if err := wm.Resize(1); err != nil || wm.Size() != 1 {
+		t.Errorf("Expected 1 worker, got %d (%v)", wm.Size(), err)
+	}
+	select {
+	case <-removed.Done():
+	case <-time.After(time.Second):
+		t.Error("Expected removed worker to stop")
+	}
+
+	// Remaining workers still run jobs
+	done := make(chan struct{})
+	wm.AddJob(func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Expected job to be executed after resizing")
+	}
+}
+
+func TestWorkerManagerAutoscale(t *testing.T) {
+	setup()
+
+	wm := NewWorkerManager(1, 10)
+	if err := wm.SetBounds(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	policy := AutoscalePolicy{Interval: time.Second, ScaleUpWait: 10 * time.Millisecond, IdleTimeout: time.Minute}
+
+	// Jobs queued behind a busy worker scale the pool up, up to the maximum
+	release := make(chan struct{})
+	started := make(chan struct{})
+	wm.AddJob(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	wm.started.Store(0)
+	wm.AddJob(func() {})
+	wm.autoscale(policy, time.Now())
+	if wm.Size() != 2 {
+		t.Errorf("Expected the pool to scale up to 2 workers, got %d", wm.Size())
+	}
+	wm.maxWait.Store(int64(time.Second))
+	wm.autoscale(policy, time.Now())
+	if wm.Size() != 2 {
+		t.Errorf("Expected the pool to stay at its maximum, got %d", wm.Size())
+	}
+	close(release)
+	wm.Shutdown(context.Background())
+
+	// Idle workers are removed down to the minimum
+	wm.autoscale(policy, time.Now())
+	if wm.Size() != 2 {
+		t.Errorf("Expected recently active workers to be kept, got %d", wm.Size())
+	}
+	wm.autoscale(policy, time.Now().Add(2*time.Minute))
+	wm.autoscale(policy, time.Now().Add(2*time.Minute))
+	if wm.Size() != 1 {
+		t.Errorf("Expected the pool to scale down to 1 worker, got %d", wm.Size())
+	}
+}
+
+func TestWorkerManagerAutoscaleStops(t *testing.T) {
+	setup()
+
+	wm := NewWorkerManager(1, 1)
+	stopped := func(ctx context.Context, policy AutoscalePolicy) bool {
+		done := make(chan struct{})
+		go func() {
+			wm.Autoscale(ctx, policy)
+			close(done)
+		}()
+		select {
+		case <-done:
+			return true
+		case <-time.After(time.Second):
+			return false
+		}
+	}
+
+	// Autoscaling stops with its context, and does not start without an interval
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if !stopped(ctx, AutoscalePolicy{Interval: time.Millisecond}) {
+		t.Error("Expected Autoscale to return once its context is done")
+	}
+	if !stopped(context.Background(), AutoscalePolicy{}) {
+		t.Error("Expected Autoscale to return without an interval")
+	}
+}
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+)
+
+// ErrAPIKeyNotFound is returned when an API key with the requested ID or prefix does not exist.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKeyRepository defines the storage operations available for API keys.
+type APIKeyRepository interface {
+	// Create stores a new API key and returns it with its generated fields set.
+	Create(ctx context.Context, key models.APIKey) (models.APIKey, error)
+	// GetByPrefix returns the API key with the given prefix, or ErrAPIKeyNotFound.
+	// Revoked and expired keys are returned as well, it is up to the caller to reject them.
+	GetByPrefix(ctx context.Context, prefix string) (models.APIKey, error)
+	// ListByUser returns the API keys of a user, including revoked and expired ones.
+	ListByUser(ctx context.Context, userID uint) ([]models.APIKey, error)
+	// Revoke revokes an API key of a user and returns it, or ErrAPIKeyNotFound.
+	// Revoking an already revoked key keeps its original revocation time.
+	Revoke(ctx context.Context, id uint, userID uint) (models.APIKey, error)
+}
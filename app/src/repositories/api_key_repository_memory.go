@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+)
+
+// MemoryAPIKeyRepository is an APIKeyRepository that keeps API keys in process memory.
+// It is safe for concurrent use and is mainly intended for tests.
+type MemoryAPIKeyRepository struct {
+	mu     sync.RWMutex
+	keys   map[uint]models.APIKey
+	nextID uint
+}
+
+// NewMemoryAPIKeyRepository creates a new, empty MemoryAPIKeyRepository.
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{
+		keys:   make(map[uint]models.APIKey),
+		nextID: 1,
+	}
+}
+
+// Create stores a new API key and returns it with its generated fields set.
+func (r *MemoryAPIKeyRepository) Create(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key.Id = r.nextID
+	key.CreatedAt = time.Now()
+	key.RevokedAt = nil
+	r.keys[key.Id] = key
+	r.nextID++
+	return key, nil
+}
+
+// GetByPrefix returns the API key with the given prefix, or ErrAPIKeyNotFound.
+func (r *MemoryAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return models.APIKey{}, ErrAPIKeyNotFound
+}
+
+// ListByUser returns the API keys of a user, newest first.
+func (r *MemoryAPIKeyRepository) ListByUser(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := []models.APIKey{}
+	for _, key := range r.keys {
+		if key.UserId == userID {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Id > keys[j].Id })
+	return keys, nil
+}
+
+// Revoke revokes an API key of a user and returns it, or ErrAPIKeyNotFound.
+func (r *MemoryAPIKeyRepository) Revoke(ctx context.Context, id uint, userID uint) (models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok || key.UserId != userID {
+		return models.APIKey{}, ErrAPIKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+		r.keys[id] = key
+	}
+	return key, nil
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/lib/pq"
+)
+
+// apiKeyColumns is the list of columns selected for an API key, in models.APIKey field order.
+const apiKeyColumns = "id, user_id, name, prefix, key_hash, scopes, expires_at, revoked_at, created_at"
+
+// PostgresAPIKeyRepository is an APIKeyRepository backed by a PostgreSQL database.
+type PostgresAPIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresAPIKeyRepository creates a new PostgresAPIKeyRepository using the given database connection.
+func NewPostgresAPIKeyRepository(db *sql.DB) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db}
+}
+
+// scanAPIKey scans a single API key row in apiKeyColumns order.
+func scanAPIKey(s scanner) (models.APIKey, error) {
+	var key models.APIKey
+	err := s.Scan(
+		&key.Id, &key.UserId, &key.Name, &key.Prefix, &key.KeyHash,
+		pq.Array(&key.Scopes), &key.ExpiresAt, &key.RevokedAt, &key.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.APIKey{}, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+// Create stores a new API key and returns it with its generated fields set.
+func (r *PostgresAPIKeyRepository) Create(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx,
+		"INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING "+apiKeyColumns,
+		key.UserId, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.ExpiresAt,
+	))
+}
+
+// GetByPrefix returns the API key with the given prefix, or ErrAPIKeyNotFound.
+func (r *PostgresAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = $1", prefix))
+}
+
+// ListByUser returns the API keys of a user, newest first.
+func (r *PostgresAPIKeyRepository) ListByUser(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = $1 ORDER BY id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke revokes an API key of a user and returns it, or ErrAPIKeyNotFound.
+func (r *PostgresAPIKeyRepository) Revoke(ctx context.Context, id uint, userID uint) (models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx,
+		"UPDATE api_keys SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP) WHERE id = $1 AND user_id = $2 RETURNING "+apiKeyColumns,
+		id, userID,
+	))
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresAPIKeyRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresAPIKeyRepository(db)
+	now := time.Now()
+	keyRows := []string{"id", "user_id", "name", "prefix", "key_hash", "scopes", "expires_at", "revoked_at", "created_at"}
+
+	// Create
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)")).
+		WithArgs(1, "ci", "abc", "hash", "{\"tasks:read\"}", nil).
+		WillReturnRows(sqlmock.NewRows(keyRows).AddRow(1, 1, "ci", "abc", "hash", "{tasks:read}", nil, nil, now))
+	key, err := repo.Create(context.Background(), models.APIKey{UserId: 1, Name: "ci", Prefix: "abc", KeyHash: "hash", Scopes: []string{"tasks:read"}})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), key.Id)
+	assert.Equal(t, []string{"tasks:read"}, key.Scopes)
+	assert.Nil(t, key.ExpiresAt)
+
+	// GetByPrefix
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT " + apiKeyColumns + " FROM api_keys WHERE prefix = $1")).
+		WithArgs("abc").
+		WillReturnRows(sqlmock.NewRows(keyRows).AddRow(1, 1, "ci", "abc", "hash", "{tasks:read,tasks:write}", now, nil, now))
+	key, err = repo.GetByPrefix(context.Background(), "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tasks:read", "tasks:write"}, key.Scopes)
+	assert.NotNil(t, key.ExpiresAt)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT " + apiKeyColumns + " FROM api_keys WHERE prefix = $1")).
+		WithArgs("xyz").
+		WillReturnRows(sqlmock.NewRows(keyRows))
+	_, err = repo.GetByPrefix(context.Background(), "xyz")
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	// ListByUser
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT " + apiKeyColumns + " FROM api_keys WHERE user_id = $1 ORDER BY id DESC")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(keyRows).AddRow(1, 1, "ci", "abc", "hash", "{}", nil, nil, now))
+	keys, err := repo.ListByUser(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	// Revoke a key of another user
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE api_keys SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP) WHERE id = $1 AND user_id = $2")).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows(keyRows))
+	_, err = repo.Revoke(context.Background(), 1, 2)
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMemoryAPIKeyRepository(t *testing.T) {
+	repo := NewMemoryAPIKeyRepository()
+
+	first, err := repo.Create(context.Background(), models.APIKey{UserId: 1, Name: "first", Prefix: "aaa"})
+	assert.NoError(t, err)
+	second, _ := repo.Create(context.Background(), models.APIKey{UserId: 1, Name: "second", Prefix: "bbb"})
+	_, _ = repo.Create(context.Background(), models.APIKey{UserId: 2, Name: "other", Prefix: "ccc"})
+
+	found, err := repo.GetByPrefix(context.Background(), "bbb")
+	assert.NoError(t, err)
+	assert.Equal(t, second.Id, found.Id)
+	_, err = repo.GetByPrefix(context.Background(), "zzz")
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	keys, err := repo.ListByUser(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{second.Id, first.Id}, []uint{keys[0].Id, keys[1].Id})
+
+	_, err = repo.Revoke(context.Background(), first.Id, 2)
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+	revoked, err := repo.Revoke(context.Background(), first.Id, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+	again, err := repo.Revoke(context.Background(), first.Id, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, revoked.RevokedAt, again.RevokedAt)
+}
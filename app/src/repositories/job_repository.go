@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+)
+
+// ErrJobNotFound is returned when a job with the requested ID does not exist, or when no job is due.
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrJobNotRetryable is returned when retrying a job that is not dead.
+var ErrJobNotRetryable = errors.New("only dead jobs can be retried")
+
+// JobFilter holds the conditions a job must match to be listed or counted.
+// Zero values are ignored.
+type JobFilter struct {
+	// Status matches the job status exactly.
+	Status string
+	// Type matches the job type exactly.
+	Type string
+}
+
+// JobListOptions holds the parameters used to list jobs.
+type JobListOptions struct {
+	JobFilter
+	Limit  int
+	Offset int
+}
+
+// JobRepository defines the storage operations available for background jobs.
+type JobRepository interface {
+	// Enqueue stores a new pending job and returns it with its generated fields set.
+	// A zero RunAt runs the job as soon as possible.
+	Enqueue(ctx context.Context, job models.Job) (models.Job, error)
+	// Claim marks the oldest due pending job as running, increments its attempts and returns it,
+	// or returns ErrJobNotFound if no job is due. Concurrent claims never return the same job.
+	Claim(ctx context.Context) (models.Job, error)
+	// Complete marks a running job as completed.
+	Complete(ctx context.Context, id uint) error
+	// Fail records the error of a running job and schedules it again at retryAt,
+	// or moves it to the dead state if retryAt is zero.
+	Fail(ctx context.Context, id uint, message string, retryAt time.Time) error
+	// Retry moves a dead job back to pending with its attempts reset and returns it.
+	// It returns ErrJobNotFound or ErrJobNotRetryable.
+	Retry(ctx context.Context, id uint) (models.Job, error)
+	// RequeueStale moves the jobs claimed before the given time and still running back to pending,
+	// e.g. after the process running them crashed, and returns how many were requeued.
+	RequeueStale(ctx context.Context, lockedBefore time.Time) (int, error)
+	// Get returns the job with the given ID, or ErrJobNotFound.
+	Get(ctx context.Context, id uint) (models.Job, error)
+	// List returns the jobs matching the given options, newest first.
+	List(ctx context.Context, opts JobListOptions) ([]models.Job, error)
+	// Count returns the number of jobs matching the filter.
+	Count(ctx context.Context, filter JobFilter) (int, error)
+}
@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+)
+
+// MemoryJobRepository is a JobRepository that keeps jobs in process memory.
+// It is safe for concurrent use and is mainly intended for tests.
+type MemoryJobRepository struct {
+	mu     sync.Mutex
+	jobs   map[uint]models.Job
+	nextID uint
+}
+
+// NewMemoryJobRepository creates a new, empty MemoryJobRepository.
+func NewMemoryJobRepository() *MemoryJobRepository {
+	return &MemoryJobRepository{
+		jobs:   make(map[uint]models.Job),
+		nextID: 1,
+	}
+}
+
+// Enqueue stores a new pending job and returns it with its generated fields set.
+func (r *MemoryJobRepository) Enqueue(ctx context.Context, job models.Job) (models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	job.Id = r.nextID
+	job.Payload = jobPayload(job)
+	job.Status = models.JobPending
+	job.Attempts = 0
+	job.LastError = ""
+	job.LockedAt = nil
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	r.jobs[job.Id] = job
+	r.nextID++
+	return job, nil
+}
+
+// Claim marks the oldest due pending job as running and returns it, or ErrJobNotFound.
+func (r *MemoryJobRepository) Claim(ctx context.Context) (models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var claimed *models.Job
+	for _, job := range r.jobs {
+		if job.Status != models.JobPending || job.RunAt.After(now) {
+			continue
+		}
+		if claimed == nil || job.RunAt.Before(claimed.RunAt) || (job.RunAt.Equal(claimed.RunAt) && job.Id < claimed.Id) {
+			job := job
+			claimed = &job
+		}
+	}
+	if claimed == nil {
+		return models.Job{}, ErrJobNotFound
+	}
+	claimed.Status = models.JobRunning
+	claimed.Attempts++
+	claimed.LockedAt = &now
+	claimed.UpdatedAt = now
+	r.jobs[claimed.Id] = *claimed
+	return *claimed, nil
+}
+
+// Complete marks a running job as completed, or returns ErrJobNotFound.
+func (r *MemoryJobRepository) Complete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok || job.Status != models.JobRunning {
+		return ErrJobNotFound
+	}
+	job.Status = models.JobCompleted
+	job.LockedAt = nil
+	job.UpdatedAt = time.Now()
+	r.jobs[id] = job
+	return nil
+}
+
+// Fail records the error of a running job and schedules it again at retryAt,
+// or moves it to the dead state if retryAt is zero. It returns ErrJobNotFound if the job is not running.
+func (r *MemoryJobRepository) Fail(ctx context.Context, id uint, message string, retryAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok || job.Status != models.JobRunning {
+		return ErrJobNotFound
+	}
+	job.Status = models.JobDead
+	if !retryAt.IsZero() {
+		job.Status = models.JobPending
+		job.RunAt = retryAt
+	}
+	job.LastError = message
+	job.LockedAt = nil
+	job.UpdatedAt = time.Now()
+	r.jobs[id] = job
+	return nil
+}
+
+// Retry moves a dead job back to pending with its attempts reset and returns it.
+func (r *MemoryJobRepository) Retry(ctx context.Context, id uint) (models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return models.Job{}, ErrJobNotFound
+	}
+	if job.Status != models.JobDead {
+		return models.Job{}, ErrJobNotRetryable
+	}
+	now := time.Now()
+	job.Status = models.JobPending
+	job.Attempts = 0
+	job.RunAt = now
+	job.UpdatedAt = now
+	r.jobs[id] = job
+	return job, nil
+}
+
+// RequeueStale moves the jobs claimed before the given time and still running back to pending.
+func (r *MemoryJobRepository) RequeueStale(ctx context.Context, lockedBefore time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for id, job := range r.jobs {
+		if job.Status != models.JobRunning || job.LockedAt == nil || !job.LockedAt.Before(lockedBefore) {
+			continue
+		}
+		job.Status = models.JobPending
+		job.LockedAt = nil
+		job.UpdatedAt = time.Now()
+		r.jobs[id] = job
+		count++
+	}
+	return count, nil
+}
+
+// Get returns the job with the given ID, or ErrJobNotFound.
+func (r *MemoryJobRepository) Get(ctx context.Context, id uint) (models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.jobs[id]
+	if !ok {
+		return models.Job{}, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// matches reports whether a job matches the given filter.
+func (f JobFilter) matches(job models.Job) bool {
+	return (f.Status == "" || job.Status == f.Status) && (f.Type == "" || job.Type == f.Type)
+}
+
+// List returns the jobs matching the given options, newest first.
+func (r *MemoryJobRepository) List(ctx context.Context, opts JobListOptions) ([]models.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := []models.Job{}
+	for _, job := range r.jobs {
+		if opts.matches(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Id > jobs[j].Id })
+
+	if opts.Offset >= len(jobs) {
+		return []models.Job{}, nil
+	}
+	jobs = jobs[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(jobs) {
+		jobs = jobs[:opts.Limit]
+	}
+	return jobs, nil
+}
+
+// Count returns the number of jobs matching the given filter.
+func (r *MemoryJobRepository) Count(ctx context.Context, filter JobFilter) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for _, job := range r.jobs {
+		if filter.matches(job) {
+			count++
+		}
+	}
+	return count, nil
+}
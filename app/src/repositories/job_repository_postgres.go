@@ -0,0 +1,196 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+)
+
+// jobColumns is the list of columns selected for a job, in models.Job field order.
+const jobColumns = "id, type, payload, status, attempts, max_attempts, last_error, run_at, locked_at, created_at, updated_at"
+
+// PostgresJobRepository is a JobRepository backed by a PostgreSQL database.
+// Jobs are claimed with `SELECT ... FOR UPDATE SKIP LOCKED`, so that any number of
+// processes can share the jobs table without claiming the same job twice.
+type PostgresJobRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresJobRepository creates a new PostgresJobRepository using the given database connection.
+func NewPostgresJobRepository(db *sql.DB) *PostgresJobRepository {
+	return &PostgresJobRepository{db: db}
+}
+
+// scanJob scans a single job row in jobColumns order.
+func scanJob(s scanner) (models.Job, error) {
+	var job models.Job
+	var payload []byte
+	err := s.Scan(
+		&job.Id, &job.Type, &payload, &job.Status, &job.Attempts, &job.MaxAttempts,
+		&job.LastError, &job.RunAt, &job.LockedAt, &job.CreatedAt, &job.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Job{}, ErrJobNotFound
+	}
+	job.Payload = payload
+	return job, err
+}
+
+// jobPayload returns the payload of a job, an empty JSON object if it has none.
+func jobPayload(job models.Job) json.RawMessage {
+	if len(job.Payload) == 0 {
+		return json.RawMessage("{}")
+	}
+	return job.Payload
+}
+
+// jobWhereClause builds the WHERE clause and its arguments for the given filter.
+func jobWhereClause(filter JobFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// Enqueue stores a new pending job and returns it with its generated fields set.
+func (r *PostgresJobRepository) Enqueue(ctx context.Context, job models.Job) (models.Job, error) {
+	runAt := sql.NullTime{Time: job.RunAt, Valid: !job.RunAt.IsZero()}
+	return scanJob(r.db.QueryRowContext(ctx,
+		"INSERT INTO jobs (type, payload, max_attempts, run_at) VALUES ($1, $2, $3, COALESCE($4, CURRENT_TIMESTAMP)) RETURNING "+jobColumns,
+		job.Type, []byte(jobPayload(job)), job.MaxAttempts, runAt,
+	))
+}
+
+// Claim marks the oldest due pending job as running and returns it, or ErrJobNotFound.
+// Rows locked by a concurrent claim are skipped rather than waited for.
+func (r *PostgresJobRepository) Claim(ctx context.Context) (models.Job, error) {
+	return scanJob(r.db.QueryRowContext(ctx,
+		"UPDATE jobs SET status = $1, attempts = attempts + 1, locked_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP "+
+			"WHERE id = (SELECT id FROM jobs WHERE status = $2 AND run_at <= CURRENT_TIMESTAMP ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED) "+
+			"RETURNING "+jobColumns,
+		models.JobRunning, models.JobPending,
+	))
+}
+
+// Complete marks a running job as completed, or returns ErrJobNotFound.
+func (r *PostgresJobRepository) Complete(ctx context.Context, id uint) error {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE jobs SET status = $1, locked_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND status = $3",
+		models.JobCompleted, id, models.JobRunning,
+	)
+	return jobAffected(result, err)
+}
+
+// Fail records the error of a running job and schedules it again at retryAt,
+// or moves it to the dead state if retryAt is zero. It returns ErrJobNotFound if the job is not running.
+func (r *PostgresJobRepository) Fail(ctx context.Context, id uint, message string, retryAt time.Time) error {
+	status := models.JobPending
+	runAt := sql.NullTime{Time: retryAt, Valid: !retryAt.IsZero()}
+	if retryAt.IsZero() {
+		status = models.JobDead
+	}
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE jobs SET status = $1, last_error = $2, run_at = COALESCE($3, run_at), locked_at = NULL, updated_at = CURRENT_TIMESTAMP "+
+			"WHERE id = $4 AND status = $5",
+		status, message, runAt, id, models.JobRunning,
+	)
+	return jobAffected(result, err)
+}
+
+// jobAffected turns an update that did not affect any row into ErrJobNotFound.
+func jobAffected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// Retry moves a dead job back to pending with its attempts reset and returns it.
+func (r *PostgresJobRepository) Retry(ctx context.Context, id uint) (models.Job, error) {
+	job, err := scanJob(r.db.QueryRowContext(ctx,
+		"UPDATE jobs SET status = $1, attempts = 0, run_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP "+
+			"WHERE id = $2 AND status = $3 RETURNING "+jobColumns,
+		models.JobPending, id, models.JobDead,
+	))
+	if !errors.Is(err, ErrJobNotFound) {
+		return job, err
+	}
+	// Tell a missing job apart from a job that is not dead
+	if _, err := r.Get(ctx, id); err != nil {
+		return models.Job{}, err
+	}
+	return models.Job{}, ErrJobNotRetryable
+}
+
+// RequeueStale moves the jobs claimed before the given time and still running back to pending.
+func (r *PostgresJobRepository) RequeueStale(ctx context.Context, lockedBefore time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE jobs SET status = $1, locked_at = NULL, updated_at = CURRENT_TIMESTAMP WHERE status = $2 AND locked_at < $3",
+		models.JobPending, models.JobRunning, lockedBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// Get returns the job with the given ID, or ErrJobNotFound.
+func (r *PostgresJobRepository) Get(ctx context.Context, id uint) (models.Job, error) {
+	return scanJob(r.db.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = $1", id))
+}
+
+// List returns the jobs matching the given options, newest first.
+func (r *PostgresJobRepository) List(ctx context.Context, opts JobListOptions) ([]models.Job, error) {
+	where, args := jobWhereClause(opts.JobFilter)
+	args = append(args, opts.Limit, opts.Offset)
+	query := "SELECT " + jobColumns + " FROM jobs" + where +
+		fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Count returns the number of jobs matching the given filter.
+func (r *PostgresJobRepository) Count(ctx context.Context, filter JobFilter) (int, error) {
+	where, args := jobWhereClause(filter)
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM jobs"+where, args...).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresJobRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresJobRepository(db)
+	now := time.Now()
+	jobRows := []string{"id", "type", "payload", "status", "attempts", "max_attempts", "last_error", "run_at", "locked_at", "created_at", "updated_at"}
+
+	// Enqueue
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO jobs (type, payload, max_attempts, run_at)")).
+		WithArgs("email", []byte(`{"to":"alice"}`), 3, nil).
+		WillReturnRows(sqlmock.NewRows(jobRows).AddRow(1, "email", []byte(`{"to":"alice"}`), "pending", 0, 3, "", now, nil, now, now))
+	job, err := repo.Enqueue(context.Background(), models.Job{Type: "email", Payload: json.RawMessage(`{"to":"alice"}`), MaxAttempts: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), job.Id)
+	assert.JSONEq(t, `{"to":"alice"}`, string(job.Payload))
+
+	// Claim
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE SKIP LOCKED")).
+		WithArgs(models.JobRunning, models.JobPending).
+		WillReturnRows(sqlmock.NewRows(jobRows).AddRow(1, "email", []byte(`{}`), "running", 1, 3, "", now, now, now, now))
+	job, err = repo.Claim(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, models.JobRunning, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE SKIP LOCKED")).
+		WithArgs(models.JobRunning, models.JobPending).
+		WillReturnRows(sqlmock.NewRows(jobRows))
+	_, err = repo.Claim(context.Background())
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	// Fail with a retry, then for good
+	retryAt := now.Add(time.Minute)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE jobs SET status = $1, last_error = $2, run_at = COALESCE($3, run_at)")).
+		WithArgs(models.JobPending, "boom", retryAt, 1, models.JobRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.Fail(context.Background(), 1, "boom", retryAt))
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE jobs SET status = $1, last_error = $2, run_at = COALESCE($3, run_at)")).
+		WithArgs(models.JobDead, "boom", nil, 1, models.JobRunning).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.Fail(context.Background(), 1, "boom", time.Time{}), ErrJobNotFound)
+
+	// Retry a job that is not dead
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE jobs SET status = $1, attempts = 0")).
+		WithArgs(models.JobPending, 1, models.JobDead).
+		WillReturnRows(sqlmock.NewRows(jobRows))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT " + jobColumns + " FROM jobs WHERE id = $1")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(jobRows).AddRow(1, "email", []byte(`{}`), "completed", 1, 3, "", now, nil, now, now))
+	_, err = repo.Retry(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrJobNotRetryable)
+
+	// List and Count
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT "+jobColumns+" FROM jobs WHERE status = $1 ORDER BY id DESC LIMIT $2 OFFSET $3")).
+		WithArgs(models.JobDead, 10, 0).
+		WillReturnRows(sqlmock.NewRows(jobRows).AddRow(2, "email", []byte(`{}`), "dead", 3, 3, "boom", now, nil, now, now))
+	jobs, err := repo.List(context.Background(), JobListOptions{JobFilter: JobFilter{Status: models.JobDead}, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM jobs WHERE status = $1 AND type = $2")).
+		WithArgs(models.JobDead, "email").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	count, err := repo.Count(context.Background(), JobFilter{Status: models.JobDead, Type: "email"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMemoryJobRepository(t *testing.T) {
+	repo := NewMemoryJobRepository()
+	ctx := context.Background()
+
+	first, err := repo.Enqueue(ctx, models.Job{Type: "email", MaxAttempts: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, models.JobPending, first.Status)
+	assert.JSONEq(t, `{}`, string(first.Payload))
+	_, _ = repo.Enqueue(ctx, models.Job{Type: "report", RunAt: time.Now().Add(time.Hour)})
+
+	// Only the due job is claimed
+	job, err := repo.Claim(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, first.Id, job.Id)
+	assert.Equal(t, 1, job.Attempts)
+	_, err = repo.Claim(ctx)
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	// A failure with a retry time makes the job pending again
+	assert.NoError(t, repo.Fail(ctx, job.Id, "boom", time.Now().Add(-time.Second)))
+	job, err = repo.Claim(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, "boom", job.LastError)
+
+	// A dead job can be retried, other jobs cannot
+	_, err = repo.Retry(ctx, job.Id)
+	assert.ErrorIs(t, err, ErrJobNotRetryable)
+	assert.NoError(t, repo.Fail(ctx, job.Id, "boom again", time.Time{}))
+	assert.ErrorIs(t, repo.Complete(ctx, job.Id), ErrJobNotFound)
+	job, err = repo.Retry(ctx, job.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, models.JobPending, job.Status)
+	assert.Equal(t, 0, job.Attempts)
+	_, err = repo.Retry(ctx, 99)
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	// Stale running jobs are requeued
+	job, _ = repo.Claim(ctx)
+	count, err := repo.RequeueStale(ctx, time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	job, _ = repo.Claim(ctx)
+	assert.NoError(t, repo.Complete(ctx, job.Id))
+
+	jobs, err := repo.List(ctx, JobListOptions{JobFilter: JobFilter{Status: models.JobCompleted}, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+	count, err = repo.Count(ctx, JobFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
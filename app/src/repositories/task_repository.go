@@ -0,0 +1,100 @@
+// Package repositories provides storage abstractions for the application's models.
+// Each repository is described by an interface so that HTTP controllers do not depend
+// on a concrete storage engine. A PostgreSQL implementation is used in production,
+// while an in-memory implementation is available for tests and local development.
+// Every method takes a context, usually the request context, that cancels the database queries when it is done.
+//
+// Usage:
+// Create a repository and inject it into a controller.
+//
+// Example:
+// repo := repositories.NewPostgresTaskRepository(database.GetDatabase())
+// tc := controllers.NewTaskController(repo)
+//
+// Or, for tests:
+// repo := repositories.NewMemoryTaskRepository()
+// tc := controllers.NewTaskController(repo)
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+)
+
+// ErrTaskNotFound is returned when a task with the requested ID does not exist.
+var ErrTaskNotFound = errors.New("task not found")
+
+// ErrTaskStatusChanged is returned by TaskRepository.Transition and TaskRepository.Update when the task
+// no longer has the expected status, or, for Transition, no longer exists.
+var ErrTaskStatusChanged = errors.New("task status changed concurrently")
+
+// TaskSortColumns lists the columns tasks can be sorted by.
+var TaskSortColumns = []string{"id", "title", "description", "status", "created_at", "updated_at"}
+
+// IsTaskSortColumn reports whether the given column can be used to sort tasks.
+func IsTaskSortColumn(column string) bool {
+	for _, c := range TaskSortColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskFilter holds the conditions a task must match to be listed or counted.
+// Zero values are ignored.
+type TaskFilter struct {
+	// OwnerID matches the tasks created by the given user.
+	OwnerID uint
+	// Status matches the task status exactly.
+	Status string
+	// Title and Description match a case-insensitive substring of the respective field.
+	Title       string
+	Description string
+	// Query is a full-text search over the title and description.
+	Query string
+	// CreatedAfter, CreatedBefore, UpdatedAfter and UpdatedBefore are inclusive date bounds.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+}
+
+// TaskListOptions holds the parameters used to list tasks.
+type TaskListOptions struct {
+	TaskFilter
+	// SortBy is one of TaskSortColumns, defaults to "id".
+	SortBy string
+	// SortDesc sorts in descending order when true.
+	SortDesc bool
+	// AfterID enables keyset pagination when set: only tasks past this ID in the
+	// sort direction are returned. It requires SortBy to be "id" and Offset to be 0.
+	AfterID uint
+	Limit   int
+	Offset  int
+}
+
+// TaskRepository defines the storage operations available for tasks.
+type TaskRepository interface {
+	// List returns the tasks matching the given options.
+	List(ctx context.Context, opts TaskListOptions) ([]models.Task, error)
+	// Get returns the task with the given ID, or ErrTaskNotFound.
+	Get(ctx context.Context, id uint) (models.Task, error)
+	// Create stores a new task owned by the given user and returns it with its generated fields set.
+	Create(ctx context.Context, req models.CreateTaskRequest, ownerID uint) (models.Task, error)
+	// Update overwrites the title, description and status of an existing task in the from status
+	// and returns the updated task, ErrTaskNotFound, or ErrTaskStatusChanged if the task is not in the from status.
+	// The status change, if any, must be validated by the caller.
+	Update(ctx context.Context, task models.Task, from string) (models.Task, error)
+	// Transition atomically changes the status of a task from one status to another
+	// and returns the updated task, or ErrTaskStatusChanged if the task is not in the from status.
+	// The transition itself must be validated by the caller.
+	Transition(ctx context.Context, id uint, from string, to string) (models.Task, error)
+	// Delete removes the task with the given ID, or returns ErrTaskNotFound.
+	Delete(ctx context.Context, id uint) error
+	// Count returns the number of tasks matching the given filter.
+	Count(ctx context.Context, filter TaskFilter) (int, error)
+}
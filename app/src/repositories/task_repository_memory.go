@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+)
+
+// MemoryTaskRepository is a TaskRepository that keeps tasks in process memory.
+// It is safe for concurrent use and is mainly intended for tests.
+type MemoryTaskRepository struct {
+	mu     sync.RWMutex
+	tasks  map[uint]models.Task
+	nextID uint
+}
+
+// NewMemoryTaskRepository creates a new, empty MemoryTaskRepository.
+func NewMemoryTaskRepository() *MemoryTaskRepository {
+	return &MemoryTaskRepository{
+		tasks:  make(map[uint]models.Task),
+		nextID: 1,
+	}
+}
+
+// matches reports whether the task matches the given filter.
+// Full-text search is approximated by requiring every query word to appear
+// in the title or description.
+func matches(task models.Task, filter TaskFilter) bool {
+	if filter.OwnerID != 0 && task.OwnerId != filter.OwnerID {
+		return false
+	}
+	if filter.Status != "" && task.Status != filter.Status {
+		return false
+	}
+	if filter.Title != "" && !containsFold(task.Title, filter.Title) {
+		return false
+	}
+	if filter.Description != "" && !containsFold(task.Description, filter.Description) {
+		return false
+	}
+	for _, word := range strings.Fields(filter.Query) {
+		if !containsFold(task.Title+" "+task.Description, word) {
+			return false
+		}
+	}
+	if !filter.CreatedAfter.IsZero() && task.CreatedAt.Before(filter.CreatedAfter) {
+		return false
+	}
+	if !filter.CreatedBefore.IsZero() && task.CreatedAt.After(filter.CreatedBefore) {
+		return false
+	}
+	if !filter.UpdatedAfter.IsZero() && task.UpdatedAt.Before(filter.UpdatedAfter) {
+		return false
+	}
+	if !filter.UpdatedBefore.IsZero() && task.UpdatedAt.After(filter.UpdatedBefore) {
+		return false
+	}
+	return true
+}
+
+// containsFold reports whether substr is within s, ignoring case.
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// less reports whether task a sorts before task b by the given column in ascending order.
+// Ties are broken by ID.
+func less(a models.Task, b models.Task, column string) bool {
+	switch column {
+	case "title":
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+	case "description":
+		if a.Description != b.Description {
+			return a.Description < b.Description
+		}
+	case "status":
+		if a.Status != b.Status {
+			return a.Status < b.Status
+		}
+	case "created_at":
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+	case "updated_at":
+		if !a.UpdatedAt.Equal(b.UpdatedAt) {
+			return a.UpdatedAt.Before(b.UpdatedAt)
+		}
+	}
+	return a.Id < b.Id
+}
+
+// filtered returns the tasks matching the given filter. The caller must hold the lock.
+func (r *MemoryTaskRepository) filtered(filter TaskFilter) []models.Task {
+	tasks := make([]models.Task, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		if matches(task, filter) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// List returns the tasks matching the given options.
+func (r *MemoryTaskRepository) List(ctx context.Context, opts TaskListOptions) ([]models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := r.filtered(opts.TaskFilter)
+	if opts.AfterID > 0 {
+		page := tasks[:0]
+		for _, task := range tasks {
+			if (!opts.SortDesc && task.Id > opts.AfterID) || (opts.SortDesc && task.Id < opts.AfterID) {
+				page = append(page, task)
+			}
+		}
+		tasks = page
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if opts.SortDesc {
+			return less(tasks[j], tasks[i], opts.SortBy)
+		}
+		return less(tasks[i], tasks[j], opts.SortBy)
+	})
+
+	if opts.Offset >= len(tasks) {
+		return nil, nil
+	}
+	tasks = tasks[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(tasks) {
+		tasks = tasks[:opts.Limit]
+	}
+	return tasks, nil
+}
+
+// Get returns the task with the given ID, or ErrTaskNotFound.
+func (r *MemoryTaskRepository) Get(ctx context.Context, id uint) (models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return models.Task{}, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// Create stores a new task owned by the given user and returns it with its generated fields set.
+func (r *MemoryTaskRepository) Create(ctx context.Context, req models.CreateTaskRequest, ownerID uint) (models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	task := models.Task{
+		Id:          r.nextID,
+		Title:       req.Title,
+		Description: req.Description,
+		Status:      req.Status,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		OwnerId:     ownerID,
+	}
+	r.tasks[task.Id] = task
+	r.nextID++
+	return task, nil
+}
+
+// Update overwrites the title, description and status of an existing task in the from status
+// and returns the updated task, ErrTaskNotFound, or ErrTaskStatusChanged if the task is not in the from status.
+func (r *MemoryTaskRepository) Update(ctx context.Context, task models.Task, from string) (models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.tasks[task.Id]
+	if !ok {
+		return models.Task{}, ErrTaskNotFound
+	}
+	if existing.Status != from {
+		return models.Task{}, ErrTaskStatusChanged
+	}
+	existing.Title = task.Title
+	existing.Description = task.Description
+	existing.Status = task.Status
+	existing.UpdatedAt = time.Now()
+	r.tasks[task.Id] = existing
+	return existing, nil
+}
+
+// Transition atomically changes the status of a task from one status to another
+// and returns the updated task, or ErrTaskStatusChanged if the task is not in the from status.
+func (r *MemoryTaskRepository) Transition(ctx context.Context, id uint, from string, to string) (models.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	task, ok := r.tasks[id]
+	if !ok || task.Status != from {
+		return models.Task{}, ErrTaskStatusChanged
+	}
+	task.Status = to
+	task.UpdatedAt = time.Now()
+	r.tasks[id] = task
+	return task, nil
+}
+
+// Delete removes the task with the given ID, or returns ErrTaskNotFound.
+func (r *MemoryTaskRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tasks[id]; !ok {
+		return ErrTaskNotFound
+	}
+	delete(r.tasks, id)
+	return nil
+}
+
+// Count returns the number of tasks matching the given filter.
+func (r *MemoryTaskRepository) Count(ctx context.Context, filter TaskFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.filtered(filter)), nil
+}
@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+)
+
+// taskColumns is the list of columns selected for a task, in models.Task field order.
+const taskColumns = "id, title, description, status, created_at, updated_at, COALESCE(owner_id, 0)"
+
+// taskSearchVector is the tsvector expression used for full-text search.
+// It must match the expression of the tasks_search_idx index created by the database package.
+const taskSearchVector = "to_tsvector('english', title || ' ' || description)"
+
+// PostgresTaskRepository is a TaskRepository backed by a PostgreSQL database.
+// All queries are parameterized.
+type PostgresTaskRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresTaskRepository creates a new PostgresTaskRepository using the given database connection.
+func NewPostgresTaskRepository(db *sql.DB) *PostgresTaskRepository {
+	return &PostgresTaskRepository{db: db}
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask scans a single task row in taskColumns order.
+func scanTask(s scanner) (models.Task, error) {
+	var task models.Task
+	err := s.Scan(&task.Id, &task.Title, &task.Description, &task.Status, &task.CreatedAt, &task.UpdatedAt, &task.OwnerId)
+	return task, err
+}
+
+// whereClause builds a parameterized WHERE clause for the given filter.
+// It returns an empty string if the filter has no conditions.
+func whereClause(filter TaskFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OwnerID != 0 {
+		add("owner_id = $%d", filter.OwnerID)
+	}
+	if filter.Status != "" {
+		add("status = $%d", filter.Status)
+	}
+	if filter.Title != "" {
+		add("title ILIKE $%d", "%"+escapeLike(filter.Title)+"%")
+	}
+	if filter.Description != "" {
+		add("description ILIKE $%d", "%"+escapeLike(filter.Description)+"%")
+	}
+	if filter.Query != "" {
+		add(taskSearchVector+" @@ plainto_tsquery('english', $%d)", filter.Query)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		add("created_at >= $%d", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		add("created_at <= $%d", filter.CreatedBefore)
+	}
+	if !filter.UpdatedAfter.IsZero() {
+		add("updated_at >= $%d", filter.UpdatedAfter)
+	}
+	if !filter.UpdatedBefore.IsZero() {
+		add("updated_at <= $%d", filter.UpdatedBefore)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// escapeLike escapes the LIKE wildcard characters in the given string.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// orderClause builds the ORDER BY clause for the given options.
+// The sort column is checked against TaskSortColumns since it cannot be parameterized.
+func orderClause(opts TaskListOptions) string {
+	column := opts.SortBy
+	if !IsTaskSortColumn(column) {
+		column = "id"
+	}
+	direction := "ASC"
+	if opts.SortDesc {
+		direction = "DESC"
+	}
+	if column == "id" {
+		return " ORDER BY id " + direction
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction)
+}
+
+// List returns the tasks matching the given options.
+func (r *PostgresTaskRepository) List(ctx context.Context, opts TaskListOptions) ([]models.Task, error) {
+	where, args := whereClause(opts.TaskFilter)
+	if opts.AfterID > 0 {
+		args = append(args, opts.AfterID)
+		condition := fmt.Sprintf("id > $%d", len(args))
+		if opts.SortDesc {
+			condition = fmt.Sprintf("id < $%d", len(args))
+		}
+		if where == "" {
+			where = " WHERE " + condition
+		} else {
+			where += " AND " + condition
+		}
+	}
+	args = append(args, opts.Limit, opts.Offset)
+	query := "SELECT " + taskColumns + " FROM tasks" + where + orderClause(opts) +
+		fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// Get returns the task with the given ID, or ErrTaskNotFound.
+func (r *PostgresTaskRepository) Get(ctx context.Context, id uint) (models.Task, error) {
+	task, err := scanTask(r.db.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Task{}, ErrTaskNotFound
+	}
+	return task, err
+}
+
+// Create stores a new task owned by the given user and returns it with its generated fields set.
+func (r *PostgresTaskRepository) Create(ctx context.Context, req models.CreateTaskRequest, ownerID uint) (models.Task, error) {
+	return scanTask(r.db.QueryRowContext(ctx,
+		"INSERT INTO tasks (title, description, status, owner_id) VALUES ($1, $2, $3, $4) RETURNING "+taskColumns,
+		req.Title, req.Description, req.Status, ownerID,
+	))
+}
+
+// Update overwrites the title, description and status of an existing task in the from status
+// and returns the updated task, ErrTaskNotFound, or ErrTaskStatusChanged if the task is not in the from status.
+func (r *PostgresTaskRepository) Update(ctx context.Context, task models.Task, from string) (models.Task, error) {
+	updated, err := scanTask(r.db.QueryRowContext(ctx,
+		"UPDATE tasks SET title = $1, description = $2, status = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4 AND status = $5 RETURNING "+taskColumns,
+		task.Title, task.Description, task.Status, task.Id, from,
+	))
+	if !errors.Is(err, sql.ErrNoRows) {
+		return updated, err
+	}
+	// Tell a missing task from a task whose status changed
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1)", task.Id).Scan(&exists); err != nil {
+		return models.Task{}, err
+	}
+	if exists {
+		return models.Task{}, ErrTaskStatusChanged
+	}
+	return models.Task{}, ErrTaskNotFound
+}
+
+// Transition atomically changes the status of a task from one status to another
+// and returns the updated task, or ErrTaskStatusChanged if the task is not in the from status.
+func (r *PostgresTaskRepository) Transition(ctx context.Context, id uint, from string, to string) (models.Task, error) {
+	task, err := scanTask(r.db.QueryRowContext(ctx,
+		"UPDATE tasks SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND status = $3 RETURNING "+taskColumns,
+		to, id, from,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Task{}, ErrTaskStatusChanged
+	}
+	return task, err
+}
+
+// Delete removes the task with the given ID, or returns ErrTaskNotFound.
+func (r *PostgresTaskRepository) Delete(ctx context.Context, id uint) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+// Count returns the number of tasks matching the given filter.
+func (r *PostgresTaskRepository) Count(ctx context.Context, filter TaskFilter) (int, error) {
+	where, args := whereClause(filter)
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks"+where, args...).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var columns = []string{"id", "title", "description", "status", "created_at", "updated_at", "owner_id"}
+
+func TestPostgresTaskRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresTaskRepository(db)
+	now := time.Now()
+
+	// List
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT "+taskColumns+" FROM tasks ORDER BY id ASC LIMIT $1 OFFSET $2")).
+		WithArgs(10, 20).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Title", "Description", "pending", now, now, 1))
+	tasks, err := repo.List(context.Background(), TaskListOptions{Limit: 10, Offset: 20})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	// List with filters and sorting
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT "+taskColumns+" FROM tasks WHERE status = $1 AND title ILIKE $2 AND "+
+		taskSearchVector+" @@ plainto_tsquery('english', $3) AND created_at >= $4 ORDER BY created_at DESC, id DESC LIMIT $5 OFFSET $6")).
+		WithArgs("pending", `%100\%%`, "weekly report", now, 5, 0).
+		WillReturnRows(sqlmock.NewRows(columns))
+	tasks, err = repo.List(context.Background(), TaskListOptions{
+		TaskFilter: TaskFilter{Status: "pending", Title: "100%", Query: "weekly report", CreatedAfter: now},
+		SortBy:     "created_at",
+		SortDesc:   true,
+		Limit:      5,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, tasks)
+
+	// List with a keyset cursor
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT "+taskColumns+" FROM tasks WHERE owner_id = $1 AND status = $2 AND id < $3 ORDER BY id DESC LIMIT $4 OFFSET $5")).
+		WithArgs(1, "pending", 50, 10, 0).
+		WillReturnRows(sqlmock.NewRows(columns))
+	_, err = repo.List(context.Background(), TaskListOptions{TaskFilter: TaskFilter{OwnerID: 1, Status: "pending"}, SortBy: "id", SortDesc: true, AfterID: 50, Limit: 10})
+	assert.NoError(t, err)
+
+	// List never interpolates an unknown sort column
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT "+taskColumns+" FROM tasks ORDER BY id ASC LIMIT $1 OFFSET $2")).
+		WithArgs(10, 0).
+		WillReturnRows(sqlmock.NewRows(columns))
+	_, err = repo.List(context.Background(), TaskListOptions{SortBy: "id; DROP TABLE tasks", Limit: 10})
+	assert.NoError(t, err)
+
+	// Get
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT " + taskColumns + " FROM tasks WHERE id = $1")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "Title", "Description", "pending", now, now, 1))
+	task, err := repo.Get(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Title", task.Title)
+
+	// Get unknown task
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT " + taskColumns + " FROM tasks WHERE id = $1")).
+		WithArgs(2).
+		WillReturnError(sql.ErrNoRows)
+	_, err = repo.Get(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	// Create, the values must be passed as arguments rather than inlined
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO tasks (title, description, status, owner_id) VALUES ($1, $2, $3, $4)")).
+		WithArgs("'; DROP TABLE tasks; --", "Description", "pending", 1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, "'; DROP TABLE tasks; --", "Description", "pending", now, now, 1))
+	task, err = repo.Create(context.Background(), models.CreateTaskRequest{Title: "'; DROP TABLE tasks; --", Description: "Description", Status: "pending"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), task.Id)
+	assert.Equal(t, uint(1), task.OwnerId)
+
+	// Update, only from the expected status
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE tasks SET title = $1, description = $2, status = $3, updated_at = CURRENT_TIMESTAMP WHERE id = $4 AND status = $5")).
+		WithArgs("Title", "Description", "completed", 3, "in_progress").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, "Title", "Description", "completed", now, now, 1))
+	task, err = repo.Update(context.Background(), models.Task{Id: 3, Title: "Title", Description: "Description", Status: "completed"}, "in_progress")
+	assert.NoError(t, err)
+	assert.Equal(t, "completed", task.Status)
+
+	// Update a task whose status changed
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE tasks SET title = $1, description = $2, status = $3")).
+		WithArgs("Title", "Description", "in_progress", 3, "pending").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1)")).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	_, err = repo.Update(context.Background(), models.Task{Id: 3, Title: "Title", Description: "Description", Status: "in_progress"}, "pending")
+	assert.ErrorIs(t, err, ErrTaskStatusChanged)
+
+	// Update unknown task
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE tasks SET title = $1, description = $2, status = $3")).
+		WithArgs("Title", "Description", "completed", 4, "pending").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM tasks WHERE id = $1)")).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	_, err = repo.Update(context.Background(), models.Task{Id: 4, Title: "Title", Description: "Description", Status: "completed"}, "pending")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	// Delete
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM tasks WHERE id = $1")).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.Delete(context.Background(), 1))
+
+	// Delete unknown task
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM tasks WHERE id = $1")).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.Delete(context.Background(), 5), ErrTaskNotFound)
+
+	// Count
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM tasks WHERE status = $1")).
+		WithArgs("pending").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	count, err := repo.Count(context.Background(), TaskFilter{Status: "pending"})
+	assert.NoError(t, err)
+	assert.Equal(t, 7, count)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMemoryTaskRepository(t *testing.T) {
+	repo := NewMemoryTaskRepository()
+
+	for i := 0; i < 3; i++ {
+		_, err := repo.Create(context.Background(), models.CreateTaskRequest{Title: "Title", Description: "Description", Status: "pending"}, uint(i%2+1))
+		assert.NoError(t, err)
+	}
+
+	count, err := repo.Count(context.Background(), TaskFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	tasks, err := repo.List(context.Background(), TaskListOptions{SortDesc: true})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), tasks[0].Id)
+
+	count, err = repo.Count(context.Background(), TaskFilter{OwnerID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	tasks, err = repo.List(context.Background(), TaskListOptions{Limit: 2, Offset: 1})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, uint(2), tasks[0].Id)
+
+	tasks, err = repo.List(context.Background(), TaskListOptions{Limit: 2, AfterID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{2, 3}, []uint{tasks[0].Id, tasks[1].Id})
+
+	tasks, err = repo.List(context.Background(), TaskListOptions{Limit: 2, Offset: 10})
+	assert.NoError(t, err)
+	assert.Nil(t, tasks)
+
+	updated, err := repo.Update(context.Background(), models.Task{Id: 2, Title: "New", Description: "Weekly report", Status: "completed"}, "pending")
+	assert.NoError(t, err)
+	assert.Equal(t, "New", updated.Title)
+
+	_, err = repo.Update(context.Background(), models.Task{Id: 2, Title: "Newer", Status: "in_progress"}, "pending")
+	assert.ErrorIs(t, err, ErrTaskStatusChanged)
+
+	tasks, err = repo.List(context.Background(), TaskListOptions{TaskFilter: TaskFilter{Status: "completed", Query: "REPORT weekly"}})
+	assert.NoError(t, err)
+	assert.Len(t, tasks, 1)
+
+	count, err = repo.Count(context.Background(), TaskFilter{Description: "descr"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	tasks, err = repo.List(context.Background(), TaskListOptions{SortBy: "title"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), tasks[0].Id)
+
+	_, err = repo.Update(context.Background(), models.Task{Id: 42}, "pending")
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+
+	assert.NoError(t, repo.Delete(context.Background(), 2))
+	assert.ErrorIs(t, repo.Delete(context.Background(), 2), ErrTaskNotFound)
+
+	_, err = repo.Get(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+)
+
+var (
+	// ErrUserNotFound is returned when a user with the requested ID or username does not exist.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists is returned when a username is already taken.
+	ErrUserExists = errors.New("user already exists")
+	// ErrRefreshTokenNotFound is returned when a refresh token is unknown, expired or already used.
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+)
+
+// UserRepository defines the storage operations available for users and their refresh tokens.
+type UserRepository interface {
+	// Create stores a new user with the default role and returns it with its generated fields set,
+	// or ErrUserExists.
+	Create(ctx context.Context, username string, passwordHash string) (models.User, error)
+	// Get returns the user with the given ID, or ErrUserNotFound.
+	Get(ctx context.Context, id uint) (models.User, error)
+	// GetByUsername returns the user with the given username, or ErrUserNotFound.
+	GetByUsername(ctx context.Context, username string) (models.User, error)
+	// SaveRefreshToken stores the hash of a refresh token issued to a user.
+	SaveRefreshToken(ctx context.Context, userID uint, tokenHash string, expiresAt time.Time) error
+	// ConsumeRefreshToken deletes a valid refresh token and returns the ID of the user it was issued to,
+	// or ErrRefreshTokenNotFound. A refresh token can only be consumed once.
+	ConsumeRefreshToken(ctx context.Context, tokenHash string) (uint, error)
+}
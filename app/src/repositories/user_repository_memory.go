@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+)
+
+// refreshToken is a refresh token stored by MemoryUserRepository.
+type refreshToken struct {
+	userID    uint
+	expiresAt time.Time
+}
+
+// MemoryUserRepository is a UserRepository that keeps users in process memory.
+// It is safe for concurrent use and is mainly intended for tests.
+type MemoryUserRepository struct {
+	mu            sync.RWMutex
+	users         map[uint]models.User
+	refreshTokens map[string]refreshToken
+	nextID        uint
+}
+
+// NewMemoryUserRepository creates a new, empty MemoryUserRepository.
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:         make(map[uint]models.User),
+		refreshTokens: make(map[string]refreshToken),
+		nextID:        1,
+	}
+}
+
+// Create stores a new user and returns it with its generated fields set, or ErrUserExists.
+func (r *MemoryUserRepository) Create(ctx context.Context, username string, passwordHash string) (models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if user.Username == username {
+			return models.User{}, ErrUserExists
+		}
+	}
+	user := models.User{
+		Id:           r.nextID,
+		Username:     username,
+		PasswordHash: passwordHash,
+		Role:         "user",
+		CreatedAt:    time.Now(),
+	}
+	r.users[user.Id] = user
+	r.nextID++
+	return user, nil
+}
+
+// Get returns the user with the given ID, or ErrUserNotFound.
+func (r *MemoryUserRepository) Get(ctx context.Context, id uint) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// GetByUsername returns the user with the given username, or ErrUserNotFound.
+func (r *MemoryUserRepository) GetByUsername(ctx context.Context, username string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrUserNotFound
+}
+
+// SaveRefreshToken stores the hash of a refresh token issued to a user.
+func (r *MemoryUserRepository) SaveRefreshToken(ctx context.Context, userID uint, tokenHash string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refreshTokens[tokenHash] = refreshToken{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+// ConsumeRefreshToken deletes a valid refresh token and returns the ID of the user it was issued to,
+// or ErrRefreshTokenNotFound.
+func (r *MemoryUserRepository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.refreshTokens[tokenHash]
+	if !ok {
+		return 0, ErrRefreshTokenNotFound
+	}
+	delete(r.refreshTokens, tokenHash)
+	if !time.Now().Before(token.expiresAt) {
+		return 0, ErrRefreshTokenNotFound
+	}
+	return token.userID, nil
+}
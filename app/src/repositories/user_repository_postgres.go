@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/emso-c/konzek-go-assignment/src/models"
+	"github.com/lib/pq"
+)
+
+// userColumns is the list of columns selected for a user, in models.User field order.
+const userColumns = "id, username, password_hash, role, created_at"
+
+// uniqueViolation is the PostgreSQL error code of a unique constraint violation.
+const uniqueViolation = "23505"
+
+// PostgresUserRepository is a UserRepository backed by a PostgreSQL database.
+type PostgresUserRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresUserRepository creates a new PostgresUserRepository using the given database connection.
+func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
+	return &PostgresUserRepository{db: db}
+}
+
+// scanUser scans a single user row in userColumns order.
+func scanUser(s scanner) (models.User, error) {
+	var user models.User
+	err := s.Scan(&user.Id, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, ErrUserNotFound
+	}
+	return user, err
+}
+
+// Create stores a new user and returns it with its generated fields set, or ErrUserExists.
+func (r *PostgresUserRepository) Create(ctx context.Context, username string, passwordHash string) (models.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx,
+		"INSERT INTO users (username, password_hash) VALUES ($1, $2) RETURNING "+userColumns,
+		username, passwordHash,
+	))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return models.User{}, ErrUserExists
+	}
+	return user, err
+}
+
+// Get returns the user with the given ID, or ErrUserNotFound.
+func (r *PostgresUserRepository) Get(ctx context.Context, id uint) (models.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", id))
+}
+
+// GetByUsername returns the user with the given username, or ErrUserNotFound.
+func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username string) (models.User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE username = $1", username))
+}
+
+// SaveRefreshToken stores the hash of a refresh token issued to a user.
+func (r *PostgresUserRepository) SaveRefreshToken(ctx context.Context, userID uint, tokenHash string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO refresh_tokens (token_hash, user_id, expires_at) VALUES ($1, $2, $3)",
+		tokenHash, userID, expiresAt,
+	)
+	return err
+}
+
+// ConsumeRefreshToken deletes a valid refresh token and returns the ID of the user it was issued to,
+// or ErrRefreshTokenNotFound.
+func (r *PostgresUserRepository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (uint, error) {
+	var userID uint
+	err := r.db.QueryRowContext(ctx,
+		"DELETE FROM refresh_tokens WHERE token_hash = $1 AND expires_at > CURRENT_TIMESTAMP RETURNING user_id",
+		tokenHash,
+	).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrRefreshTokenNotFound
+	}
+	return userID, err
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostgresUserRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewPostgresUserRepository(db)
+	now := time.Now()
+	userRows := []string{"id", "username", "password_hash", "role", "created_at"}
+
+	// Create
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (username, password_hash) VALUES ($1, $2)")).
+		WithArgs("alice", "hash").
+		WillReturnRows(sqlmock.NewRows(userRows).AddRow(1, "alice", "hash", "user", now))
+	user, err := repo.Create(context.Background(), "alice", "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), user.Id)
+
+	// Create duplicate
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (username, password_hash) VALUES ($1, $2)")).
+		WithArgs("alice", "hash").
+		WillReturnError(&pq.Error{Code: uniqueViolation})
+	_, err = repo.Create(context.Background(), "alice", "hash")
+	assert.ErrorIs(t, err, ErrUserExists)
+
+	// GetByUsername unknown user
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT " + userColumns + " FROM users WHERE username = $1")).
+		WithArgs("bob").
+		WillReturnRows(sqlmock.NewRows(userRows))
+	_, err = repo.GetByUsername(context.Background(), "bob")
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	// Refresh tokens
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO refresh_tokens (token_hash, user_id, expires_at) VALUES ($1, $2, $3)")).
+		WithArgs("token-hash", 1, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.SaveRefreshToken(context.Background(), 1, "token-hash", now))
+
+	mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM refresh_tokens WHERE token_hash = $1")).
+		WithArgs("token-hash").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	userID, err := repo.ConsumeRefreshToken(context.Background(), "token-hash")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), userID)
+
+	mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM refresh_tokens WHERE token_hash = $1")).
+		WithArgs("token-hash").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	_, err = repo.ConsumeRefreshToken(context.Background(), "token-hash")
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMemoryUserRepository(t *testing.T) {
+	repo := NewMemoryUserRepository()
+
+	user, err := repo.Create(context.Background(), "alice", "hash")
+	assert.NoError(t, err)
+	_, err = repo.Create(context.Background(), "alice", "other")
+	assert.ErrorIs(t, err, ErrUserExists)
+
+	found, err := repo.GetByUsername(context.Background(), "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, user.Id, found.Id)
+	_, err = repo.Get(context.Background(), 42)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+
+	assert.NoError(t, repo.SaveRefreshToken(context.Background(), user.Id, "valid", time.Now().Add(time.Hour)))
+	assert.NoError(t, repo.SaveRefreshToken(context.Background(), user.Id, "expired", time.Now().Add(-time.Hour)))
+
+	userID, err := repo.ConsumeRefreshToken(context.Background(), "valid")
+	assert.NoError(t, err)
+	assert.Equal(t, user.Id, userID)
+	_, err = repo.ConsumeRefreshToken(context.Background(), "valid")
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+	_, err = repo.ConsumeRefreshToken(context.Background(), "expired")
+	assert.ErrorIs(t, err, ErrRefreshTokenNotFound)
+}
@@ -5,12 +5,5 @@ WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = 'demo_db');
 -- Use the newly created or existing database
 \c demo_db
 
--- Create table if it does not exist
-CREATE TABLE IF NOT EXISTS tasks (
-    id SERIAL PRIMARY KEY,
-    title TEXT,
-    description TEXT,
-    status TEXT,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-);
+-- The schema is created and upgraded by the application's migrations,
+-- see app/src/database/migrations. Run `app migrate status` to list them.